@@ -8,7 +8,16 @@ import (
 	"back-minijira-petproject1/internal/repository"
 	"back-minijira-petproject1/internal/service"
 	"back-minijira-petproject1/internal/transport"
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	// Часовые пояса пользователей не должны зависеть от tzdata в образе
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,7 +28,7 @@ func main() {
 	db := config.SetUpDatabaseConnection(logger)
 
 	// db.Migrator().DropTable(&models.User{})
-	if err := db.AutoMigrate(&models.Project{}, &models.Task{}, &models.User{}, &models.ChatMessage{}, &models.Team{}); err != nil {
+	if err := db.AutoMigrate(&models.Project{}, &models.Task{}, &models.TaskStatusChange{}, &models.User{}, &models.ChatMessage{}, &models.Team{}, &models.ChatReaction{}, &models.ChatReadMarker{}, &models.DirectConversation{}, &models.Notification{}, &models.NotificationSettings{}, &models.NotificationPreference{}, &models.ProjectMute{}, &models.CalendarToken{}, &models.ChatOpsIntegration{}, &models.EmailOutbox{}, &models.Webhook{}, &models.WebhookDelivery{}, &models.Worklog{}, &models.ReportSnapshot{}); err != nil {
 		logger.Error("ошибка при выполнении автомиграции", "error", err)
 		panic(fmt.Sprintf("не удалось выполнит миграции:%v", err))
 	}
@@ -30,24 +39,84 @@ func main() {
 	reportRepo := repository.NewReportRepository(db, logger)
 	chatRepo := repository.NewChatRepositoryGorm(db)
 	teamRepo := repository.NewTeamRepository(db, logger)
+	notificationRepo := repository.NewNotificationRepository(db, logger)
+	emailOutboxRepo := repository.NewEmailOutboxRepository(db, logger)
+	digestRepo := repository.NewDigestRepository(db, logger)
+	webhookRepo := repository.NewWebhookRepository(db, logger)
+	notificationSettingsRepo := repository.NewNotificationSettingsRepository(db, logger)
+	calendarRepo := repository.NewCalendarRepository(db, logger)
+	chatOpsRepo := repository.NewChatOpsRepository(db, logger)
+	worklogRepo := repository.NewWorklogRepository(db, logger)
+	reportSnapshotRepo := repository.NewReportSnapshotRepository(db, logger)
 
-	projectService := service.NewProjectService(db, logger, projectRepo)
-	taskService := service.NewTaskService(db, logger, taskRepo, projectRepo)
-	userService := service.NewUserService(userRepo, db, logger)
+	mailer, err := service.NewMailerFromEnv(logger)
+	if err != nil {
+		logger.Error("ошибка настройки почтового транспорта", "error", err)
+		panic(fmt.Sprintf("не удалось настроить отправку почты:%v", err))
+	}
+	emailTemplates, err := service.LoadEmailTemplates()
+	if err != nil {
+		logger.Error("ошибка загрузки шаблонов писем", "error", err)
+		panic(fmt.Sprintf("не удалось загрузить шаблоны писем:%v", err))
+	}
+	emailService := service.NewEmailService(mailer, emailTemplates, emailOutboxRepo)
+	notificationSettingsService := service.NewNotificationSettingsService(db, notificationSettingsRepo, logger)
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, notificationSettingsService, emailService, logger)
+	webhookService := service.NewWebhookService(webhookRepo, projectRepo, logger)
+	chatOpsService := service.NewChatOpsService(chatOpsRepo, projectRepo, userRepo, chatRepo, logger)
+	reportSnapshotService := service.NewReportSnapshotService(reportSnapshotRepo, reportRepo, logger)
+	events := service.MultiPublisher{webhookService, chatOpsService, reportSnapshotService}
+	projectService := service.NewProjectService(db, logger, projectRepo, events)
+	taskService := service.NewTaskService(db, logger, taskRepo, projectRepo, notificationService, events)
+	userService := service.NewUserService(userRepo, db, logger, notificationService)
 	reportService := service.NewReportService(reportRepo, logger)
-	chatService := service.NewChatService(chatRepo, logger)
-	authService := service.NewAuthService(userRepo, logger)
-	teamService := service.NewTeamService(teamRepo, logger)
+	chatService := service.NewChatService(chatRepo, notificationService, events, logger)
+	emailOutboxService := service.NewEmailOutboxService(emailOutboxRepo, emailService, logger)
+	authService := service.NewAuthService(db, userRepo, emailService, logger)
+	teamService := service.NewTeamService(teamRepo, notificationService, logger)
+	chatOpsCommandService := service.NewChatOpsCommandService(chatOpsRepo, taskService, logger)
+	calendarService := service.NewCalendarService(calendarRepo, userRepo, notificationSettingsService, logger)
+	worklogService := service.NewWorklogService(db, worklogRepo, taskRepo, logger)
+	digestService := service.NewDigestService(db, digestRepo, notificationSettingsService, emailService, logger)
+
+	// Фоновые воркеры останавливаются вместе с сервером по SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go emailOutboxService.Run(ctx)
+	go digestService.Run(ctx)
+	go webhookService.Run(ctx)
+	go chatOpsService.Run(ctx)
+	go reportSnapshotService.Run(ctx)
+
+	// Ответы на письма по почте включаются, если MTA складывает входящие в INBOUND_MAILDIR
+	if dir := os.Getenv("INBOUND_MAILDIR"); dir != "" {
+		inboundEmailService := service.NewInboundEmailService(dir, userRepo, chatService, logger)
+		go inboundEmailService.Run(ctx)
+	}
+
 	r := gin.Default()
 
 	// Добавляем CORS middleware
 	r.Use(middleware.CORS())
 
 	transport.RegisterRoutes(
-		r, logger, taskService, projectService, reportService, chatService, userService, authService, userRepo, teamService,
+		r, logger, taskService, projectService, reportService, chatService, userService, authService, userRepo, teamService, notificationService, notificationSettingsService, emailOutboxService, webhookService, calendarService, chatOpsService, chatOpsCommandService, worklogService, reportSnapshotService,
 	)
 
+	srv := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("ошибка при остановке сервера", "error", err)
+		}
+	}()
+
 	logger.Info("Server running on :8080")
 
-	r.Run(":8080")
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("ошибка работы сервера", "error", err)
+	}
 }
@@ -45,6 +45,17 @@ const authAPI = {
         });
         return response;
     },
+
+    async resetPassword(token, password) {
+        const response = await fetch(`${API_BASE_URL}/auth/password/reset`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify({ token, password }),
+        });
+        return response;
+    },
 };
 
 // Projects API
@@ -614,6 +625,34 @@ async function handleVerifyEmail(e) {
     }
 }
 
+async function handleResetPassword(e) {
+    e.preventDefault();
+    clearErrors();
+
+    const token = document.getElementById('reset-token').value;
+    const password = document.getElementById('reset-password').value;
+
+    try {
+        const response = await authAPI.resetPassword(token, password);
+        const data = await response.json();
+
+        if (response.ok) {
+            showSuccess('reset-success', 'Пароль изменён. Теперь вы можете войти.');
+            document.getElementById('reset-form-element').reset();
+            // Убираем токен из адреса, чтобы ссылка не осталась в истории браузера
+            history.replaceState(null, '', window.location.pathname);
+            setTimeout(() => {
+                document.getElementById('reset-form').style.display = 'none';
+                document.getElementById('login-form').style.display = 'block';
+            }, 2000);
+        } else {
+            showError('reset-error', data.error || 'Ссылка недействительна или устарела');
+        }
+    } catch (error) {
+        showError('reset-error', 'Ошибка соединения с сервером');
+    }
+}
+
 function handleLogout() {
     localStorage.removeItem('token');
     currentUser = null;
@@ -2898,6 +2937,16 @@ document.addEventListener('DOMContentLoaded', () => {
     document.getElementById('register-form-element').addEventListener('submit', handleRegister);
     document.getElementById('login-form-element').addEventListener('submit', handleLogin);
     document.getElementById('verify-form-element').addEventListener('submit', handleVerifyEmail);
+    document.getElementById('reset-form-element').addEventListener('submit', handleResetPassword);
+
+    // Ссылка из письма о сбросе пароля ведёт сюда с ?reset_token=...
+    const resetToken = new URLSearchParams(window.location.search).get('reset_token');
+    if (resetToken) {
+        showAuth();
+        document.getElementById('login-form').style.display = 'none';
+        document.getElementById('reset-token').value = resetToken;
+        document.getElementById('reset-form').style.display = 'block';
+    }
 
     // Auth navigation
     document.getElementById('show-register').addEventListener('click', (e) => {
@@ -2923,6 +2972,13 @@ document.addEventListener('DOMContentLoaded', () => {
         document.getElementById('login-form').style.display = 'block';
     });
 
+    document.getElementById('reset-back-to-login').addEventListener('click', (e) => {
+        e.preventDefault();
+        clearErrors();
+        document.getElementById('reset-form').style.display = 'none';
+        document.getElementById('login-form').style.display = 'block';
+    });
+
     // Logout
     document.getElementById('logout-btn').addEventListener('click', handleLogout);
 
@@ -24,7 +24,9 @@ func SetUpDatabaseConnection(logger *slog.Logger) *gorm.DB {
 
 logger.Info("server started addr=:%v env=local", "addr",dbPort,"env","local")
 
-	dsn := fmt.Sprintf("host=%v user=%v password=%v dbname=%v port=%v", dbHost, dbUser, dbPass, dbName, dbPort)
+	// Сессия всегда в UTC: отчёты шагают по дням и месяцам через timestamptz, и локальный
+	// часовой пояс сервера с переходом на летнее время сдвигал бы границы интервалов
+	dsn := fmt.Sprintf("host=%v user=%v password=%v dbname=%v port=%v TimeZone=UTC", dbHost, dbUser, dbPass, dbName, dbPort)
 
 	db, err := gorm.Open(postgres.New(postgres.Config{
 		DSN:                  dsn,
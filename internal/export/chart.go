@@ -0,0 +1,133 @@
+package export
+
+import (
+	"fmt"
+	"math"
+)
+
+// chartPalette — цвета рядов в RGB 0..1, по кругу
+var chartPalette = [][3]float64{
+	{0.22, 0.47, 0.80},
+	{0.85, 0.37, 0.01},
+	{0.30, 0.65, 0.30},
+	{0.55, 0.35, 0.70},
+	{0.50, 0.50, 0.50},
+}
+
+// chartMaxLabels — сколько подписей оси X помещается без наложения
+const chartMaxLabels = 12
+
+// drawChart рисует линейный или столбчатый график в прямоугольнике (x, y) — (x+w, y+h)
+func (p *pdfWriter) drawChart(chart Chart, x, y, w, h float64) {
+	p.text(x, y+h+4, 10, true, chart.Title)
+
+	// Поля под подписи осей и легенду
+	plotX, plotY := x+40, y+14
+	plotW, plotH := w-40, h-28
+
+	lo, hi := 0.0, 0.0
+	for _, s := range chart.Series {
+		for _, v := range s.Values {
+			if !math.IsNaN(v) {
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+		}
+	}
+	hi = niceCeil(hi)
+	if hi <= lo {
+		hi = lo + 1
+	}
+	scale := func(v float64) float64 { return plotY + (v-lo)/(hi-lo)*plotH }
+
+	// Сетка и подписи оси Y
+	const gridLines = 5
+	for i := 0; i <= gridLines; i++ {
+		v := lo + (hi-lo)*float64(i)/gridLines
+		gy := scale(v)
+		fmt.Fprintf(&p.page, "0.85 G 0.3 w %.2f %.2f m %.2f %.2f l S\n", plotX, gy, plotX+plotW, gy)
+		label := formatAxis(v)
+		p.text(plotX-4-p.textWidth(label, 6), gy-2, 6, false, label)
+	}
+	fmt.Fprintf(&p.page, "0 G 0.6 w %.2f %.2f m %.2f %.2f l %.2f %.2f l S\n", plotX, plotY+plotH, plotX, plotY, plotX+plotW, plotY)
+
+	n := len(chart.Labels)
+	if n == 0 {
+		return
+	}
+
+	// Подписи оси X: не больше chartMaxLabels, равномерно
+	slot := plotW / float64(n)
+	step := (n + chartMaxLabels - 1) / chartMaxLabels
+	for i := 0; i < n; i += step {
+		label := p.fitText(chart.Labels[i], slot*float64(step)-2, 6, false)
+		cx := plotX + slot*(float64(i)+0.5)
+		if chart.Kind == LineChart && n > 1 {
+			cx = plotX + plotW*float64(i)/float64(n-1)
+		}
+		p.text(cx-p.textWidth(label, 6)/2, plotY-9, 6, false, label)
+	}
+
+	for si, s := range chart.Series {
+		c := chartPalette[si%len(chartPalette)]
+		switch chart.Kind {
+		case BarChart:
+			barW := slot * 0.8 / float64(len(chart.Series))
+			fmt.Fprintf(&p.page, "%.2f %.2f %.2f rg\n", c[0], c[1], c[2])
+			for i, v := range s.Values {
+				if i >= n || math.IsNaN(v) {
+					continue
+				}
+				bx := plotX + slot*float64(i) + slot*0.1 + barW*float64(si)
+				fmt.Fprintf(&p.page, "%.2f %.2f %.2f %.2f re f\n", bx, scale(0), barW, scale(v)-scale(0))
+			}
+		default:
+			fmt.Fprintf(&p.page, "%.2f %.2f %.2f RG 1.2 w\n", c[0], c[1], c[2])
+			open := false
+			for i, v := range s.Values {
+				if i >= n || math.IsNaN(v) {
+					open = false
+					continue
+				}
+				px := plotX
+				if n > 1 {
+					px += plotW * float64(i) / float64(n-1)
+				}
+				op := "l"
+				if !open {
+					op = "m"
+					open = true
+				}
+				fmt.Fprintf(&p.page, "%.2f %.2f %s\n", px, scale(v), op)
+			}
+			p.page.WriteString("S\n")
+		}
+
+		// Легенда справа над графиком
+		lx := x + w - float64(len(chart.Series)-si)*110
+		fmt.Fprintf(&p.page, "%.2f %.2f %.2f rg %.2f %.2f 8 8 re f\n", c[0], c[1], c[2], lx, y+h+4)
+		p.page.WriteString("0 g\n")
+		p.text(lx+11, y+h+5, 7, false, p.fitText(s.Name, 95, 7, false))
+	}
+	p.page.WriteString("0 G 0 g\n")
+}
+
+// niceCeil округляет максимум оси вверх до 1, 2 или 5 × 10^k, чтобы деления были круглыми
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 0
+	}
+	mag := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if v <= m*mag {
+			return m * mag
+		}
+	}
+	return 10 * mag
+}
+
+func formatAxis(v float64) string {
+	if v == math.Trunc(v) {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.1f", v)
+}
@@ -0,0 +1,64 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvFlushEvery — раз во сколько строк сбрасывать буфер в ответ
+const csvFlushEvery = 500
+
+// WriteCSV пишет листы подряд: у каждого строка заголовков, между листами пустая строка.
+// Файл начинается с BOM, чтобы Excel открыл кириллицу в UTF-8.
+func WriteCSV(w io.Writer, doc Document) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	for i, sheet := range doc.Sheets {
+		if i > 0 {
+			if err := cw.Write(nil); err != nil {
+				return err
+			}
+		}
+		if len(doc.Sheets) > 1 {
+			if err := cw.Write([]string{sheet.Title}); err != nil {
+				return err
+			}
+		}
+
+		header := make([]string, len(sheet.Columns))
+		for j, col := range sheet.Columns {
+			header[j] = col.Title
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+
+		written := 0
+		err := sheet.Rows(func(row []any) error {
+			record := make([]string, len(sheet.Columns))
+			for j := range record {
+				if j < len(row) {
+					record[j] = formatText(row[j], sheet.Columns[j].Type)
+				}
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+			written++
+			if written%csvFlushEvery == 0 {
+				cw.Flush()
+				return cw.Error()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,155 @@
+package export
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// ColumnType определяет, как значение колонки записывается в XLSX и выравнивается в PDF
+type ColumnType int
+
+const (
+	Text ColumnType = iota
+	Integer
+	Number
+	Date
+	DateTime
+)
+
+type Column struct {
+	Title string
+	Type  ColumnType
+}
+
+// RowFunc отдаёт строки листа по одной. Значения: string, int, int64, uint, float64,
+// time.Time, указатели на них или nil для пустой ячейки.
+type RowFunc func(yield func(row []any) error) error
+
+type Sheet struct {
+	Title   string
+	Columns []Column
+	Rows    RowFunc
+}
+
+type ChartKind int
+
+const (
+	LineChart ChartKind = iota
+	BarChart
+)
+
+// Series — ряд графика; NaN в Values означает пропуск точки
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+type Chart struct {
+	Title  string
+	Kind   ChartKind
+	Labels []string
+	Series []Series
+}
+
+// Document — выгружаемый отчёт. Графики рисуются только в PDF;
+// табличные форматы получают те же данные в листах.
+type Document struct {
+	Title  string
+	Sheets []Sheet
+	Charts []Chart
+}
+
+// Rows превращает срез в RowFunc для данных, которые уже в памяти
+func Rows[T any](items []T, row func(T) []any) RowFunc {
+	return func(yield func([]any) error) error {
+		for _, item := range items {
+			if err := yield(row(item)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Float возвращает значение или NaN для пустого указателя — так пропуски попадают в ряды графиков
+func Float(v *float64) float64 {
+	if v == nil {
+		return math.NaN()
+	}
+	return *v
+}
+
+// deref снимает указатель; nil-указатель становится nil
+func deref(v any) any {
+	switch p := v.(type) {
+	case *string:
+		if p == nil {
+			return nil
+		}
+		return *p
+	case *int:
+		if p == nil {
+			return nil
+		}
+		return *p
+	case *int64:
+		if p == nil {
+			return nil
+		}
+		return *p
+	case *uint:
+		if p == nil {
+			return nil
+		}
+		return *p
+	case *float64:
+		if p == nil {
+			return nil
+		}
+		return *p
+	case *time.Time:
+		if p == nil {
+			return nil
+		}
+		return *p
+	}
+	return v
+}
+
+// number приводит числовое значение к float64
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case float64:
+		return n, !math.IsNaN(n) && !math.IsInf(n, 0)
+	}
+	return 0, false
+}
+
+// formatText — текстовое представление ячейки для CSV и PDF
+func formatText(v any, typ ColumnType) string {
+	switch x := deref(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case time.Time:
+		if typ == Date {
+			return x.Format("2006-01-02")
+		}
+		return x.UTC().Format(time.RFC3339)
+	case bool:
+		return strconv.FormatBool(x)
+	default:
+		if n, ok := number(x); ok {
+			return strconv.FormatFloat(n, 'f', -1, 64)
+		}
+		return ""
+	}
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+	FormatPDF  Format = "pdf"
+)
+
+const (
+	MIMECSV  = "text/csv"
+	MIMEXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	MIMEPDF  = "application/pdf"
+)
+
+var ErrUnknownFormat = errors.New("format must be json, csv, xlsx or pdf")
+
+// ParseFormat разбирает ?format=; пустое значение — JSON
+func ParseFormat(value string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(value))); f {
+	case "":
+		return FormatJSON, nil
+	case FormatJSON, FormatCSV, FormatXLSX, FormatPDF:
+		return f, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// FormatForMIME сопоставляет тип из заголовка Accept с форматом выгрузки
+func FormatForMIME(mime string) Format {
+	switch mime {
+	case MIMECSV:
+		return FormatCSV
+	case MIMEXLSX:
+		return FormatXLSX
+	case MIMEPDF:
+		return FormatPDF
+	default:
+		return FormatJSON
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return MIMECSV + "; charset=utf-8"
+	case FormatXLSX:
+		return MIMEXLSX
+	case FormatPDF:
+		return MIMEPDF
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// Write выгружает документ в формате f. Строки листов читаются по одной,
+// поэтому в памяти не держится весь документ — только текущая страница PDF.
+func Write(w io.Writer, f Format, doc Document) error {
+	switch f {
+	case FormatCSV:
+		return WriteCSV(w, doc)
+	case FormatXLSX:
+		return WriteXLSX(w, doc)
+	case FormatPDF:
+		return WritePDF(w, doc)
+	default:
+		return ErrUnknownFormat
+	}
+}
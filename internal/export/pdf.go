@@ -0,0 +1,372 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	_ "embed"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// Страница A4 в альбомной ориентации, размеры в пунктах
+const (
+	pdfPageWidth  = 842.0
+	pdfPageHeight = 595.0
+	pdfMargin     = 36.0
+	pdfFontSize   = 8.0
+	pdfRowHeight  = 12.0
+	pdfChartSize  = 190.0
+)
+
+// Зарезервированные номера объектов: каталог, дерево страниц и шрифты пишутся в конце,
+// когда известны список страниц и использованные глифы
+const (
+	pdfCatalogObj = 1
+	pdfPagesObj   = 2
+	pdfFontObj    = 3
+	pdfBoldObj    = 4
+)
+
+// DejaVu Sans — свободный шрифт с кириллицей; в PDF встраивается только подмножество
+// глифов, которые встретились в документе. Лицензия — fonts/LICENSE.
+var (
+	//go:embed fonts/DejaVuSans.ttf
+	dejaVuSans []byte
+	//go:embed fonts/DejaVuSans-Bold.ttf
+	dejaVuSansBold []byte
+)
+
+// loadPDFFonts разбирает встроенные шрифты один раз на процесс: обычное и жирное начертания
+var loadPDFFonts = sync.OnceValues(func() ([2]*ttfFont, error) {
+	regular, err := parseTTF("DejaVuSans", dejaVuSans)
+	if err != nil {
+		return [2]*ttfFont{}, err
+	}
+	bold, err := parseTTF("DejaVuSans-Bold", dejaVuSansBold)
+	if err != nil {
+		return [2]*ttfFont{}, err
+	}
+	return [2]*ttfFont{regular, bold}, nil
+})
+
+// pdfFont — шрифт документа и глифы, которые в нём использованы (для подмножества и ToUnicode)
+type pdfFont struct {
+	ttf  *ttfFont
+	obj  int
+	used map[uint16]rune
+}
+
+// pdfWriter пишет PDF потоково: каждая страница уходит в w, как только заполнена,
+// в памяти остаются только смещения объектов для таблицы xref
+type pdfWriter struct {
+	w       *bufio.Writer
+	offset  int64
+	offsets []int64
+	pages   []int
+	page    bytes.Buffer
+	fonts   [2]*pdfFont
+	// y — текущая позиция сверху вниз в координатах PDF (ноль внизу страницы)
+	y   float64
+	err error
+}
+
+// WritePDF рисует заголовок, графики документа и его листы таблицами.
+// Текст набирается встроенным DejaVu Sans, поэтому кириллица выводится как есть.
+func WritePDF(w io.Writer, doc Document) error {
+	fonts, err := loadPDFFonts()
+	if err != nil {
+		return fmt.Errorf("load pdf fonts: %w", err)
+	}
+
+	p := &pdfWriter{w: bufio.NewWriter(w), offsets: make([]int64, pdfBoldObj+1)}
+	p.fonts[0] = &pdfFont{ttf: fonts[0], obj: pdfFontObj, used: map[uint16]rune{}}
+	p.fonts[1] = &pdfFont{ttf: fonts[1], obj: pdfBoldObj, used: map[uint16]rune{}}
+	p.raw("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	p.newPage()
+	p.text(pdfMargin, p.y-14, 14, true, doc.Title)
+	p.y -= 28
+
+	for _, chart := range doc.Charts {
+		if p.y-pdfChartSize-24 < pdfMargin {
+			p.newPage()
+		}
+		p.drawChart(chart, pdfMargin, p.y-pdfChartSize-12, pdfPageWidth-2*pdfMargin, pdfChartSize)
+		p.y -= pdfChartSize + 36
+	}
+
+	for _, sheet := range doc.Sheets {
+		if err := p.drawTable(sheet); err != nil {
+			return err
+		}
+		p.y -= pdfRowHeight
+	}
+
+	return p.finish()
+}
+
+func (p *pdfWriter) drawTable(sheet Sheet) error {
+	widths := columnWidths(sheet.Columns, pdfPageWidth-2*pdfMargin)
+
+	header := func() {
+		p.text(pdfMargin, p.y-pdfFontSize-2, 10, true, sheet.Title)
+		p.y -= pdfRowHeight + 6
+		p.cells(sheet.Columns, widths, nil, true)
+	}
+	if p.y-3*pdfRowHeight < pdfMargin {
+		p.newPage()
+	}
+	header()
+
+	return sheet.Rows(func(row []any) error {
+		if p.y-pdfRowHeight < pdfMargin {
+			p.newPage()
+			header()
+		}
+		p.cells(sheet.Columns, widths, row, false)
+		return p.err
+	})
+}
+
+// cells пишет строку таблицы; row == nil — строка заголовков
+func (p *pdfWriter) cells(columns []Column, widths []float64, row []any, bold bool) {
+	x := pdfMargin
+	baseline := p.y - pdfFontSize - 1
+	for i, col := range columns {
+		value := col.Title
+		if row != nil {
+			value = ""
+			if i < len(row) {
+				value = formatText(row[i], col.Type)
+			}
+		}
+		value = p.fitText(value, widths[i]-4, pdfFontSize, bold)
+
+		tx := x + 2
+		if row != nil && col.Type != Text {
+			tx = x + widths[i] - 2 - p.textWidth(value, pdfFontSize)
+		}
+		p.text(tx, baseline, pdfFontSize, bold, value)
+		x += widths[i]
+	}
+	if bold {
+		fmt.Fprintf(&p.page, "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, p.y-pdfRowHeight, pdfPageWidth-pdfMargin, p.y-pdfRowHeight)
+	}
+	p.y -= pdfRowHeight
+}
+
+// columnWidths делит ширину между колонками: текстовым достаётся больше места
+func columnWidths(columns []Column, total float64) []float64 {
+	weights := make([]float64, len(columns))
+	sum := 0.0
+	for i, col := range columns {
+		switch col.Type {
+		case Text:
+			weights[i] = 3
+		case DateTime:
+			weights[i] = 1.8
+		case Date:
+			weights[i] = 1.2
+		default:
+			weights[i] = 1
+		}
+		sum += weights[i]
+	}
+	widths := make([]float64, len(columns))
+	for i := range weights {
+		widths[i] = total * weights[i] / sum
+	}
+	return widths
+}
+
+func (p *pdfWriter) font(bold bool) *pdfFont {
+	if bold {
+		return p.fonts[1]
+	}
+	return p.fonts[0]
+}
+
+// text пишет строку номерами глифов (кодировка Identity-H, по два байта на глиф)
+func (p *pdfWriter) text(x, y, size float64, bold bool, s string) {
+	resource := "F1"
+	if bold {
+		resource = "F2"
+	}
+	font := p.font(bold)
+
+	fmt.Fprintf(&p.page, "BT /%s %.1f Tf %.2f %.2f Td <", resource, size, x, y)
+	for _, r := range s {
+		if r == '\n' || r == '\r' || r == '\t' {
+			r = ' '
+		}
+		gid := font.ttf.glyph(r)
+		if _, ok := font.used[gid]; !ok {
+			font.used[gid] = r
+		}
+		fmt.Fprintf(&p.page, "%04X", gid)
+	}
+	p.page.WriteString("> Tj ET\n")
+}
+
+func (p *pdfWriter) newPage() {
+	if len(p.pages) > 0 || p.page.Len() > 0 {
+		p.flushPage()
+	}
+	p.y = pdfPageHeight - pdfMargin
+}
+
+func (p *pdfWriter) flushPage() {
+	content := p.newObject()
+	p.object(content, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.page.Len(), p.page.String()))
+	p.page.Reset()
+
+	page := p.newObject()
+	p.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] "+
+		"/Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPagesObj, pdfPageWidth, pdfPageHeight, pdfFontObj, pdfBoldObj, content))
+	p.pages = append(p.pages, page)
+}
+
+func (p *pdfWriter) finish() error {
+	p.flushPage()
+	for _, font := range p.fonts {
+		p.writeFont(font)
+	}
+
+	kids := make([]string, len(p.pages))
+	for i, page := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	p.object(pdfPagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	p.object(pdfCatalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesObj))
+
+	xref := p.offset
+	p.raw(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(p.offsets)))
+	for _, off := range p.offsets[1:] {
+		p.raw(fmt.Sprintf("%010d 00000 n \n", off))
+	}
+	p.raw(fmt.Sprintf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(p.offsets), pdfCatalogObj, xref))
+
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
+
+// writeFont встраивает подмножество шрифта как составной шрифт Type0 с CIDFontType2.
+// ToUnicode нужен, чтобы текст из PDF можно было скопировать и найти поиском.
+func (p *pdfWriter) writeFont(font *pdfFont) {
+	f := font.ttf
+	name := fmt.Sprintf("MJAAA%c+%s", 'A'+font.obj, f.name)
+	scale := func(v int) int { return v * 1000 / f.unitsPerEm }
+
+	gids := make([]uint16, 0, len(font.used))
+	for gid := range font.used {
+		gids = append(gids, gid)
+	}
+	slices.Sort(gids)
+
+	used := make(map[uint16]bool, len(gids))
+	var widths strings.Builder
+	for _, gid := range gids {
+		used[gid] = true
+		fmt.Fprintf(&widths, "%d [%d] ", gid, f.glyphWidth(gid))
+	}
+
+	sfnt := f.subset(used)
+	var packed bytes.Buffer
+	zw := zlib.NewWriter(&packed)
+	zw.Write(sfnt)
+	zw.Close()
+
+	file := p.newObject()
+	p.object(file, fmt.Sprintf("<< /Length %d /Length1 %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+		packed.Len(), len(sfnt), packed.String()))
+
+	descriptor := p.newObject()
+	p.object(descriptor, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] "+
+		"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, scale(f.bbox[0]), scale(f.bbox[1]), scale(f.bbox[2]), scale(f.bbox[3]),
+		scale(f.ascent), scale(f.descent), scale(f.ascent), file))
+
+	cmap := toUnicodeCMap(gids, font.used)
+	toUnicode := p.newObject()
+	p.object(toUnicode, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(cmap), cmap))
+
+	cid := p.newObject()
+	p.object(cid, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+		"/FontDescriptor %d 0 R /W [%s] /CIDToGIDMap /Identity >>", name, descriptor, widths.String()))
+
+	p.object(font.obj, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H "+
+		"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", name, cid, toUnicode))
+}
+
+// toUnicodeCMap сопоставляет глифам символы Unicode (в UTF-16BE), по 100 записей в блоке
+func toUnicodeCMap(gids []uint16, runes map[uint16]rune) string {
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	var entries []string
+	for _, gid := range gids {
+		if gid == 0 {
+			continue
+		}
+		var utf strings.Builder
+		for _, unit := range utf16.Encode([]rune{runes[gid]}) {
+			fmt.Fprintf(&utf, "%04X", unit)
+		}
+		entries = append(entries, fmt.Sprintf("<%04X> <%s>\n", gid, utf.String()))
+	}
+	for chunk := range slices.Chunk(entries, 100) {
+		fmt.Fprintf(&b, "%d beginbfchar\n%sendbfchar\n", len(chunk), strings.Join(chunk, ""))
+	}
+
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.String()
+}
+
+func (p *pdfWriter) newObject() int {
+	p.offsets = append(p.offsets, 0)
+	return len(p.offsets) - 1
+}
+
+func (p *pdfWriter) object(num int, body string) {
+	p.offsets[num] = p.offset
+	p.raw(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", num, body))
+}
+
+func (p *pdfWriter) raw(s string) {
+	if p.err != nil {
+		return
+	}
+	n, err := p.w.WriteString(s)
+	p.offset += int64(n)
+	p.err = err
+}
+
+// fitText обрезает строку с многоточием, чтобы она влезла в ширину ячейки
+func (p *pdfWriter) fitText(s string, width, size float64, bold bool) string {
+	font := p.font(bold).ttf
+	if font.width(s, size) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && font.width(string(r), size)+font.width("…", size) > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
+
+// textWidth — ширина строки обычным начертанием по метрикам шрифта
+func (p *pdfWriter) textWidth(s string, size float64) float64 {
+	return p.fonts[0].ttf.width(s, size)
+}
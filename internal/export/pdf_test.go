@@ -0,0 +1,146 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWritePDFEmbedsCyrillicFont(t *testing.T) {
+	doc := Document{
+		Title: "Отчёт по проекту «Ёлка»",
+		Charts: []Chart{{
+			Title:  "Выполнено",
+			Labels: []string{"Пн", "Вт"},
+			Series: []Series{{Name: "Задачи", Values: []float64{1, 2}}},
+		}},
+		Sheets: []Sheet{{
+			Title:   "Задачи",
+			Columns: []Column{{Title: "Название", Type: Text}, {Title: "Оценка", Type: Number}},
+			Rows: Rows([]string{"Починить вход", "Экспорт (PDF)"}, func(s string) []any {
+				return []any{s, 1.5}
+			}),
+		}},
+	}
+
+	var out bytes.Buffer
+	if err := WritePDF(&out, doc); err != nil {
+		t.Fatalf("WritePDF: %v", err)
+	}
+	pdf := out.String()
+
+	for _, want := range []string{"/Subtype /Type0", "/Encoding /Identity-H", "/CIDToGIDMap /Identity", "/FontFile2", "/ToUnicode"} {
+		if !strings.Contains(pdf, want) {
+			t.Errorf("pdf has no %s", want)
+		}
+	}
+	if strings.Contains(pdf, "Helvetica") {
+		t.Error("pdf still references the built-in Helvetica")
+	}
+
+	fonts, err := loadPDFFonts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Каждая буква кириллицы есть в шрифте и попадает в ToUnicode, то есть текст копируется
+	for _, r := range "ОтчёпоекЁлВыполнЗадичНазвОцтьЭсPDF" {
+		gid := fonts[0].glyph(r)
+		if gid == 0 {
+			gid = fonts[1].glyph(r)
+		}
+		if gid == 0 {
+			t.Fatalf("font has no glyph for %q", r)
+		}
+		entry := fmt.Sprintf("<%04X> <%04X>", gid, r)
+		if !strings.Contains(pdf, entry) {
+			t.Errorf("ToUnicode has no entry %s for %q", entry, r)
+		}
+	}
+
+	checkXref(t, out.Bytes())
+}
+
+// checkXref проверяет, что каждое смещение в таблице xref указывает на начало своего объекта
+func checkXref(t *testing.T, pdf []byte) {
+	t.Helper()
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	var count int
+	fmt.Sscanf(lines[1], "0 %d", &count)
+	for num := 1; num < count; num++ {
+		off, _ := strconv.Atoi(lines[2+num][:10])
+		if prefix := fmt.Sprintf("%d 0 obj\n", num); !bytes.HasPrefix(pdf[off:], []byte(prefix)) {
+			t.Errorf("xref entry for object %d points to %q", num, pdf[off:min(off+20, len(pdf))])
+		}
+	}
+}
+
+func TestSubsetKeepsOnlyUsedGlyphs(t *testing.T) {
+	fonts, err := loadPDFFonts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	font := fonts[0]
+
+	used := map[uint16]bool{font.glyph('Ж'): true, font.glyph('й'): true}
+	data := font.subset(used)
+	if len(data) > len(dejaVuSans)/5 {
+		t.Errorf("subset is %d bytes, full font %d", len(data), len(dejaVuSans))
+	}
+
+	tables := map[string][]byte{}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < n; i++ {
+		rec := data[12+16*i:]
+		off, length := binary.BigEndian.Uint32(rec[8:]), binary.BigEndian.Uint32(rec[12:])
+		table := data[off : off+length]
+		if sum := tableChecksum(table); sum != binary.BigEndian.Uint32(rec[4:]) {
+			t.Errorf("table %s checksum mismatch", rec[:4])
+		}
+		tables[string(rec[:4])] = table
+	}
+
+	loca, glyf := tables["loca"], tables["glyf"]
+	glyph := func(gid uint16) []byte {
+		return glyf[binary.BigEndian.Uint32(loca[4*int(gid):]):binary.BigEndian.Uint32(loca[4*int(gid)+4:])]
+	}
+	for gid := range used {
+		if !bytes.Equal(glyph(gid), padded(font.glyphData(gid))) {
+			t.Errorf("glyph %d differs from the original", gid)
+		}
+	}
+	// «й» в DejaVu составной: его части тоже должны остаться
+	components := compositeComponents(font.glyphData(font.glyph('й')))
+	if len(components) == 0 {
+		t.Fatal("expected й to be a composite glyph")
+	}
+	for _, component := range components {
+		if len(glyph(component)) == 0 {
+			t.Errorf("component glyph %d of й was dropped", component)
+		}
+	}
+	if g := font.glyph('Q'); len(glyph(g)) != 0 {
+		t.Errorf("unused glyph %d was kept", g)
+	}
+
+	head := tables["head"]
+	if binary.BigEndian.Uint16(head[50:]) != 1 {
+		t.Error("subset must use long loca offsets")
+	}
+}
+
+func padded(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
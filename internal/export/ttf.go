@@ -0,0 +1,356 @@
+package export
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var errBadFont = errors.New("export: malformed TrueType font")
+
+// ttfFont — разобранный TrueType-шрифт: ровно то, что нужно, чтобы мерить текст
+// и встраивать в PDF подмножество глифов
+type ttfFont struct {
+	name       string
+	tables     map[string][]byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	bbox       [4]int
+	advances   []uint16
+	loca       []uint32
+	cmap       map[rune]uint16
+}
+
+func parseTTF(name string, data []byte) (*ttfFont, error) {
+	if len(data) < 12 {
+		return nil, errBadFont
+	}
+	f := &ttfFont{name: name, tables: map[string][]byte{}}
+
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, errBadFont
+		}
+		off := binary.BigEndian.Uint32(data[rec+8:])
+		length := binary.BigEndian.Uint32(data[rec+12:])
+		if uint64(off)+uint64(length) > uint64(len(data)) {
+			return nil, errBadFont
+		}
+		f.tables[string(data[rec:rec+4])] = data[off : off+length]
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "loca", "glyf", "cmap"} {
+		if _, ok := f.tables[tag]; !ok {
+			return nil, fmt.Errorf("%w: no %s table", errBadFont, tag)
+		}
+	}
+
+	head, hhea, maxp := f.tables["head"], f.tables["hhea"], f.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errBadFont
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	if f.unitsPerEm == 0 {
+		return nil, errBadFont
+	}
+
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	if err := f.parseMetrics(numGlyphs, int(binary.BigEndian.Uint16(hhea[34:]))); err != nil {
+		return nil, err
+	}
+	if err := f.parseLoca(numGlyphs, binary.BigEndian.Uint16(head[50:]) == 1); err != nil {
+		return nil, err
+	}
+	if err := f.parseCmap(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *ttfFont) parseMetrics(numGlyphs, numMetrics int) error {
+	hmtx := f.tables["hmtx"]
+	if numMetrics == 0 || numMetrics > numGlyphs || len(hmtx) < 4*numMetrics {
+		return errBadFont
+	}
+	f.advances = make([]uint16, numGlyphs)
+	for g := range f.advances {
+		// После numMetrics у глифов та же ширина, что у последнего
+		f.advances[g] = binary.BigEndian.Uint16(hmtx[4*min(g, numMetrics-1):])
+	}
+	return nil
+}
+
+func (f *ttfFont) parseLoca(numGlyphs int, long bool) error {
+	loca := f.tables["loca"]
+	f.loca = make([]uint32, numGlyphs+1)
+	for g := range f.loca {
+		if long {
+			if len(loca) < 4*(g+1) {
+				return errBadFont
+			}
+			f.loca[g] = binary.BigEndian.Uint32(loca[4*g:])
+		} else {
+			if len(loca) < 2*(g+1) {
+				return errBadFont
+			}
+			f.loca[g] = uint32(binary.BigEndian.Uint16(loca[2*g:])) * 2
+		}
+		if g > 0 && f.loca[g] < f.loca[g-1] {
+			return errBadFont
+		}
+	}
+	if int(f.loca[numGlyphs]) > len(f.tables["glyf"]) {
+		return errBadFont
+	}
+	return nil
+}
+
+// parseCmap читает юникодную таблицу символов: формат 12 (весь Unicode) или 4 (только BMP)
+func (f *ttfFont) parseCmap() error {
+	cmap := f.tables["cmap"]
+	if len(cmap) < 4 {
+		return errBadFont
+	}
+
+	var format4, format12 []byte
+	for i := 0; i < int(binary.BigEndian.Uint16(cmap[2:])); i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(cmap) {
+			return errBadFont
+		}
+		platform, encoding := binary.BigEndian.Uint16(cmap[rec:]), binary.BigEndian.Uint16(cmap[rec+2:])
+		off := binary.BigEndian.Uint32(cmap[rec+4:])
+		if platform != 3 || int(off)+4 > len(cmap) {
+			continue
+		}
+		sub := cmap[off:]
+		switch {
+		case encoding == 10 && binary.BigEndian.Uint16(sub) == 12:
+			format12 = sub
+		case encoding == 1 && binary.BigEndian.Uint16(sub) == 4:
+			format4 = sub
+		}
+	}
+
+	f.cmap = map[rune]uint16{}
+	switch {
+	case format12 != nil:
+		if len(format12) < 16 {
+			return errBadFont
+		}
+		groups := int(binary.BigEndian.Uint32(format12[12:]))
+		if len(format12) < 16+12*groups {
+			return errBadFont
+		}
+		for i := 0; i < groups; i++ {
+			g := format12[16+12*i:]
+			start, end, gid := binary.BigEndian.Uint32(g), binary.BigEndian.Uint32(g[4:]), binary.BigEndian.Uint32(g[8:])
+			for r := start; r <= end && r <= 0x10FFFF; r++ {
+				f.cmap[rune(r)] = uint16(gid + r - start)
+			}
+		}
+	case format4 != nil:
+		if len(format4) < 14 {
+			return errBadFont
+		}
+		segs := int(binary.BigEndian.Uint16(format4[6:])) / 2
+		if len(format4) < 16+8*segs {
+			return errBadFont
+		}
+		ends, starts := format4[14:], format4[16+2*segs:]
+		deltas, rangeOffsets := format4[16+4*segs:], format4[16+6*segs:]
+		for i := 0; i < segs; i++ {
+			start, end := int(binary.BigEndian.Uint16(starts[2*i:])), int(binary.BigEndian.Uint16(ends[2*i:]))
+			delta := binary.BigEndian.Uint16(deltas[2*i:])
+			rangeOffset := int(binary.BigEndian.Uint16(rangeOffsets[2*i:]))
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				gid := uint16(c) + delta
+				if rangeOffset != 0 {
+					pos := 16 + 6*segs + 2*i + rangeOffset + 2*(c-start)
+					if pos+2 > len(format4) {
+						return errBadFont
+					}
+					if gid = binary.BigEndian.Uint16(format4[pos:]); gid != 0 {
+						gid += delta
+					}
+				}
+				f.cmap[rune(c)] = gid
+			}
+		}
+	default:
+		return fmt.Errorf("%w: no unicode cmap", errBadFont)
+	}
+	return nil
+}
+
+// glyph возвращает номер глифа для символа; 0 — .notdef, если символа в шрифте нет
+func (f *ttfFont) glyph(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// width — ширина строки в пунктах при данном кегле
+func (f *ttfFont) width(s string, size float64) float64 {
+	units := 0
+	for _, r := range s {
+		units += int(f.advances[f.glyph(r)])
+	}
+	return float64(units) * size / float64(f.unitsPerEm)
+}
+
+// glyphWidth — ширина глифа в тысячных долях кегля, как её ждёт массив /W в PDF
+func (f *ttfFont) glyphWidth(gid uint16) int {
+	return int(f.advances[gid]) * 1000 / f.unitsPerEm
+}
+
+func (f *ttfFont) glyphData(gid uint16) []byte {
+	return f.tables["glyf"][f.loca[gid]:f.loca[gid+1]]
+}
+
+// subset собирает шрифт, где данные есть только у использованных глифов (и у частей
+// составных). Номера глифов сохраняются, поэтому в PDF подходит CIDToGIDMap /Identity,
+// а таблица cmap не нужна.
+func (f *ttfFont) subset(used map[uint16]bool) []byte {
+	keep := map[uint16]bool{}
+	var visit func(gid uint16)
+	visit = func(gid uint16) {
+		if keep[gid] || int(gid) >= len(f.advances) {
+			return
+		}
+		keep[gid] = true
+		for _, component := range compositeComponents(f.glyphData(gid)) {
+			visit(component)
+		}
+	}
+	visit(0)
+	for gid := range used {
+		visit(gid)
+	}
+
+	var glyf []byte
+	loca := make([]byte, 4*len(f.loca))
+	for gid := 0; gid < len(f.advances); gid++ {
+		binary.BigEndian.PutUint32(loca[4*gid:], uint32(len(glyf)))
+		if keep[uint16(gid)] {
+			glyf = append(glyf, f.glyphData(uint16(gid))...)
+			// Глифы выравниваются по 4 байта, как делают генераторы шрифтов
+			for len(glyf)%4 != 0 {
+				glyf = append(glyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(loca[4*len(f.advances):], uint32(len(glyf)))
+
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)  // checkSumAdjustment PDF-просмотрщики не проверяют
+	binary.BigEndian.PutUint16(head[50:], 1) // loca в длинном формате
+
+	tables := map[string][]byte{
+		"head": head,
+		"hhea": f.tables["hhea"],
+		"maxp": f.tables["maxp"],
+		"hmtx": f.tables["hmtx"],
+		"loca": loca,
+		"glyf": glyf,
+	}
+	// Инструкции хинтинга нужны глифам, если они есть в исходном шрифте
+	for _, tag := range []string{"cvt ", "fpgm", "prep"} {
+		if t, ok := f.tables[tag]; ok {
+			tables[tag] = t
+		}
+	}
+	return writeSFNT(tables)
+}
+
+// compositeComponents возвращает глифы, из которых собран составной глиф
+func compositeComponents(glyph []byte) []uint16 {
+	if len(glyph) < 10 || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil
+	}
+
+	const (
+		argsAreWords   = 0x0001
+		haveScale      = 0x0008
+		moreComponents = 0x0020
+		haveXYScale    = 0x0040
+		haveTwoByTwo   = 0x0080
+	)
+
+	var components []uint16
+	for pos := 10; pos+4 <= len(glyph); {
+		flags := binary.BigEndian.Uint16(glyph[pos:])
+		components = append(components, binary.BigEndian.Uint16(glyph[pos+2:]))
+		pos += 4
+		if flags&argsAreWords != 0 {
+			pos += 4
+		} else {
+			pos += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			pos += 2
+		case flags&haveXYScale != 0:
+			pos += 4
+		case flags&haveTwoByTwo != 0:
+			pos += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return components
+}
+
+// writeSFNT собирает файл шрифта из таблиц: каталог по алфавиту тегов, таблицы по 4 байта
+func writeSFNT(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	n := len(tags)
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= n {
+		searchRange *= 2
+		entrySelector++
+	}
+
+	out := make([]byte, 12+16*n)
+	binary.BigEndian.PutUint32(out, 0x00010000)
+	binary.BigEndian.PutUint16(out[4:], uint16(n))
+	binary.BigEndian.PutUint16(out[6:], uint16(searchRange*16))
+	binary.BigEndian.PutUint16(out[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(out[10:], uint16(n*16-searchRange*16))
+
+	for i, tag := range tags {
+		table := tables[tag]
+		rec := out[12+16*i:]
+		copy(rec, tag)
+		binary.BigEndian.PutUint32(rec[4:], tableChecksum(table))
+		binary.BigEndian.PutUint32(rec[8:], uint32(len(out)))
+		binary.BigEndian.PutUint32(rec[12:], uint32(len(table)))
+		out = append(out, table...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+	return out
+}
+
+func tableChecksum(table []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(table); i += 4 {
+		var word [4]byte
+		copy(word[:], table[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
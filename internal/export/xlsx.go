@@ -0,0 +1,228 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Индексы стилей ячеек из xlsxStyles (cellXfs)
+const (
+	xlsxStyleDefault  = 0
+	xlsxStyleDate     = 1
+	xlsxStyleDateTime = 2
+	xlsxStyleHeader   = 3
+	xlsxStyleNumber   = 4
+)
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+%s</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="5">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="2" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+</cellXfs>
+</styleSheet>`
+
+// excelEpoch — нулевой день серийных дат Excel (с учётом ошибки 1900 года)
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// WriteXLSX пишет книгу Office Open XML: по листу на Sheet. Строки пишутся прямо в zip
+// как inline-строки, без общей таблицы строк, поэтому книга не собирается в памяти.
+func WriteXLSX(w io.Writer, doc Document) error {
+	zw := zip.NewWriter(w)
+	names := sheetNames(doc.Sheets)
+
+	var overrides, sheets, rels strings.Builder
+	for i, name := range names {
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`+"\n", i+1)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(name), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`+"\n", i+1, i+1)
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`+"\n", len(names)+1)
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", fmt.Sprintf(xlsxContentTypes, overrides.String())},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+` + rels.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return err
+		}
+	}
+
+	for i, sheet := range doc.Sheets {
+		f, err := zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err := writeXLSXSheet(f, sheet); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func writeXLSXSheet(w io.Writer, sheet Sheet) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	bw.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	// Ширина колонок задаётся по типу: содержимое заранее неизвестно, а читать строки дважды нельзя
+	bw.WriteString("<cols>")
+	for i, col := range sheet.Columns {
+		width := 14
+		switch col.Type {
+		case Text:
+			width = max(20, len([]rune(col.Title))+2)
+		case DateTime:
+			width = 18
+		}
+		fmt.Fprintf(bw, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+	}
+	bw.WriteString("</cols><sheetData>")
+
+	header := make([]any, len(sheet.Columns))
+	for i, col := range sheet.Columns {
+		header[i] = col.Title
+	}
+	writeXLSXRow(bw, 1, header, nil)
+
+	rowNum := 1
+	err := sheet.Rows(func(row []any) error {
+		rowNum++
+		writeXLSXRow(bw, rowNum, row, sheet.Columns)
+		if bw.Buffered() > 64*1024 {
+			return bw.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	bw.WriteString("</sheetData></worksheet>")
+	return bw.Flush()
+}
+
+// writeXLSXRow пишет строку; columns == nil — строка заголовков
+func writeXLSXRow(w *bufio.Writer, rowNum int, row []any, columns []Column) {
+	fmt.Fprintf(w, `<row r="%d">`, rowNum)
+	for i, value := range row {
+		ref := columnName(i) + strconv.Itoa(rowNum)
+		value = deref(value)
+
+		if columns == nil {
+			fmt.Fprintf(w, `<c r="%s" t="inlineStr" s="%d"><is><t>%s</t></is></c>`, ref, xlsxStyleHeader, xmlEscape(formatText(value, Text)))
+			continue
+		}
+
+		typ := Text
+		if i < len(columns) {
+			typ = columns[i].Type
+		}
+		switch v := value.(type) {
+		case nil:
+			continue
+		case time.Time:
+			style := xlsxStyleDateTime
+			if typ == Date {
+				style = xlsxStyleDate
+			}
+			serial := v.UTC().Sub(excelEpoch).Hours() / 24
+			fmt.Fprintf(w, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(serial, 'f', -1, 64))
+			continue
+		}
+		if n, ok := number(value); ok && typ != Text {
+			style := xlsxStyleDefault
+			if typ == Number {
+				style = xlsxStyleNumber
+			}
+			fmt.Fprintf(w, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(n, 'f', -1, 64))
+			continue
+		}
+		fmt.Fprintf(w, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(formatText(value, typ)))
+	}
+	w.WriteString("</row>")
+}
+
+// columnName переводит индекс колонки в буквы Excel: 0 → A, 26 → AA
+func columnName(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
+
+// sheetNames делает имена листов допустимыми для Excel: до 31 символа, без []:*?/\ и без повторов
+func sheetNames(sheets []Sheet) []string {
+	names := make([]string, len(sheets))
+	seen := make(map[string]bool)
+	for i, sheet := range sheets {
+		name := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`[]:*?/\`, r) {
+				return '_'
+			}
+			return r
+		}, sheet.Title)
+		if name == "" {
+			name = "Sheet"
+		}
+		if r := []rune(name); len(r) > 31 {
+			name = string(r[:31])
+		}
+		base := name
+		for n := 2; seen[strings.ToLower(name)]; n++ {
+			suffix := fmt.Sprintf(" (%d)", n)
+			r := []rune(base)
+			name = string(r[:min(len(r), 31-len(suffix))]) + suffix
+		}
+		seen[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// CalendarToken — секрет ссылки на iCal-ленту пользователя. Хранится только хэш,
+// токен не даёт доступа к API и отзывается удалением записи.
+type CalendarToken struct {
+	Base
+	UserID     uint       `json:"user_id" gorm:"not null;uniqueIndex"`
+	TokenHash  string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type CalendarTokenResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -7,12 +7,27 @@ type ChatMessage struct {
 
 	ChatableID   uint   `json:"chatable_id"`
 	ChatableType string `json:"chatable_type"`
+
+	// ParentID указывает на корневое сообщение треда, nil — сообщение верхнего уровня
+	ParentID   *uint `json:"parent_id" gorm:"index"`
+	ReplyCount int   `json:"reply_count" gorm:"-"`
+
+	Reactions []ReactionCount `json:"reactions" gorm:"-"`
 }
 
 type ChatMessageCreateReq struct {
-	UserID uint   `json:"user_id" binding:"required"`
+	// UserID заполняется из JWT текущего пользователя, а не из тела запроса
+	UserID uint   `json:"-"`
 	Text   string `json:"text" binding:"required,min=1,max=5000"`
 
 	ChatableID   uint   `json:"chatable_id" binding:"required"`
-	ChatableType string `json:"chatable_type" binding:"required,oneof=projects tasks"`
+	ChatableType string `json:"chatable_type" binding:"required,oneof=projects tasks teams direct"`
+	ParentID     *uint  `json:"parent_id"`
+	MentionIDs   []uint `json:"mention_ids"`
+}
+
+type ChatThreadResponse struct {
+	Parent         ChatMessage   `json:"parent"`
+	Replies        []ChatMessage `json:"replies"`
+	ParticipantIDs []uint        `json:"participant_ids"`
 }
@@ -0,0 +1,19 @@
+package models
+
+type ChatReaction struct {
+	Base
+	MessageID uint   `json:"message_id" gorm:"not null;uniqueIndex:idx_chat_reaction_user_emoji"`
+	UserID    uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_chat_reaction_user_emoji"`
+	Emoji     string `json:"emoji" gorm:"type:varchar(32);not null;uniqueIndex:idx_chat_reaction_user_emoji"`
+}
+
+type ChatReactionReq struct {
+	Emoji string `json:"emoji" binding:"required,min=1,max=32"`
+}
+
+// ReactionCount — агрегированные реакции одного вида на сообщение
+type ReactionCount struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	UserIDs []uint `json:"user_ids"`
+}
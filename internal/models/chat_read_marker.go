@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ChatReadMarker хранит последнее прочитанное пользователем сообщение в чате
+type ChatReadMarker struct {
+	Base
+	UserID            uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_chat_read_marker"`
+	ChatableType      string    `json:"chatable_type" gorm:"type:varchar(20);not null;uniqueIndex:idx_chat_read_marker"`
+	ChatableID        uint      `json:"chatable_id" gorm:"not null;uniqueIndex:idx_chat_read_marker"`
+	LastReadMessageID uint      `json:"last_read_message_id"`
+	ReadAt            time.Time `json:"read_at"`
+}
+
+type ChatMarkReadReq struct {
+	MessageID *uint `json:"message_id"`
+}
+
+type UnreadCount struct {
+	ChatableType string `json:"chatable_type"`
+	ChatableID   uint   `json:"chatable_id"`
+	Unread       int    `json:"unread"`
+}
+
+type UnreadSummary struct {
+	Tasks    []UnreadCount `json:"tasks"`
+	Projects []UnreadCount `json:"projects"`
+	Teams    []UnreadCount `json:"teams"`
+	Direct   []UnreadCount `json:"direct"`
+	Total    int           `json:"total"`
+}
+
+type SeenByResponse struct {
+	MessageID uint   `json:"message_id"`
+	UserIDs   []uint `json:"user_ids"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// EventTaskAssigned — назначение исполнителя; транслируется только в чаты, вебхукам недоступно
+const EventTaskAssigned = "task.assigned"
+
+// ChatOpsEventTypes — события, которые можно транслировать в канал Slack/Mattermost
+var ChatOpsEventTypes = []string{
+	EventTaskCreated,
+	EventTaskAssigned,
+	EventTaskStatusChanged,
+	EventChatMessageCreated,
+}
+
+// ChatOpsIntegration — интеграция проекта с Slack-совместимым чатом: входящий вебхук для
+// сообщений о событиях и токен slash-команды /minijira. У проекта одна интеграция.
+type ChatOpsIntegration struct {
+	Base
+	ProjectID  uint   `json:"project_id" gorm:"not null;uniqueIndex"`
+	WebhookURL string `json:"webhook_url" gorm:"type:varchar(2048)"`
+	// Channel и Username переопределяют настройки вебхука, если чат это разрешает
+	Channel  string `json:"channel" gorm:"type:varchar(100)"`
+	Username string `json:"username" gorm:"type:varchar(100)"`
+	// CommandTokenHash — sha256 токена, который чат присылает с каждой slash-командой
+	CommandTokenHash string `json:"-" gorm:"type:varchar(64)"`
+	// Events — события через запятую, как у Webhook
+	Events string `json:"-" gorm:"type:text"`
+	Active bool   `json:"active" gorm:"default:true"`
+}
+
+type ChatOpsSaveReq struct {
+	WebhookURL *string   `json:"webhook_url" binding:"omitempty,url"`
+	Channel    *string   `json:"channel"`
+	Username   *string   `json:"username"`
+	Events     *[]string `json:"events" binding:"omitempty,dive,oneof=task.created task.assigned task.status_changed chat.message_created"`
+	// CommandToken — токен из настроек slash-команды в Slack/Mattermost; пустая строка отключает команды
+	CommandToken *string `json:"command_token"`
+	Active       *bool   `json:"active"`
+}
+
+type ChatOpsResponse struct {
+	ProjectID       uint      `json:"project_id"`
+	WebhookURL      string    `json:"webhook_url"`
+	Channel         string    `json:"channel"`
+	Username        string    `json:"username"`
+	Events          []string  `json:"events"`
+	CommandsEnabled bool      `json:"commands_enabled"`
+	CommandURL      string    `json:"command_url"`
+	Active          bool      `json:"active"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// SlashCommand — поля формы, которую Slack и Mattermost присылают на URL команды
+type SlashCommand struct {
+	Token       string `form:"token"`
+	Command     string `form:"command"`
+	Text        string `form:"text"`
+	UserName    string `form:"user_name"`
+	ChannelName string `form:"channel_name"`
+}
+
+// SlashCommandResponse — ответ в формате Slack: ephemeral видит только автор команды
+type SlashCommandResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// DirectConversation — личная переписка двух и более пользователей (chatable_type = "direct")
+type DirectConversation struct {
+	Base
+	Users        []User        `gorm:"many2many:direct_conversation_users;" json:"-"`
+	ChatMessages []ChatMessage `gorm:"polymorphic:Chatable" json:"-"`
+}
+
+type DirectConversationCreateReq struct {
+	UserIDs []uint `json:"user_ids" binding:"required,min=1"`
+}
+
+type DirectConversationResponse struct {
+	ID        uint      `json:"id"`
+	UserIDs   []uint    `json:"user_ids"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Внутренние события об изменениях задач. На них не подписываются вебхуки и чаты:
+// по ним пересчитываются снимки отчётов.
+const (
+	EventTaskUpdated = "task.updated"
+	EventTaskDeleted = "task.deleted"
+)
+
+// DomainEvent — событие предметной области, которое сервисы публикуют после коммита
+type DomainEvent struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	ProjectID  uint      `json:"project_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+type TaskEventData struct {
+	ID          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Status      string     `json:"status"`
+	ProjectID   uint       `json:"project_id"`
+	Priority    int        `json:"priority"`
+	DueDate     *time.Time `json:"due_date"`
+	AssigneeIDs []uint     `json:"assignee_ids"`
+}
+
+type TaskStatusChangedData struct {
+	Task      TaskEventData `json:"task"`
+	OldStatus string        `json:"old_status"`
+	NewStatus string        `json:"new_status"`
+	ActorID   uint          `json:"actor_id"`
+}
+
+// TaskAssignedData — AssigneeIDs содержит только новых исполнителей, полный список — в Task
+type TaskAssignedData struct {
+	Task        TaskEventData `json:"task"`
+	AssigneeIDs []uint        `json:"assignee_ids"`
+	ActorID     uint          `json:"actor_id"`
+}
+
+type ChatMessageEventData struct {
+	ID           uint   `json:"id"`
+	ChatableType string `json:"chatable_type"`
+	ChatableID   uint   `json:"chatable_id"`
+	ParentID     *uint  `json:"parent_id"`
+	UserID       uint   `json:"user_id"`
+	Text         string `json:"text"`
+}
+
+type ProjectEventData struct {
+	ID     uint   `json:"id"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+}
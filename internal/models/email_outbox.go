@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSent    = "sent"
+	// EmailStatusDead — письмо исчерпало попытки доставки и ждёт ручной переотправки
+	EmailStatusDead = "dead"
+)
+
+type EmailOutbox struct {
+	Base
+	To            string     `json:"to" gorm:"type:varchar(255);not null"`
+	Subject       string     `json:"subject" gorm:"type:varchar(255)"`
+	Body          string     `json:"body" gorm:"type:text"`
+	HTMLBody      string     `json:"html_body" gorm:"type:text"`
+	ReplyTo       string     `json:"reply_to" gorm:"type:varchar(255)"`
+	Status        string     `json:"status" gorm:"type:varchar(20);default:'pending';index"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index"`
+	LastError     string     `json:"last_error" gorm:"type:text"`
+	SentAt        *time.Time `json:"sent_at"`
+}
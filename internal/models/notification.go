@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Типы событий, о которых пользователь получает уведомления
+const (
+	NotificationTaskAssigned      = "task.assigned"
+	NotificationTaskStatusChanged = "task.status_changed"
+	NotificationChatComment       = "chat.comment"
+	NotificationChatReply         = "chat.reply"
+	NotificationChatMention       = "chat.mention"
+	NotificationTeamAdded         = "team.added"
+)
+
+type Notification struct {
+	Base
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	ActorID    uint       `json:"actor_id"`
+	Type       string     `json:"type" gorm:"type:varchar(50);not null;index"`
+	Title      string     `json:"title" gorm:"type:varchar(255)"`
+	Text       string     `json:"text" gorm:"type:text"`
+	EntityType string     `json:"entity_type" gorm:"type:varchar(20)"`
+	EntityID   uint       `json:"entity_id"`
+	ProjectID  uint       `json:"project_id" gorm:"index"`
+	ReadAt     *time.Time `json:"read_at" gorm:"index"`
+	// DigestOnly — событие нужно только для сводки, в ленте приложения его не показываем
+	DigestOnly bool `json:"-" gorm:"default:false"`
+}
+
+// NotificationEvent — событие сервиса, которое раскладывается в уведомления получателям
+type NotificationEvent struct {
+	Type         string
+	ActorID      uint
+	RecipientIDs []uint
+	Title        string
+	Text         string
+	// EntityName — название сущности (например, задачи) для писем на языке получателя
+	EntityName string
+	EntityType string
+	EntityID   uint
+	ProjectID  uint
+	// ThreadID — корневое сообщение треда для событий чата, чтобы ответ по почте попал в тот же тред
+	ThreadID uint
+}
+
+type NotificationFilter struct {
+	UnreadOnly bool
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,78 @@
+package models
+
+// Каналы доставки уведомлений
+const (
+	ChannelInApp  = "in_app"
+	ChannelEmail  = "email"
+	ChannelDigest = "digest"
+	// ChannelNone — событие полностью отключено
+	ChannelNone = "none"
+)
+
+// NotificationEventTypes — события, для которых пользователь выбирает каналы
+var NotificationEventTypes = []string{
+	NotificationTaskAssigned,
+	NotificationTaskStatusChanged,
+	NotificationChatComment,
+	NotificationChatReply,
+	NotificationChatMention,
+	NotificationTeamAdded,
+}
+
+// DefaultNotificationChannels — каналы, пока пользователь ничего не настроил
+var DefaultNotificationChannels = map[string][]string{
+	NotificationTaskAssigned:      {ChannelInApp, ChannelEmail, ChannelDigest},
+	NotificationTaskStatusChanged: {ChannelInApp, ChannelDigest},
+	NotificationChatComment:       {ChannelInApp, ChannelEmail},
+	NotificationChatReply:         {ChannelInApp, ChannelEmail},
+	NotificationChatMention:       {ChannelInApp, ChannelEmail, ChannelDigest},
+	NotificationTeamAdded:         {ChannelInApp},
+}
+
+// NotificationSettings — часовой пояс и тихие часы пользователя
+type NotificationSettings struct {
+	Base
+	UserID   uint   `json:"user_id" gorm:"not null;uniqueIndex"`
+	Timezone string `json:"timezone" gorm:"type:varchar(64)"`
+	// QuietHoursStart/End в формате HH:MM по часовому поясу пользователя; пустые — тихие часы выключены
+	QuietHoursStart string `json:"quiet_hours_start" gorm:"type:varchar(5)"`
+	QuietHoursEnd   string `json:"quiet_hours_end" gorm:"type:varchar(5)"`
+}
+
+// NotificationPreference — выбранные каналы для одного типа события; пустой Channels значит «none»
+type NotificationPreference struct {
+	Base
+	UserID    uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_notification_preference_user_type"`
+	EventType string `json:"event_type" gorm:"type:varchar(50);not null;uniqueIndex:idx_notification_preference_user_type"`
+	Channels  string `json:"channels" gorm:"type:varchar(50)"`
+}
+
+// ProjectMute — пользователь не получает никаких уведомлений по проекту
+type ProjectMute struct {
+	UserID    uint `json:"user_id" gorm:"primaryKey"`
+	ProjectID uint `json:"project_id" gorm:"primaryKey;index"`
+}
+
+// QuietHours — интервал HH:MM, может переходить через полночь (22:00–08:00)
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type NotificationSettingsResponse struct {
+	Timezone        string              `json:"timezone"`
+	QuietHours      *QuietHours         `json:"quiet_hours"`
+	Events          map[string][]string `json:"events"`
+	MutedProjectIDs []uint              `json:"muted_project_ids"`
+	DigestFrequency string              `json:"digest_frequency"`
+}
+
+// NotificationSettingsUpdateReq — все поля необязательны; events обновляет только переданные типы,
+// muted_project_ids заменяет список целиком, пустые start/end в quiet_hours выключают тихие часы
+type NotificationSettingsUpdateReq struct {
+	Timezone        *string             `json:"timezone"`
+	QuietHours      *QuietHours         `json:"quiet_hours"`
+	Events          map[string][]string `json:"events"`
+	MutedProjectIDs *[]uint             `json:"muted_project_ids"`
+	DigestFrequency *string             `json:"digest_frequency" binding:"omitempty,oneof=none daily weekly"`
+}
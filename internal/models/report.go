@@ -0,0 +1,385 @@
+package models
+
+import "time"
+
+const (
+	ReportIntervalDay   = "day"
+	ReportIntervalWeek  = "week"
+	ReportIntervalMonth = "month"
+
+	// ReportUnitCount считает работу задачами, ReportUnitEstimate — суммой оценок
+	ReportUnitCount    = "count"
+	ReportUnitEstimate = "estimate"
+)
+
+// WorkflowStatuses — статусы задач в порядке прохождения рабочего процесса
+var WorkflowStatuses = []string{"todo", "in_progress", "done"}
+
+// BurndownRow — состояние проекта на конец одного интервала, восстановленное по истории статусов
+type BurndownRow struct {
+	Date          time.Time
+	ScopeCount    int
+	DoneCount     int
+	ScopeEstimate float64
+	DoneEstimate  float64
+}
+
+// BurndownPoint — точка графика. Scope и Completed дают burnup, Remaining — burndown.
+// Для дат в будущем фактические значения не заполняются, остаётся только идеальная линия.
+type BurndownPoint struct {
+	Date      string   `json:"date"`
+	Scope     *float64 `json:"scope"`
+	Completed *float64 `json:"completed"`
+	Remaining *float64 `json:"remaining"`
+	Ideal     *float64 `json:"ideal"`
+}
+
+type BurndownReport struct {
+	ProjectID uint            `json:"project_id"`
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Interval  string          `json:"interval"`
+	Unit      string          `json:"unit"`
+	TimeEnd   *time.Time      `json:"time_end"`
+	Points    []BurndownPoint `json:"points"`
+}
+
+type BurndownQuery struct {
+	ReportRangeQuery
+	// Unit — count, estimate или пусто: тогда estimate, если в проекте есть оценённые задачи
+	Unit string `form:"unit"`
+}
+
+// StatusCountRow — число задач в статусе на конец интервала
+type StatusCountRow struct {
+	Date   time.Time
+	Status string
+	Count  int
+}
+
+// CumulativeFlowPoint — распределение задач по статусам на дату; в Counts есть все статусы процесса
+type CumulativeFlowPoint struct {
+	Date   string         `json:"date"`
+	Counts map[string]int `json:"counts"`
+	Total  int            `json:"total"`
+}
+
+type CumulativeFlowReport struct {
+	ProjectID uint                  `json:"project_id"`
+	From      string                `json:"from"`
+	To        string                `json:"to"`
+	Interval  string                `json:"interval"`
+	Statuses  []string              `json:"statuses"`
+	Points    []CumulativeFlowPoint `json:"points"`
+}
+
+// ReportRangeQuery — общий диапазон отчётов по датам; даты в формате 2006-01-02
+type ReportRangeQuery struct {
+	From     string `form:"from"`
+	To       string `form:"to"`
+	Interval string `form:"interval"`
+}
+
+// CycleTimeQuery — фильтры отчёта по времени выполнения; from и to ограничивают дату завершения
+type CycleTimeQuery struct {
+	From       string `form:"from"`
+	To         string `form:"to"`
+	Priority   *int   `form:"priority" binding:"omitempty,min=0,max=2"`
+	AssigneeID *uint  `form:"assignee_id"`
+}
+
+// CompletedTaskRow — даты жизненного цикла завершённой задачи, восстановленные по истории статусов
+type CompletedTaskRow struct {
+	TaskID    uint
+	CreatedAt time.Time
+	StartedAt *time.Time
+	DoneAt    time.Time
+	Reopened  bool
+}
+
+type HistogramBucket struct {
+	Label string `json:"label"`
+	// UpToSeconds — верхняя граница корзины, не включая её; nil у последней корзины
+	UpToSeconds *int64 `json:"up_to_seconds"`
+	Count       int    `json:"count"`
+}
+
+// DurationStats — распределение длительностей в секундах
+type DurationStats struct {
+	Count       int               `json:"count"`
+	MinSeconds  int64             `json:"min_seconds"`
+	MaxSeconds  int64             `json:"max_seconds"`
+	MeanSeconds int64             `json:"mean_seconds"`
+	P50Seconds  int64             `json:"p50_seconds"`
+	P75Seconds  int64             `json:"p75_seconds"`
+	P85Seconds  int64             `json:"p85_seconds"`
+	P95Seconds  int64             `json:"p95_seconds"`
+	P50Human    string            `json:"p50_human"`
+	P85Human    string            `json:"p85_human"`
+	Histogram   []HistogramBucket `json:"histogram"`
+}
+
+// CycleTimeStats — cycle time (от начала работы до done) и lead time (от создания до done)
+type CycleTimeStats struct {
+	CycleTime DurationStats `json:"cycle_time"`
+	LeadTime  DurationStats `json:"lead_time"`
+}
+
+// CycleTimeReport. Переоткрытые задачи (хотя бы раз возвращённые из done) искажают
+// распределение, поэтому они посчитаны отдельно и в основную статистику не входят.
+type CycleTimeReport struct {
+	ProjectID  uint   `json:"project_id"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Priority   *int   `json:"priority"`
+	AssigneeID *uint  `json:"assignee_id"`
+	CycleTimeStats
+	Reopened CycleTimeStats `json:"reopened"`
+}
+
+// BucketCountRow — число событий в интервале, сгруппированное через date_trunc
+type BucketCountRow struct {
+	Bucket time.Time
+	Count  int
+}
+
+// ThroughputQuery — по умолчанию недельные интервалы и скользящее среднее по 4 интервалам
+type ThroughputQuery struct {
+	ReportRangeQuery
+	Window int `form:"window" binding:"omitempty,min=1,max=52"`
+}
+
+type ThroughputPoint struct {
+	Date      string `json:"date"`
+	Completed int    `json:"completed"`
+	// RollingAverage — среднее за Window интервалов, заканчивая текущим
+	RollingAverage float64 `json:"rolling_average"`
+}
+
+// ThroughputReport. ProjectID пуст в сводном отчёте по всем проектам.
+// PreviousTotal — число закрытых задач за такой же по длине период прямо перед From.
+type ThroughputReport struct {
+	ProjectID     *uint             `json:"project_id"`
+	From          string            `json:"from"`
+	To            string            `json:"to"`
+	Interval      string            `json:"interval"`
+	Window        int               `json:"window"`
+	Total         int               `json:"total"`
+	PreviousTotal int               `json:"previous_total"`
+	ChangePercent *float64          `json:"change_percent"`
+	Points        []ThroughputPoint `json:"points"`
+}
+
+const (
+	HealthOnTrack  = "on_track"
+	HealthAtRisk   = "at_risk"
+	HealthOffTrack = "off_track"
+	HealthDone     = "done"
+)
+
+// PortfolioRow — агрегаты по задачам одного проекта
+type PortfolioRow struct {
+	ProjectID       uint
+	Title           string
+	Status          string
+	CreatedAt       time.Time
+	TimeEnd         *time.Time
+	TotalTasks      int
+	DoneTasks       int
+	InProgressTasks int
+	OverdueTasks    int
+}
+
+type PortfolioProject struct {
+	ProjectID         uint       `json:"project_id"`
+	Title             string     `json:"title"`
+	Status            string     `json:"status"`
+	TimeEnd           *time.Time `json:"time_end"`
+	DaysLeft          *int       `json:"days_left"`
+	TotalTasks        int        `json:"total_tasks"`
+	DoneTasks         int        `json:"done_tasks"`
+	InProgressTasks   int        `json:"in_progress_tasks"`
+	OverdueTasks      int        `json:"overdue_tasks"`
+	CompletionPercent float64    `json:"completion_percent"`
+	// ExpectedPercent — доля прошедшего срока проекта; nil, если срок не задан
+	ExpectedPercent *float64 `json:"expected_percent"`
+	Health          string   `json:"health"`
+	HealthReason    string   `json:"health_reason"`
+}
+
+type PortfolioTotals struct {
+	Projects        int            `json:"projects"`
+	Health          map[string]int `json:"health"`
+	InProgressTasks int            `json:"in_progress_tasks"`
+	OverdueTasks    int            `json:"overdue_tasks"`
+}
+
+type PortfolioReport struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Totals      PortfolioTotals    `json:"totals"`
+	Projects    []PortfolioProject `json:"projects"`
+}
+
+// WorkloadRow — открытые задачи пользователя; нагрузка задачи делится между её исполнителями
+type WorkloadRow struct {
+	UserID               uint
+	Name                 string
+	Capacity             int
+	OpenTasks            int
+	InProgressTasks      int
+	WeightedLoad         float64
+	OldestStartedAt      *time.Time
+	AvgInProgressSeconds float64
+}
+
+type WorkloadQuery struct {
+	ProjectID *uint `form:"project_id"`
+	// OverCapacity=true оставляет только перегруженных
+	OverCapacity bool `form:"over_capacity"`
+}
+
+type WorkloadUser struct {
+	UserID          uint    `json:"user_id"`
+	Name            string  `json:"name"`
+	Capacity        int     `json:"capacity"`
+	OpenTasks       int     `json:"open_tasks"`
+	InProgressTasks int     `json:"in_progress_tasks"`
+	WeightedLoad    float64 `json:"weighted_load"`
+	// UtilizationPercent — нагрузка относительно Capacity; nil при нулевой Capacity
+	UtilizationPercent      *float64 `json:"utilization_percent"`
+	OverCapacity            bool     `json:"over_capacity"`
+	OldestInProgressSeconds int64    `json:"oldest_in_progress_seconds"`
+	OldestInProgressHuman   string   `json:"oldest_in_progress_human"`
+	AvgInProgressSeconds    int64    `json:"avg_in_progress_seconds"`
+}
+
+type WorkloadReport struct {
+	GeneratedAt  time.Time      `json:"generated_at"`
+	ProjectID    *uint          `json:"project_id"`
+	OverCapacity int            `json:"over_capacity"`
+	Users        []WorkloadUser `json:"users"`
+}
+
+// LoggedTimeFilter — выборка worklogs для отчётов; пустые ProjectID и UserID не ограничивают.
+// Запущенные таймеры считаются по Now.
+type LoggedTimeFilter struct {
+	ProjectID *uint
+	UserID    *uint
+	From      time.Time
+	To        time.Time
+	Now       time.Time
+}
+
+// LoggedTimeRow — время, записанное пользователем на задачу внутри периода
+type LoggedTimeRow struct {
+	TaskID       uint
+	TaskTitle    string
+	ProjectID    uint
+	ProjectTitle string
+	UserID       uint
+	UserName     string
+	Seconds      float64
+}
+
+// CalendarTimeRow — сколько календарного времени задача провела в in_progress внутри периода
+type CalendarTimeRow struct {
+	TaskID    uint
+	TaskTitle string
+	ProjectID uint
+	Seconds   float64
+}
+
+type TimeReportQuery struct {
+	From   string `form:"from"`
+	To     string `form:"to"`
+	UserID *uint  `form:"user_id"`
+}
+
+// TimeAmount — записанное время против календарного времени в работе.
+// LoggedPercent — какая доля календарного времени покрыта записями; nil без календарного времени.
+type TimeAmount struct {
+	LoggedSeconds   int64    `json:"logged_seconds"`
+	LoggedHuman     string   `json:"logged_human"`
+	CalendarSeconds int64    `json:"calendar_seconds"`
+	CalendarHuman   string   `json:"calendar_human"`
+	LoggedPercent   *float64 `json:"logged_percent"`
+}
+
+type TaskTime struct {
+	TaskID uint   `json:"task_id"`
+	Title  string `json:"title"`
+	TimeAmount
+}
+
+type UserTime struct {
+	UserID        uint   `json:"user_id"`
+	Name          string `json:"name"`
+	LoggedSeconds int64  `json:"logged_seconds"`
+	LoggedHuman   string `json:"logged_human"`
+	// Projects заполняется только в отчёте по всем проектам
+	Projects []ProjectLoggedTime `json:"projects,omitempty"`
+}
+
+type ProjectLoggedTime struct {
+	ProjectID     uint   `json:"project_id"`
+	Title         string `json:"title"`
+	LoggedSeconds int64  `json:"logged_seconds"`
+	LoggedHuman   string `json:"logged_human"`
+}
+
+type ProjectTime struct {
+	ProjectID uint   `json:"project_id"`
+	Title     string `json:"title"`
+	TimeAmount
+}
+
+// ProjectTimeReport — учёт времени в одном проекте: итог, по людям и по задачам
+type ProjectTimeReport struct {
+	ProjectID uint   `json:"project_id"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	TimeAmount
+	Users []UserTime `json:"users"`
+	Tasks []TaskTime `json:"tasks"`
+}
+
+// TimeReport — учёт времени по всем проектам: по людям с разбивкой по проектам и по проектам
+type TimeReport struct {
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	UserID   *uint         `json:"user_id"`
+	Users    []UserTime    `json:"users"`
+	Projects []ProjectTime `json:"projects"`
+}
+
+// TeamContributorRow — вклад участника команды в проект команды за период
+type TeamContributorRow struct {
+	UserID          uint
+	Name            string
+	CompletedTasks  int
+	InProgressTasks int
+	LoggedSeconds   float64
+}
+
+type TeamContributor struct {
+	UserID          uint   `json:"user_id"`
+	Name            string `json:"name"`
+	CompletedTasks  int    `json:"completed_tasks"`
+	InProgressTasks int    `json:"in_progress_tasks"`
+	LoggedSeconds   int64  `json:"logged_seconds"`
+	LoggedHuman     string `json:"logged_human"`
+}
+
+// TeamReport — показатели команды по задачам её проекта, назначенным на участников.
+// Completion — текущее состояние, остальное — за период From–To.
+type TeamReport struct {
+	TeamID            uint                 `json:"team_id"`
+	Name              string               `json:"name"`
+	ProjectID         uint                 `json:"project_id"`
+	MemberIDs         []uint               `json:"member_ids"`
+	Completion        CompletionPercentDTO `json:"completion"`
+	Throughput        ThroughputReport     `json:"throughput"`
+	CycleTime         CycleTimeStats       `json:"cycle_time"`
+	AverageCycleHuman string               `json:"average_cycle_human"`
+	TopContributors   []TeamContributor    `json:"top_contributors"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// ReportSnapshot — ключевые показатели проекта на конец дня. Строка текущего дня
+// пересчитывается после изменений задач, прошлые дни остаются как история.
+type ReportSnapshot struct {
+	ID                uint      `json:"id" gorm:"primarykey"`
+	ProjectID         uint      `json:"project_id" gorm:"not null;uniqueIndex:idx_report_snapshots_project_day,priority:1"`
+	Day               time.Time `json:"day" gorm:"type:date;not null;uniqueIndex:idx_report_snapshots_project_day,priority:2;index"`
+	TotalTasks        int64     `json:"total_tasks" gorm:"not null;default:0"`
+	TodoTasks         int64     `json:"todo_tasks" gorm:"not null;default:0"`
+	InProgressTasks   int64     `json:"in_progress_tasks" gorm:"not null;default:0"`
+	DoneTasks         int64     `json:"done_tasks" gorm:"not null;default:0"`
+	OverdueTasks      int64     `json:"overdue_tasks" gorm:"not null;default:0"`
+	EstimateTotal     float64   `json:"estimate_total" gorm:"not null;default:0"`
+	EstimateDone      float64   `json:"estimate_done" gorm:"not null;default:0"`
+	CompletionPercent float64   `json:"completion_percent" gorm:"not null;default:0"`
+	AvgCycleSeconds   float64   `json:"avg_cycle_seconds" gorm:"not null;default:0"`
+	RefreshedAt       time.Time `json:"refreshed_at" gorm:"not null"`
+}
+
+type ReportSnapshotResponse struct {
+	ProjectID         uint      `json:"project_id"`
+	Day               string    `json:"day"`
+	TotalTasks        int64     `json:"total_tasks"`
+	TodoTasks         int64     `json:"todo_tasks"`
+	InProgressTasks   int64     `json:"in_progress_tasks"`
+	DoneTasks         int64     `json:"done_tasks"`
+	OverdueTasks      int64     `json:"overdue_tasks"`
+	EstimateTotal     float64   `json:"estimate_total"`
+	EstimateDone      float64   `json:"estimate_done"`
+	CompletionPercent float64   `json:"completion_percent"`
+	AvgCycleSeconds   int64     `json:"avg_cycle_seconds"`
+	AvgCycleHuman     string    `json:"avg_cycle_human"`
+	RefreshedAt       time.Time `json:"refreshed_at"`
+}
+
+// SnapshotCompareQuery — с чем сравнивать сегодняшний снимок: с датой или с днём Days назад (по умолчанию 7)
+type SnapshotCompareQuery struct {
+	Date string `form:"date"`
+	Days int    `form:"days" binding:"omitempty,min=1,max=3650"`
+}
+
+type SnapshotDelta struct {
+	TotalTasks        int64   `json:"total_tasks"`
+	DoneTasks         int64   `json:"done_tasks"`
+	InProgressTasks   int64   `json:"in_progress_tasks"`
+	OverdueTasks      int64   `json:"overdue_tasks"`
+	CompletionPercent float64 `json:"completion_percent"`
+}
+
+// SnapshotComparison — текущий снимок против ближайшего снимка не позже выбранной даты.
+// Previous и Delta пустые, если истории на ту дату ещё нет.
+type SnapshotComparison struct {
+	ProjectID  uint                    `json:"project_id"`
+	ComparedTo string                  `json:"compared_to"`
+	Current    ReportSnapshotResponse  `json:"current"`
+	Previous   *ReportSnapshotResponse `json:"previous"`
+	Delta      *SnapshotDelta          `json:"delta"`
+}
+
+type SnapshotRefreshQuery struct {
+	ProjectID *uint `form:"project_id"`
+}
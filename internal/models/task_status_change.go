@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TaskStatusChange — запись истории статусов задачи. По ней отчёты восстанавливают,
+// в каком состоянии была задача в любой прошлый момент.
+type TaskStatusChange struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	TaskID     uint      `json:"task_id" gorm:"not null;index:idx_task_status_changes_task_changed,priority:1"`
+	ProjectID  uint      `json:"project_id" gorm:"not null;index"`
+	FromStatus string    `json:"from_status" gorm:"type:varchar(50)"`
+	ToStatus   string    `json:"to_status" gorm:"type:varchar(50);not null"`
+	ActorID    uint      `json:"actor_id"`
+	ChangedAt  time.Time `json:"changed_at" gorm:"not null;index:idx_task_status_changes_task_changed,priority:2"`
+}
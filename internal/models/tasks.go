@@ -13,6 +13,9 @@ type Task struct {
 	LimitUser    int           `json:"limit" gorm:"default:1"`
 	StartTask    *time.Time    `json:"start_task" gorm:"index"`
 	FinishTask   *time.Time    `json:"finish_task" gorm:"index"`
+	DueDate      *time.Time    `json:"due_date" gorm:"index"`
+	// Estimate — оценка трудоёмкости (часы или story points); nil — задача не оценена
+	Estimate     *float64      `json:"estimate"`
 	ChatMessages []ChatMessage `gorm:"polymorphic:Chatable"`
 }
 
@@ -28,6 +31,8 @@ type TaskCreateReq struct {
 	LimitUser   int        `json:"limit"`
 	StartTask   *time.Time `json:"start_task"`
 	FinishTask  *time.Time `json:"finish_task"`
+	DueDate     *time.Time `json:"due_date"`
+	Estimate    *float64   `json:"estimate" binding:"omitempty,gte=0"`
 }
 
 type TaskCreateRes struct {
@@ -48,6 +53,8 @@ type TaskUpdateReq struct {
 	LimitUser   *int       `json:"limit"`
 	StartTask   *time.Time `json:"start_task"`
 	FinishTask  *time.Time `json:"finish_task"`
+	DueDate     *time.Time `json:"due_date"`
+	Estimate    *float64   `json:"estimate" binding:"omitempty,gte=0"`
 }
 
 type TaskFilter struct {
@@ -73,4 +80,6 @@ type TaskResponse struct {
 	LimitUser   int        `json:"limit"`
 	StartTask   *time.Time `json:"start_task"`
 	FinishTask  *time.Time `json:"finish_task"`
+	DueDate     *time.Time `json:"due_date"`
+	Estimate    *float64   `json:"estimate"`
 }
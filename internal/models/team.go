@@ -6,6 +6,8 @@ type Team struct {
 	Users     []User `gorm:"many2many:team_users;" json:"-"`
 	ProjectID uint   `json:"project_id"`
 	UserID    uint   `json:"user_id"`
+
+	ChatMessages []ChatMessage `gorm:"polymorphic:Chatable" json:"-"`
 }
 
 type TeamCreateReq struct {
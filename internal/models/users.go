@@ -1,5 +1,33 @@
 package models
 
+import "time"
+
+// Языки интерфейса и писем
+const (
+	LocaleRU      = "ru"
+	LocaleEN      = "en"
+	DefaultLocale = LocaleRU
+)
+
+var SupportedLocales = []string{LocaleRU, LocaleEN}
+
+// Как часто пользователь получает сводку на почту
+const (
+	DigestNone   = "none"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// NormalizeLocale возвращает поддерживаемую локаль или локаль по умолчанию
+func NormalizeLocale(locale string) string {
+	for _, l := range SupportedLocales {
+		if l == locale {
+			return l
+		}
+	}
+	return DefaultLocale
+}
+
 type User struct {
 	Base
 	FullName     string `json:"full_name"`
@@ -9,6 +37,21 @@ type User struct {
 	IsAdmin      bool   `json:"is_admin"`
 	IsVerified   bool   `json:"is_verified"`
 	VerifyToken  string `json:"-"`
+	Locale       string `json:"locale" gorm:"type:varchar(5);default:'ru'"`
+
+	// Сводка включена по умолчанию, частота меняется в /users/me/notification-settings.
+	// Первая сводка уходит в ближайший час рассылки, а не сразу: без DigestLastSentAt
+	// (и после смены частоты) отсчёт начинается с текущего слота.
+	DigestFrequency  string     `json:"digest_frequency" gorm:"type:varchar(10);default:'daily';index"`
+	DigestLastSentAt *time.Time `json:"-"`
+
+	// Capacity — сколько очков нагрузки человек тянет одновременно; очки задачи зависят
+	// от приоритета, см. отчёт /reports/workload
+	Capacity int `json:"capacity" gorm:"not null;default:10"`
+
+	// ResetTokenHash — sha256 токена из ссылки на сброс пароля; сам токен есть только в письме
+	ResetTokenHash      string     `json:"-" gorm:"index"`
+	ResetTokenExpiresAt *time.Time `json:"-"`
 }
 
 type UserCreateReq struct {
@@ -19,6 +62,10 @@ type UserCreateReq struct {
 type UserUpdateReq struct {
 	FullName *string `json:"full_name"`
 	TaskIDs  []uint  `json:"task_ids"`
+	Locale   *string `json:"locale" binding:"omitempty,oneof=ru en"`
+
+	// Capacity меняет только админ
+	Capacity *int `json:"capacity" binding:"omitempty,min=0,max=1000"`
 }
 
 type UserResponse struct {
@@ -26,5 +73,8 @@ type UserResponse struct {
 	FullName string `json:"full_name"`
 	Email    string `json:"email"`
 	IsAdmin  bool   `json:"is_admin"`
+	Locale   string `json:"locale"`
 	TaskIDs  []uint `json:"task_ids"`
+
+	Capacity int `json:"capacity"`
 }
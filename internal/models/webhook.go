@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// Типы доменных событий, на которые подписываются вебхуки
+const (
+	EventTaskCreated        = "task.created"
+	EventTaskStatusChanged  = "task.status_changed"
+	EventChatMessageCreated = "chat.message_created"
+	EventProjectCompleted   = "project.completed"
+)
+
+var WebhookEventTypes = []string{
+	EventTaskCreated,
+	EventTaskStatusChanged,
+	EventChatMessageCreated,
+	EventProjectCompleted,
+}
+
+const (
+	WebhookDeliveryPending = "pending"
+	WebhookDeliverySuccess = "success"
+	// WebhookDeliveryDead — доставка исчерпала попытки, повторить можно через redeliver
+	WebhookDeliveryDead = "dead"
+)
+
+type Webhook struct {
+	Base
+	ProjectID uint   `json:"project_id" gorm:"not null;index"`
+	URL       string `json:"url" gorm:"type:varchar(2048);not null"`
+	Secret    string `json:"-" gorm:"type:varchar(128);not null"`
+	// Events — подписки через запятую, например "task.created,task.status_changed"
+	Events string `json:"-" gorm:"type:text;not null"`
+	Active bool   `json:"active" gorm:"default:true"`
+}
+
+type WebhookDelivery struct {
+	Base
+	WebhookID      uint       `json:"webhook_id" gorm:"not null;index"`
+	EventID        string     `json:"event_id" gorm:"type:varchar(36);index"`
+	EventType      string     `json:"event_type" gorm:"type:varchar(50)"`
+	Payload        string     `json:"payload" gorm:"type:text"`
+	Status         string     `json:"status" gorm:"type:varchar(20);default:'pending';index"`
+	Attempts       int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index"`
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `json:"response_body" gorm:"type:text"`
+	LastError      string     `json:"last_error" gorm:"type:text"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	// RedeliveryOf — исходная доставка, если эта запись создана через redeliver
+	RedeliveryOf *uint `json:"redelivery_of"`
+}
+
+type WebhookCreateReq struct {
+	URL    string   `json:"url" binding:"required,url"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=task.created task.status_changed chat.message_created project.completed"`
+	// Secret можно не передавать — тогда он будет сгенерирован
+	Secret string `json:"secret"`
+	Active *bool  `json:"active"`
+}
+
+type WebhookUpdateReq struct {
+	URL    *string   `json:"url" binding:"omitempty,url"`
+	Events *[]string `json:"events" binding:"omitempty,min=1,dive,oneof=task.created task.status_changed chat.message_created project.completed"`
+	Secret *string   `json:"secret"`
+	Active *bool     `json:"active"`
+}
+
+type WebhookResponse struct {
+	ID        uint      `json:"id"`
+	ProjectID uint      `json:"project_id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	// Secret отдаётся только при создании или смене секрета
+	Secret string `json:"secret,omitempty"`
+}
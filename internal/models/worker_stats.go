@@ -33,5 +33,7 @@ type UserTrackerDTO struct {
 	TotalTimeHuman     string               `json:"total_time_human"`
 	AverageTimeSeconds int64                `json:"average_time_seconds"`
 	AverageTimeHuman   string               `json:"average_time_human"`
+	LoggedTimeSeconds  int64                `json:"logged_time_seconds"`
+	LoggedTimeHuman    string               `json:"logged_time_human"`
 	ActiveTasks        []UserTrackerTaskDTO `json:"active_tasks"`
 }
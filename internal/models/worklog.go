@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+const (
+	WorklogSourceTimer  = "timer"
+	WorklogSourceManual = "manual"
+)
+
+// Worklog — отрезок работы пользователя над задачей. Запись без EndedAt — запущенный таймер;
+// у пользователя он может быть только один, это держит частичный уникальный индекс.
+type Worklog struct {
+	Base
+	TaskID    uint       `json:"task_id" gorm:"not null;index"`
+	ProjectID uint       `json:"project_id" gorm:"not null;index"`
+	UserID    uint       `json:"user_id" gorm:"not null;index;uniqueIndex:idx_worklogs_running,where:ended_at IS NULL AND deleted_at IS NULL"`
+	StartedAt time.Time  `json:"started_at" gorm:"not null;index"`
+	EndedAt   *time.Time `json:"ended_at" gorm:"index"`
+	Source    string     `json:"source" gorm:"type:varchar(10);not null"`
+	Comment   string     `json:"comment" gorm:"type:text"`
+}
+
+type TimerStartReq struct {
+	Comment string `json:"comment" binding:"max=2000"`
+}
+
+// WorklogCreateReq — ручная запись; без StartedAt считается, что работа только что закончилась
+type WorklogCreateReq struct {
+	StartedAt       *time.Time `json:"started_at"`
+	DurationMinutes int        `json:"duration_minutes" binding:"required,min=1,max=1440"`
+	Comment         string     `json:"comment" binding:"max=2000"`
+}
+
+type WorklogUpdateReq struct {
+	StartedAt       *time.Time `json:"started_at"`
+	DurationMinutes *int       `json:"duration_minutes" binding:"omitempty,min=1,max=1440"`
+	Comment         *string    `json:"comment" binding:"omitempty,max=2000"`
+}
+
+type WorklogResponse struct {
+	ID              uint       `json:"id"`
+	TaskID          uint       `json:"task_id"`
+	ProjectID       uint       `json:"project_id"`
+	UserID          uint       `json:"user_id"`
+	StartedAt       time.Time  `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at"`
+	Running         bool       `json:"running"`
+	DurationSeconds int64      `json:"duration_seconds"`
+	DurationHuman   string     `json:"duration_human"`
+	Source          string     `json:"source"`
+	Comment         string     `json:"comment"`
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CalendarRepository interface {
+	SaveToken(token *models.CalendarToken) error
+	DeleteToken(userID uint) (int64, error)
+	GetByTokenHash(hash string) (*models.CalendarToken, error)
+	TouchToken(id uint, at time.Time) error
+	GetUserTasks(userID uint) ([]models.Task, error)
+	GetUserProjects(userID uint) ([]models.Project, error)
+}
+
+type calendarRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewCalendarRepository(db *gorm.DB, logger *slog.Logger) CalendarRepository {
+	return &calendarRepository{db: db, logger: logger}
+}
+
+// SaveToken выпускает новый токен пользователя; старый при этом перестаёт работать
+func (r *calendarRepository) SaveToken(token *models.CalendarToken) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token_hash", "created_at", "updated_at", "last_used_at"}),
+	}).Create(token).Error
+	if err != nil {
+		r.logger.Error("SaveCalendarToken failed", "user_id", token.UserID, "err", err)
+	}
+	return err
+}
+
+// DeleteToken удаляет токен физически, чтобы отозванная ссылка не могла ожить
+func (r *calendarRepository) DeleteToken(userID uint) (int64, error) {
+	res := r.db.Unscoped().Where("user_id = ?", userID).Delete(&models.CalendarToken{})
+	if res.Error != nil {
+		r.logger.Error("DeleteCalendarToken failed", "user_id", userID, "err", res.Error)
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
+func (r *calendarRepository) GetByTokenHash(hash string) (*models.CalendarToken, error) {
+	var token models.CalendarToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("GetCalendarToken failed", "err", err)
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *calendarRepository) TouchToken(id uint, at time.Time) error {
+	return r.db.Model(&models.CalendarToken{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
+// GetUserTasks — задачи, назначенные пользователю, у которых есть хотя бы одна дата
+func (r *calendarRepository) GetUserTasks(userID uint) ([]models.Task, error) {
+	var tasks []models.Task
+	err := r.db.
+		Joins("JOIN task_users ON task_users.task_id = tasks.id").
+		Where("task_users.user_id = ?", userID).
+		Where("tasks.start_task IS NOT NULL OR tasks.due_date IS NOT NULL").
+		Order("tasks.id ASC").
+		Find(&tasks).Error
+	if err != nil {
+		r.logger.Error("GetCalendarUserTasks failed", "user_id", userID, "err", err)
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetUserProjects — проекты со сроком окончания, где у пользователя есть задачи или команда
+func (r *calendarRepository) GetUserProjects(userID uint) ([]models.Project, error) {
+	var projects []models.Project
+	err := r.db.
+		Where("time_end IS NOT NULL").
+		Where(`id IN (SELECT tasks.project_id FROM tasks JOIN task_users ON task_users.task_id = tasks.id
+			WHERE task_users.user_id = ? AND tasks.deleted_at IS NULL)
+			OR id IN (SELECT teams.project_id FROM teams JOIN team_users ON team_users.team_id = teams.id
+			WHERE team_users.user_id = ? AND teams.deleted_at IS NULL)`, userID, userID).
+		Order("id ASC").
+		Find(&projects).Error
+	if err != nil {
+		r.logger.Error("GetCalendarUserProjects failed", "user_id", userID, "err", err)
+		return nil, err
+	}
+	return projects, nil
+}
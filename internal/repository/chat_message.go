@@ -3,14 +3,37 @@ package repository
 import (
 	"back-minijira-petproject1/internal/models"
 	"context"
+	"database/sql"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ChatRepository interface {
 	Create(ctx context.Context, msg *models.ChatMessage) error
-	GetByChat(ctx context.Context, chatableType string, chatableID uint) ([]models.ChatMessage, error)
+	GetByChat(ctx context.Context, chatableType string, chatableID uint, topLevelOnly bool) ([]models.ChatMessage, error)
+	GetReplies(ctx context.Context, parentID uint) ([]models.ChatMessage, error)
+	CountReplies(ctx context.Context, parentIDs []uint) (map[uint]int, error)
+	GetThreadParticipants(ctx context.Context, parentID uint) ([]uint, error)
 	IsUserInTask(ctx context.Context, taskID, userID uint) (bool, error)
+	IsUserInProjectTeam(ctx context.Context, projectID, userID uint) (bool, error)
+	IsUserInTeam(ctx context.Context, teamID, userID uint) (bool, error)
+	IsUserInDirect(ctx context.Context, conversationID, userID uint) (bool, error)
+	FindDirectByMembers(ctx context.Context, userIDs []uint) (*models.DirectConversation, error)
+	CreateDirect(ctx context.Context, userIDs []uint) (*models.DirectConversation, error)
+	ListDirectForUser(ctx context.Context, userID uint) ([]models.DirectConversation, error)
+	GetChatParticipants(ctx context.Context, chatableType string, chatableID uint) ([]uint, error)
+	GetChatProjectID(ctx context.Context, chatableType string, chatableID uint) (uint, error)
+	GetChatTitle(ctx context.Context, chatableType string, chatableID uint) (string, error)
+	GetByID(ctx context.Context, id uint) (*models.ChatMessage, error)
+	AddReaction(ctx context.Context, reaction *models.ChatReaction) error
+	RemoveReaction(ctx context.Context, messageID, userID uint, emoji string) (int64, error)
+	GetReactions(ctx context.Context, messageIDs []uint) ([]models.ChatReaction, error)
+	GetLatestMessageID(ctx context.Context, chatableType string, chatableID uint) (uint, error)
+	MarkRead(ctx context.Context, userID uint, chatableType string, chatableID, messageID uint) error
+	GetUnreadCounts(ctx context.Context, userID uint) ([]models.UnreadCount, error)
+	GetSeenBy(ctx context.Context, chatableType string, chatableID, messageID uint) ([]uint, error)
 }
 
 type chatRepositoryGorm struct {
@@ -25,16 +48,66 @@ func (r *chatRepositoryGorm) Create(ctx context.Context, msg *models.ChatMessage
 	return r.db.WithContext(ctx).Create(msg).Error
 }
 
-func (r *chatRepositoryGorm) GetByChat(ctx context.Context, chatableType string, chatableID uint) ([]models.ChatMessage, error) {
+func (r *chatRepositoryGorm) GetByChat(ctx context.Context, chatableType string, chatableID uint, topLevelOnly bool) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	query := r.db.WithContext(ctx).
+		Where("chatable_type = ? AND chatable_id = ?", chatableType, chatableID)
+	if topLevelOnly {
+		query = query.Where("parent_id IS NULL")
+	}
+	err := query.Order("created_at ASC").Find(&messages).Error
+
+	return messages, err
+}
+
+func (r *chatRepositoryGorm) GetReplies(ctx context.Context, parentID uint) ([]models.ChatMessage, error) {
 	var messages []models.ChatMessage
 	err := r.db.WithContext(ctx).
-		Where("chatable_type = ? AND chatable_id = ?", chatableType, chatableID).
+		Where("parent_id = ?", parentID).
 		Order("created_at ASC").
 		Find(&messages).Error
 
 	return messages, err
 }
 
+func (r *chatRepositoryGorm) CountReplies(ctx context.Context, parentIDs []uint) (map[uint]int, error) {
+	counts := make(map[uint]int)
+	if len(parentIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ParentID uint
+		Count    int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.ChatMessage{}).
+		Select("parent_id, COUNT(*) AS count").
+		Where("parent_id IN ?", parentIDs).
+		Group("parent_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.ParentID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *chatRepositoryGorm) GetThreadParticipants(ctx context.Context, parentID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).
+		Model(&models.ChatMessage{}).
+		Where("id = ? OR parent_id = ?", parentID, parentID).
+		Distinct().
+		Order("user_id").
+		Pluck("user_id", &ids).Error
+
+	return ids, err
+}
+
 func (r *chatRepositoryGorm) IsUserInTask(ctx context.Context, taskID, userID uint) (bool, error) {
 	var count int64
 	err := r.db.WithContext(ctx).
@@ -46,3 +119,265 @@ func (r *chatRepositoryGorm) IsUserInTask(ctx context.Context, taskID, userID ui
 	}
 	return count > 0, nil
 }
+
+func (r *chatRepositoryGorm) IsUserInProjectTeam(ctx context.Context, projectID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("teams").
+		Joins("LEFT JOIN team_users ON team_users.team_id = teams.id").
+		Where("teams.project_id = ? AND teams.deleted_at IS NULL", projectID).
+		Where("team_users.user_id = ? OR teams.user_id = ?", userID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *chatRepositoryGorm) IsUserInTeam(ctx context.Context, teamID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("teams").
+		Joins("LEFT JOIN team_users ON team_users.team_id = teams.id").
+		Where("teams.id = ? AND teams.deleted_at IS NULL", teamID).
+		Where("team_users.user_id = ? OR teams.user_id = ?", userID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *chatRepositoryGorm) IsUserInDirect(ctx context.Context, conversationID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("direct_conversation_users").
+		Where("direct_conversation_id = ? AND user_id = ?", conversationID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *chatRepositoryGorm) FindDirectByMembers(ctx context.Context, userIDs []uint) (*models.DirectConversation, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).
+		Table("direct_conversation_users").
+		Joins("JOIN direct_conversations dc ON dc.id = direct_conversation_users.direct_conversation_id AND dc.deleted_at IS NULL").
+		Group("direct_conversation_id").
+		Having("COUNT(*) = ? AND COUNT(*) FILTER (WHERE user_id IN ?) = ?", len(userIDs), userIDs, len(userIDs)).
+		Limit(1).
+		Pluck("direct_conversation_id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var conv models.DirectConversation
+	if err := r.db.WithContext(ctx).Preload("Users").First(&conv, ids[0]).Error; err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+func (r *chatRepositoryGorm) CreateDirect(ctx context.Context, userIDs []uint) (*models.DirectConversation, error) {
+	var users []models.User
+	if err := r.db.WithContext(ctx).Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) != len(userIDs) {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	conv := models.DirectConversation{Users: users}
+	if err := r.db.WithContext(ctx).Create(&conv).Error; err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+func (r *chatRepositoryGorm) ListDirectForUser(ctx context.Context, userID uint) ([]models.DirectConversation, error) {
+	var conversations []models.DirectConversation
+	err := r.db.WithContext(ctx).
+		Joins("JOIN direct_conversation_users dcu ON dcu.direct_conversation_id = direct_conversations.id").
+		Where("dcu.user_id = ?", userID).
+		Preload("Users").
+		Order("direct_conversations.updated_at DESC").
+		Find(&conversations).Error
+	return conversations, err
+}
+
+// GetChatParticipants возвращает пользователей, имеющих доступ к чату (без учёта администраторов)
+func (r *chatRepositoryGorm) GetChatParticipants(ctx context.Context, chatableType string, chatableID uint) ([]uint, error) {
+	var ids []uint
+	db := r.db.WithContext(ctx)
+
+	var err error
+	switch chatableType {
+	case "tasks":
+		err = db.Table("task_users").Where("task_id = ?", chatableID).Pluck("user_id", &ids).Error
+	case "projects":
+		err = db.Raw(`
+			SELECT tu.user_id FROM teams t JOIN team_users tu ON tu.team_id = t.id
+			WHERE t.project_id = @id AND t.deleted_at IS NULL
+			UNION
+			SELECT t.user_id FROM teams t
+			WHERE t.project_id = @id AND t.deleted_at IS NULL AND t.user_id <> 0`,
+			sql.Named("id", chatableID)).Scan(&ids).Error
+	case "teams":
+		err = db.Raw(`
+			SELECT tu.user_id FROM teams t JOIN team_users tu ON tu.team_id = t.id
+			WHERE t.id = @id AND t.deleted_at IS NULL
+			UNION
+			SELECT t.user_id FROM teams t
+			WHERE t.id = @id AND t.deleted_at IS NULL AND t.user_id <> 0`,
+			sql.Named("id", chatableID)).Scan(&ids).Error
+	case "direct":
+		err = db.Table("direct_conversation_users").Where("direct_conversation_id = ?", chatableID).Pluck("user_id", &ids).Error
+	}
+
+	return ids, err
+}
+
+// GetChatTitle возвращает название задачи, проекта или команды; для личных переписок — пустую строку
+func (r *chatRepositoryGorm) GetChatTitle(ctx context.Context, chatableType string, chatableID uint) (string, error) {
+	var title string
+	db := r.db.WithContext(ctx)
+
+	var err error
+	switch chatableType {
+	case "tasks":
+		err = db.Model(&models.Task{}).Where("id = ?", chatableID).Select("title").Scan(&title).Error
+	case "projects":
+		err = db.Model(&models.Project{}).Where("id = ?", chatableID).Select("title").Scan(&title).Error
+	case "teams":
+		err = db.Model(&models.Team{}).Where("id = ?", chatableID).Select("name").Scan(&title).Error
+	}
+	return title, err
+}
+
+// GetChatProjectID возвращает проект, к которому относится чат; 0 для личных переписок
+func (r *chatRepositoryGorm) GetChatProjectID(ctx context.Context, chatableType string, chatableID uint) (uint, error) {
+	var projectID uint
+	db := r.db.WithContext(ctx)
+
+	var err error
+	switch chatableType {
+	case "projects":
+		projectID = chatableID
+	case "tasks":
+		err = db.Model(&models.Task{}).Where("id = ?", chatableID).Select("project_id").Scan(&projectID).Error
+	case "teams":
+		err = db.Model(&models.Team{}).Where("id = ?", chatableID).Select("project_id").Scan(&projectID).Error
+	}
+
+	return projectID, err
+}
+
+func (r *chatRepositoryGorm) GetByID(ctx context.Context, id uint) (*models.ChatMessage, error) {
+	var msg models.ChatMessage
+	if err := r.db.WithContext(ctx).First(&msg, id).Error; err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (r *chatRepositoryGorm) AddReaction(ctx context.Context, reaction *models.ChatReaction) error {
+	// Повторная реакция тем же эмодзи не считается ошибкой
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(reaction).Error
+}
+
+func (r *chatRepositoryGorm) RemoveReaction(ctx context.Context, messageID, userID uint, emoji string) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Unscoped().
+		Where("message_id = ? AND user_id = ? AND emoji = ?", messageID, userID, emoji).
+		Delete(&models.ChatReaction{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *chatRepositoryGorm) GetReactions(ctx context.Context, messageIDs []uint) ([]models.ChatReaction, error) {
+	var reactions []models.ChatReaction
+	if len(messageIDs) == 0 {
+		return reactions, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("message_id IN ?", messageIDs).
+		Order("created_at ASC").
+		Find(&reactions).Error
+	return reactions, err
+}
+
+func (r *chatRepositoryGorm) GetLatestMessageID(ctx context.Context, chatableType string, chatableID uint) (uint, error) {
+	var id uint
+	err := r.db.WithContext(ctx).
+		Model(&models.ChatMessage{}).
+		Where("chatable_type = ? AND chatable_id = ?", chatableType, chatableID).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).Error
+	return id, err
+}
+
+func (r *chatRepositoryGorm) MarkRead(ctx context.Context, userID uint, chatableType string, chatableID, messageID uint) error {
+	marker := models.ChatReadMarker{
+		UserID:            userID,
+		ChatableType:      chatableType,
+		ChatableID:        chatableID,
+		LastReadMessageID: messageID,
+		ReadAt:            time.Now(),
+	}
+
+	// Маркер только двигается вперёд: отметка старого сообщения не «разчитывает» новые
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "chatable_type"}, {Name: "chatable_id"}},
+			DoUpdates: clause.Set{
+				{Column: clause.Column{Name: "last_read_message_id"}, Value: gorm.Expr("GREATEST(chat_read_markers.last_read_message_id, EXCLUDED.last_read_message_id)")},
+				{Column: clause.Column{Name: "read_at"}, Value: gorm.Expr("EXCLUDED.read_at")},
+				{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("EXCLUDED.updated_at")},
+			},
+		}).
+		Create(&marker).Error
+}
+
+func (r *chatRepositoryGorm) GetUnreadCounts(ctx context.Context, userID uint) ([]models.UnreadCount, error) {
+	var counts []models.UnreadCount
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT cm.chatable_type, cm.chatable_id, COUNT(*) AS unread
+		FROM chat_messages cm
+		LEFT JOIN chat_read_markers m
+			ON m.user_id = @user AND m.chatable_type = cm.chatable_type AND m.chatable_id = cm.chatable_id
+		WHERE cm.deleted_at IS NULL
+			AND cm.user_id <> @user
+			AND cm.id > COALESCE(m.last_read_message_id, 0)
+			AND (
+				(cm.chatable_type = 'tasks' AND cm.chatable_id IN (
+					SELECT task_id FROM task_users WHERE user_id = @user))
+				OR (cm.chatable_type = 'projects' AND cm.chatable_id IN (
+					SELECT t.project_id FROM teams t
+					LEFT JOIN team_users tu ON tu.team_id = t.id
+					WHERE t.deleted_at IS NULL AND (tu.user_id = @user OR t.user_id = @user)))
+				OR (cm.chatable_type = 'teams' AND cm.chatable_id IN (
+					SELECT t.id FROM teams t
+					LEFT JOIN team_users tu ON tu.team_id = t.id
+					WHERE t.deleted_at IS NULL AND (tu.user_id = @user OR t.user_id = @user)))
+				OR (cm.chatable_type = 'direct' AND cm.chatable_id IN (
+					SELECT direct_conversation_id FROM direct_conversation_users WHERE user_id = @user))
+			)
+		GROUP BY cm.chatable_type, cm.chatable_id
+		ORDER BY cm.chatable_type, cm.chatable_id`,
+		sql.Named("user", userID)).
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *chatRepositoryGorm) GetSeenBy(ctx context.Context, chatableType string, chatableID, messageID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).
+		Model(&models.ChatReadMarker{}).
+		Where("chatable_type = ? AND chatable_id = ? AND last_read_message_id >= ?", chatableType, chatableID, messageID).
+		Order("read_at ASC").
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
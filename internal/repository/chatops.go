@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+type ChatOpsRepository interface {
+	GetByProject(projectID uint) (*models.ChatOpsIntegration, error)
+	Save(integration *models.ChatOpsIntegration) error
+	Delete(projectID uint) error
+}
+
+type chatOpsRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewChatOpsRepository(db *gorm.DB, logger *slog.Logger) ChatOpsRepository {
+	return &chatOpsRepository{db: db, logger: logger}
+}
+
+func (r *chatOpsRepository) GetByProject(projectID uint) (*models.ChatOpsIntegration, error) {
+	var integration models.ChatOpsIntegration
+	if err := r.db.Where("project_id = ?", projectID).First(&integration).Error; err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// Save создаёт интеграцию или сохраняет все поля существующей, включая false и пустые строки
+func (r *chatOpsRepository) Save(integration *models.ChatOpsIntegration) error {
+	if err := r.db.Save(integration).Error; err != nil {
+		r.logger.Error("SaveChatOpsIntegration failed", "project_id", integration.ProjectID, "err", err)
+		return err
+	}
+	return nil
+}
+
+// Delete удаляет интеграцию физически, чтобы проект мог настроить её заново
+func (r *chatOpsRepository) Delete(projectID uint) error {
+	res := r.db.Unscoped().Where("project_id = ?", projectID).Delete(&models.ChatOpsIntegration{})
+	if res.Error != nil {
+		r.logger.Error("DeleteChatOpsIntegration failed", "project_id", projectID, "err", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DigestNotification — уведомление для сводки вместе с названием задачи
+type DigestNotification struct {
+	models.Notification
+	EntityName string
+}
+
+type DigestRepository interface {
+	WithDB(db *gorm.DB) DigestRepository
+	ListRecipients(frequency string) ([]models.User, error)
+	GetDueSoonTasks(userID uint, until time.Time) ([]models.Task, error)
+	GetNotificationsSince(userID uint, types []string, since time.Time, unreadOnly bool) ([]DigestNotification, error)
+	MarkDigestSent(userID uint, at time.Time) error
+}
+
+type digestRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewDigestRepository(db *gorm.DB, logger *slog.Logger) DigestRepository {
+	return &digestRepository{db: db, logger: logger}
+}
+
+func (r *digestRepository) WithDB(db *gorm.DB) DigestRepository {
+	return &digestRepository{db: db, logger: r.logger}
+}
+
+// ListRecipients возвращает подтверждённых пользователей с почтой и нужной частотой сводки
+func (r *digestRepository) ListRecipients(frequency string) ([]models.User, error) {
+	var users []models.User
+	err := r.db.
+		Where("digest_frequency = ? AND is_verified = ? AND email <> ''", frequency, true).
+		Find(&users).Error
+	if err != nil {
+		r.logger.Error("ListRecipients failed", "frequency", frequency, "err", err)
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetDueSoonTasks — незавершённые задачи пользователя со сроком до until, включая просроченные
+func (r *digestRepository) GetDueSoonTasks(userID uint, until time.Time) ([]models.Task, error) {
+	var tasks []models.Task
+	err := r.db.
+		Joins("JOIN task_users ON task_users.task_id = tasks.id").
+		Where("task_users.user_id = ? AND tasks.status <> ? AND tasks.due_date IS NOT NULL AND tasks.due_date <= ?", userID, "done", until).
+		Order("tasks.due_date ASC").
+		Find(&tasks).Error
+	if err != nil {
+		r.logger.Error("GetDueSoonTasks failed", "user_id", userID, "err", err)
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *digestRepository) GetNotificationsSince(userID uint, types []string, since time.Time, unreadOnly bool) ([]DigestNotification, error) {
+	var result []DigestNotification
+	query := r.db.Table("notifications").
+		Select("notifications.*, tasks.title AS entity_name").
+		Joins("LEFT JOIN tasks ON notifications.entity_type = 'tasks' AND tasks.id = notifications.entity_id").
+		Where("notifications.deleted_at IS NULL").
+		Where("notifications.user_id = ? AND notifications.type IN ? AND notifications.created_at > ?", userID, types, since)
+
+	if unreadOnly {
+		query = query.Where("notifications.read_at IS NULL")
+	}
+
+	if err := query.Order("notifications.created_at ASC").Scan(&result).Error; err != nil {
+		r.logger.Error("GetNotificationsSince failed", "user_id", userID, "err", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *digestRepository) MarkDigestSent(userID uint, at time.Time) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("digest_last_sent_at", at).Error
+}
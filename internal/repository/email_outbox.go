@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmailOutboxRepository interface {
+	WithDB(db *gorm.DB) EmailOutboxRepository
+	Enqueue(msg *models.EmailOutbox) error
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.EmailOutbox, error)
+	MarkSent(id uint) error
+	MarkFailed(id uint, attempts int, nextAttemptAt time.Time, lastErr string, dead bool) error
+	ListFailed(limit, offset int) ([]models.EmailOutbox, error)
+	GetByID(id uint) (*models.EmailOutbox, error)
+	Requeue(id uint) error
+}
+
+type emailOutboxRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewEmailOutboxRepository(db *gorm.DB, logger *slog.Logger) EmailOutboxRepository {
+	return &emailOutboxRepository{db: db, logger: logger}
+}
+
+func (r *emailOutboxRepository) WithDB(db *gorm.DB) EmailOutboxRepository {
+	return &emailOutboxRepository{db: db, logger: r.logger}
+}
+
+func (r *emailOutboxRepository) Enqueue(msg *models.EmailOutbox) error {
+	if msg.Status == "" {
+		msg.Status = models.EmailStatusPending
+	}
+	if msg.NextAttemptAt.IsZero() {
+		msg.NextAttemptAt = time.Now()
+	}
+
+	if err := r.db.Create(msg).Error; err != nil {
+		r.logger.Error("Enqueue email failed", "to", msg.To, "err", err)
+		return err
+	}
+	r.logger.Info("Enqueue email success", "id", msg.ID, "to", msg.To)
+	return nil
+}
+
+// ClaimDue забирает письма, готовые к отправке, и сдвигает им next_attempt_at на время аренды,
+// чтобы параллельный воркер не взял их повторно
+func (r *emailOutboxRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.EmailOutbox, error) {
+	var messages []models.EmailOutbox
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.EmailStatusPending, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(messages))
+		for _, m := range messages {
+			ids = append(ids, m.ID)
+		}
+		return tx.Model(&models.EmailOutbox{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		r.logger.Error("ClaimDue emails failed", "err", err)
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *emailOutboxRepository) MarkSent(id uint) error {
+	now := time.Now()
+	return r.db.Model(&models.EmailOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":     models.EmailStatusSent,
+			"sent_at":    now,
+			"last_error": "",
+		}).Error
+}
+
+func (r *emailOutboxRepository) MarkFailed(id uint, attempts int, nextAttemptAt time.Time, lastErr string, dead bool) error {
+	status := models.EmailStatusPending
+	if dead {
+		status = models.EmailStatusDead
+	}
+	return r.db.Model(&models.EmailOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":          status,
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastErr,
+		}).Error
+}
+
+// ListFailed возвращает письма в dead-letter и письма, которые ещё повторяются после ошибок
+func (r *emailOutboxRepository) ListFailed(limit, offset int) ([]models.EmailOutbox, error) {
+	var messages []models.EmailOutbox
+	query := r.db.
+		Where("status = ? OR (status = ? AND attempts > 0)", models.EmailStatusDead, models.EmailStatusPending).
+		Order("updated_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	if err := query.Find(&messages).Error; err != nil {
+		r.logger.Error("ListFailed emails failed", "err", err)
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *emailOutboxRepository) GetByID(id uint) (*models.EmailOutbox, error) {
+	var msg models.EmailOutbox
+	if err := r.db.First(&msg, id).Error; err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (r *emailOutboxRepository) Requeue(id uint) error {
+	res := r.db.Model(&models.EmailOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":          models.EmailStatusPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if res.Error != nil {
+		r.logger.Error("Requeue email failed", "id", id, "err", res.Error)
+		return res.Error
+	}
+	r.logger.Info("Requeue email success", "id", id)
+	return nil
+}
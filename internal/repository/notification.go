@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	CreateNotifications(notifications []models.Notification) error
+	ListByUser(userID uint, filter models.NotificationFilter) ([]models.Notification, error)
+	MarkRead(id, userID uint) (int64, error)
+	MarkAllRead(userID uint) (int64, error)
+	CountUnread(userID uint) (int64, error)
+}
+
+type notificationRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewNotificationRepository(db *gorm.DB, logger *slog.Logger) NotificationRepository {
+	return &notificationRepository{db: db, logger: logger}
+}
+
+func (r *notificationRepository) CreateNotifications(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	res := r.db.Create(&notifications)
+	if res.Error != nil {
+		r.logger.Error("CreateNotifications failed", "err", res.Error)
+		return res.Error
+	}
+	r.logger.Info("CreateNotifications success", "rows", res.RowsAffected)
+	return nil
+}
+
+func (r *notificationRepository) ListByUser(userID uint, filter models.NotificationFilter) ([]models.Notification, error) {
+	var notifications []models.Notification
+	query := r.db.Where("user_id = ? AND digest_only = ?", userID, false)
+
+	if filter.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	if err := query.Order("created_at DESC, id DESC").Find(&notifications).Error; err != nil {
+		r.logger.Error("ListNotifications failed", "user_id", userID, "err", err)
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *notificationRepository) MarkRead(id, userID uint) (int64, error) {
+	res := r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	if res.Error != nil {
+		r.logger.Error("MarkNotificationRead failed", "id", id, "user_id", userID, "err", res.Error)
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
+func (r *notificationRepository) MarkAllRead(userID uint) (int64, error) {
+	res := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL AND digest_only = ?", userID, false).
+		Update("read_at", time.Now())
+	if res.Error != nil {
+		r.logger.Error("MarkAllNotificationsRead failed", "user_id", userID, "err", res.Error)
+		return 0, res.Error
+	}
+	r.logger.Info("MarkAllNotificationsRead success", "user_id", userID, "rows", res.RowsAffected)
+	return res.RowsAffected, nil
+}
+
+func (r *notificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL AND digest_only = ?", userID, false).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationSettingsRepository interface {
+	WithDB(db *gorm.DB) NotificationSettingsRepository
+	GetSettings(userIDs []uint) ([]models.NotificationSettings, error)
+	GetPreferences(userIDs []uint) ([]models.NotificationPreference, error)
+	GetMutes(userIDs []uint) ([]models.ProjectMute, error)
+	SaveSettings(settings *models.NotificationSettings) error
+	SavePreferences(prefs []models.NotificationPreference) error
+	ReplaceMutes(userID uint, projectIDs []uint) error
+	GetDigestFrequency(userID uint) (string, error)
+	SetDigestFrequency(userID uint, frequency string, lastSentAt *time.Time) error
+}
+
+type notificationSettingsRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewNotificationSettingsRepository(db *gorm.DB, logger *slog.Logger) NotificationSettingsRepository {
+	return &notificationSettingsRepository{db: db, logger: logger}
+}
+
+func (r *notificationSettingsRepository) WithDB(db *gorm.DB) NotificationSettingsRepository {
+	return &notificationSettingsRepository{db: db, logger: r.logger}
+}
+
+func (r *notificationSettingsRepository) GetSettings(userIDs []uint) ([]models.NotificationSettings, error) {
+	var settings []models.NotificationSettings
+	if err := r.db.Where("user_id IN ?", userIDs).Find(&settings).Error; err != nil {
+		r.logger.Error("GetNotificationSettings failed", "err", err)
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (r *notificationSettingsRepository) GetPreferences(userIDs []uint) ([]models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	if err := r.db.Where("user_id IN ?", userIDs).Find(&prefs).Error; err != nil {
+		r.logger.Error("GetNotificationPreferences failed", "err", err)
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func (r *notificationSettingsRepository) GetMutes(userIDs []uint) ([]models.ProjectMute, error) {
+	var mutes []models.ProjectMute
+	if err := r.db.Where("user_id IN ?", userIDs).Order("project_id ASC").Find(&mutes).Error; err != nil {
+		r.logger.Error("GetProjectMutes failed", "err", err)
+		return nil, err
+	}
+	return mutes, nil
+}
+
+// SaveSettings создаёт или обновляет единственную запись настроек пользователя
+func (r *notificationSettingsRepository) SaveSettings(settings *models.NotificationSettings) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"timezone", "quiet_hours_start", "quiet_hours_end", "updated_at"}),
+	}).Create(settings).Error
+	if err != nil {
+		r.logger.Error("SaveNotificationSettings failed", "user_id", settings.UserID, "err", err)
+	}
+	return err
+}
+
+func (r *notificationSettingsRepository) SavePreferences(prefs []models.NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"channels", "updated_at"}),
+	}).Create(&prefs).Error
+	if err != nil {
+		r.logger.Error("SaveNotificationPreferences failed", "user_id", prefs[0].UserID, "err", err)
+	}
+	return err
+}
+
+// ReplaceMutes заменяет список заглушённых проектов пользователя целиком
+func (r *notificationSettingsRepository) ReplaceMutes(userID uint, projectIDs []uint) error {
+	if err := r.db.Where("user_id = ?", userID).Delete(&models.ProjectMute{}).Error; err != nil {
+		r.logger.Error("ReplaceProjectMutes: delete failed", "user_id", userID, "err", err)
+		return err
+	}
+	if len(projectIDs) == 0 {
+		return nil
+	}
+
+	mutes := make([]models.ProjectMute, 0, len(projectIDs))
+	for _, id := range projectIDs {
+		mutes = append(mutes, models.ProjectMute{UserID: userID, ProjectID: id})
+	}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&mutes).Error; err != nil {
+		r.logger.Error("ReplaceProjectMutes: create failed", "user_id", userID, "err", err)
+		return err
+	}
+	return nil
+}
+
+// GetDigestFrequency — частота сводки хранится в users рядом с отметкой последней отправки
+func (r *notificationSettingsRepository) GetDigestFrequency(userID uint) (string, error) {
+	var user models.User
+	if err := r.db.Select("digest_frequency").Where("id = ?", userID).First(&user).Error; err != nil {
+		r.logger.Error("GetDigestFrequency failed", "user_id", userID, "err", err)
+		return "", err
+	}
+	return user.DigestFrequency, nil
+}
+
+// SetDigestFrequency меняет частоту сводки вместе с отметкой, от которой считается следующая отправка
+func (r *notificationSettingsRepository) SetDigestFrequency(userID uint, frequency string, lastSentAt *time.Time) error {
+	err := r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{"digest_frequency": frequency, "digest_last_sent_at": lastSentAt}).Error
+	if err != nil {
+		r.logger.Error("SetDigestFrequency failed", "user_id", userID, "err", err)
+	}
+	return err
+}
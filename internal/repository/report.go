@@ -3,6 +3,7 @@ package repository
 import (
 	"back-minijira-petproject1/internal/models"
 	"log/slog"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -13,6 +14,19 @@ type ReportRepository interface {
 	CountTasks(projectID uint) (int, error)
 	CountDoneTasks(projectID uint) (int, error)
 	GetUserTasks(projectID uint, userID uint) ([]models.Task, error)
+	GetProject(projectID uint) (*models.Project, error)
+	HasEstimates(projectID uint) (bool, error)
+	GetBurndown(projectID uint, from, to time.Time, interval string) ([]models.BurndownRow, error)
+	GetCumulativeFlow(projectID uint, from, to time.Time, interval string) ([]models.StatusCountRow, error)
+	GetCompletedTasks(projectID uint, from, to time.Time, priority *int, assigneeIDs []uint) ([]models.CompletedTaskRow, error)
+	GetThroughput(projectID *uint, assigneeIDs []uint, from, to time.Time, interval string) ([]models.BucketCountRow, error)
+	GetPortfolio(now time.Time) ([]models.PortfolioRow, error)
+	GetWorkload(now time.Time, projectID *uint) ([]models.WorkloadRow, error)
+	GetLoggedTime(filter models.LoggedTimeFilter) ([]models.LoggedTimeRow, error)
+	GetCalendarTime(projectID *uint, from, to, now time.Time) ([]models.CalendarTimeRow, error)
+	GetTeam(teamID uint) (*models.Team, []uint, error)
+	CountAssignedTasks(projectID uint, assigneeIDs []uint) (total int, done int, err error)
+	GetTeamContributors(projectID uint, memberIDs []uint, from, to, now time.Time) ([]models.TeamContributorRow, error)
 }
 
 type reportRepo struct {
@@ -61,11 +75,386 @@ func (r *reportRepo) CountDoneTasks(projectID uint) (int, error) {
 	return int(count), err
 }
 
+// GetUserTasks читает только поля, нужные трекеру: без исполнителей и только активные и готовые задачи
 func (r *reportRepo) GetUserTasks(projectID uint, userID uint) ([]models.Task, error) {
 	var tasks []models.Task
-	err := r.db.Joins("JOIN task_users  ON task_users.task_id = tasks.id").
+	err := r.db.Select("tasks.id, tasks.title, tasks.status, tasks.start_task, tasks.finish_task").
+		Joins("JOIN task_users ON task_users.task_id = tasks.id").
 		Where("tasks.project_id = ? AND task_users.user_id = ?", projectID, userID).
-		Preload("Users").
+		Where("tasks.status IN ?", []string{"in_progress", "done"}).
 		Find(&tasks).Error
 	return tasks, err
 }
+
+func (r *reportRepo) GetProject(projectID uint) (*models.Project, error) {
+	var project models.Project
+	if err := r.db.First(&project, projectID).Error; err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *reportRepo) HasEstimates(projectID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Task{}).
+		Where("project_id = ? AND estimate IS NOT NULL", projectID).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// taskStatesCTE восстанавливает состояние задач проекта на конец каждого интервала.
+// Задача учитывается, если создана раньше конца интервала и не удалена к нему.
+// Статус берётся из последней записи истории до конца интервала; если её нет — из
+// from_status первой более поздней записи, а для задач без истории — по start_task/finish_task.
+const taskStatesCTE = `
+WITH buckets AS (
+	SELECT b AS bucket_start, b + CAST(@step AS interval) AS bucket_end
+	FROM generate_series(CAST(@from AS timestamptz), CAST(@to AS timestamptz), CAST(@step AS interval)) AS b
+),
+states AS (
+	SELECT buckets.bucket_start, t.id AS task_id, t.estimate, st.status
+	FROM buckets
+	JOIN tasks t
+		ON t.project_id = @project_id
+		AND t.created_at < buckets.bucket_end
+		AND (t.deleted_at IS NULL OR t.deleted_at >= buckets.bucket_end)
+	CROSS JOIN LATERAL (
+		SELECT COALESCE(
+			(SELECT h.to_status FROM task_status_changes h
+				WHERE h.task_id = t.id AND h.changed_at < buckets.bucket_end
+				ORDER BY h.changed_at DESC, h.id DESC LIMIT 1),
+			(SELECT NULLIF(h.from_status, '') FROM task_status_changes h
+				WHERE h.task_id = t.id
+				ORDER BY h.changed_at, h.id LIMIT 1),
+			CASE
+				WHEN t.status = 'done' AND t.finish_task < buckets.bucket_end THEN 'done'
+				WHEN t.start_task < buckets.bucket_end THEN 'in_progress'
+				ELSE 'todo'
+			END
+		) AS status
+	) st
+)`
+
+const burndownSQL = taskStatesCTE + `
+SELECT
+	buckets.bucket_start AS date,
+	COUNT(states.task_id) AS scope_count,
+	COUNT(states.task_id) FILTER (WHERE states.status = 'done') AS done_count,
+	COALESCE(SUM(states.estimate), 0) AS scope_estimate,
+	COALESCE(SUM(states.estimate) FILTER (WHERE states.status = 'done'), 0) AS done_estimate
+FROM buckets
+LEFT JOIN states ON states.bucket_start = buckets.bucket_start
+GROUP BY buckets.bucket_start
+ORDER BY buckets.bucket_start`
+
+const cumulativeFlowSQL = taskStatesCTE + `
+SELECT bucket_start AS date, status, COUNT(*) AS count
+FROM states
+GROUP BY bucket_start, status
+ORDER BY bucket_start, status`
+
+// reportStep переводит интервал отчёта в шаг generate_series
+func reportStep(interval string) string {
+	switch interval {
+	case models.ReportIntervalWeek:
+		return "7 days"
+	case models.ReportIntervalMonth:
+		return "1 month"
+	default:
+		return "1 day"
+	}
+}
+
+func (r *reportRepo) GetBurndown(projectID uint, from, to time.Time, interval string) ([]models.BurndownRow, error) {
+	var rows []models.BurndownRow
+	err := r.db.Raw(burndownSQL, map[string]any{
+		"project_id": projectID,
+		"from":       from,
+		"to":         to,
+		"step":       reportStep(interval),
+	}).Scan(&rows).Error
+	if err != nil {
+		r.logger.Error("GetBurndown failed", "project_id", projectID, "err", err)
+	}
+	return rows, err
+}
+
+// GetCumulativeFlow считает задачи в каждом статусе на конец каждого интервала.
+// Интервалы без задач в результат не попадают — их дополняет сервис.
+func (r *reportRepo) GetCumulativeFlow(projectID uint, from, to time.Time, interval string) ([]models.StatusCountRow, error) {
+	var rows []models.StatusCountRow
+	err := r.db.Raw(cumulativeFlowSQL, map[string]any{
+		"project_id": projectID,
+		"from":       from,
+		"to":         to,
+		"step":       reportStep(interval),
+	}).Scan(&rows).Error
+	if err != nil {
+		r.logger.Error("GetCumulativeFlow failed", "project_id", projectID, "err", err)
+	}
+	return rows, err
+}
+
+// GetCompletedTasks возвращает задачи в статусе done, завершённые в [from, to).
+// Начало работы — первый переход в in_progress, завершение — последний переход в done;
+// для задач без истории берутся start_task и finish_task.
+func (r *reportRepo) GetCompletedTasks(projectID uint, from, to time.Time, priority *int, assigneeIDs []uint) ([]models.CompletedTaskRow, error) {
+	doneAt := "COALESCE(fd.done_at, tasks.finish_task)"
+
+	query := r.db.Model(&models.Task{}).
+		Select("tasks.id AS task_id, tasks.created_at, "+
+			"COALESCE(fs.started_at, tasks.start_task) AS started_at, "+
+			doneAt+" AS done_at, "+
+			"EXISTS (SELECT 1 FROM task_status_changes h WHERE h.task_id = tasks.id AND h.from_status = 'done') AS reopened").
+		Joins("LEFT JOIN LATERAL (SELECT MIN(h.changed_at) AS started_at FROM task_status_changes h "+
+			"WHERE h.task_id = tasks.id AND h.to_status = 'in_progress') fs ON true").
+		Joins("LEFT JOIN LATERAL (SELECT MAX(h.changed_at) AS done_at FROM task_status_changes h "+
+			"WHERE h.task_id = tasks.id AND h.to_status = 'done') fd ON true").
+		Where("tasks.project_id = ? AND tasks.status = ?", projectID, "done").
+		Where(doneAt+" >= ? AND "+doneAt+" < ?", from, to)
+
+	if priority != nil {
+		query = query.Where("tasks.priority = ?", *priority)
+	}
+	if assigneeIDs != nil {
+		query = query.Where(assignedToSQL, assigneeIDs)
+	}
+
+	var rows []models.CompletedTaskRow
+	if err := query.Scan(&rows).Error; err != nil {
+		r.logger.Error("GetCompletedTasks failed", "project_id", projectID, "err", err)
+		return nil, err
+	}
+	return rows, nil
+}
+
+// assignedToSQL — задача назначена хотя бы на одного из пользователей
+const assignedToSQL = "EXISTS (SELECT 1 FROM task_users tu WHERE tu.task_id = tasks.id AND tu.user_id IN ?)"
+
+// GetThroughput считает задачи, закрытые в [from, to), по интервалам date_trunc в UTC.
+// Момент закрытия — последний переход в done; projectID == nil — по всем проектам,
+// assigneeIDs == nil — без фильтра по исполнителям.
+func (r *reportRepo) GetThroughput(projectID *uint, assigneeIDs []uint, from, to time.Time, interval string) ([]models.BucketCountRow, error) {
+	doneAt := "COALESCE(fd.done_at, tasks.finish_task)"
+
+	query := r.db.Model(&models.Task{}).
+		Select("date_trunc(?, "+doneAt+" AT TIME ZONE 'UTC') AS bucket, COUNT(*) AS count", interval).
+		Joins("LEFT JOIN LATERAL (SELECT MAX(h.changed_at) AS done_at FROM task_status_changes h "+
+			"WHERE h.task_id = tasks.id AND h.to_status = 'done') fd ON true").
+		Where("tasks.status = ?", "done").
+		Where(doneAt+" >= ? AND "+doneAt+" < ?", from, to)
+	if projectID != nil {
+		query = query.Where("tasks.project_id = ?", *projectID)
+	}
+	if assigneeIDs != nil {
+		query = query.Where(assignedToSQL, assigneeIDs)
+	}
+
+	var rows []models.BucketCountRow
+	if err := query.Group("bucket").Order("bucket").Scan(&rows).Error; err != nil {
+		r.logger.Error("GetThroughput failed", "project_id", projectID, "err", err)
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetPortfolio одним запросом считает агрегаты задач по всем проектам.
+// Просроченная задача — не done и с due_date раньше now.
+func (r *reportRepo) GetPortfolio(now time.Time) ([]models.PortfolioRow, error) {
+	var rows []models.PortfolioRow
+	err := r.db.Model(&models.Project{}).
+		Select("projects.id AS project_id, projects.title, projects.status, projects.created_at, projects.time_end, "+
+			"COUNT(tasks.id) AS total_tasks, "+
+			"COUNT(tasks.id) FILTER (WHERE tasks.status = 'done') AS done_tasks, "+
+			"COUNT(tasks.id) FILTER (WHERE tasks.status = 'in_progress') AS in_progress_tasks, "+
+			"COUNT(tasks.id) FILTER (WHERE tasks.status <> 'done' AND tasks.due_date < ?) AS overdue_tasks", now).
+		Joins("LEFT JOIN tasks ON tasks.project_id = projects.id AND tasks.deleted_at IS NULL").
+		Group("projects.id").
+		Order("projects.id").
+		Scan(&rows).Error
+	if err != nil {
+		r.logger.Error("GetPortfolio failed", "err", err)
+	}
+	return rows, err
+}
+
+// workloadWeightSQL — очки нагрузки задачи: 1 для обычной, 2 для важной, 3 для очень важной,
+// поровну на всех исполнителей
+const workloadWeightSQL = "(GREATEST(tasks.priority, 0) + 1)::float / NULLIF(ac.assignees, 0)"
+
+// GetWorkload считает открытые задачи каждого пользователя по всем проектам или по одному
+func (r *reportRepo) GetWorkload(now time.Time, projectID *uint) ([]models.WorkloadRow, error) {
+	taskJoin := "LEFT JOIN tasks ON tasks.id = tu.task_id AND tasks.deleted_at IS NULL AND tasks.status <> 'done'"
+	var joinArgs []any
+	if projectID != nil {
+		taskJoin += " AND tasks.project_id = ?"
+		joinArgs = append(joinArgs, *projectID)
+	}
+
+	var rows []models.WorkloadRow
+	err := r.db.Model(&models.User{}).
+		Select("users.id AS user_id, users.full_name AS name, users.capacity, "+
+			"COUNT(tasks.id) AS open_tasks, "+
+			"COUNT(tasks.id) FILTER (WHERE tasks.status = 'in_progress') AS in_progress_tasks, "+
+			"COALESCE(SUM("+workloadWeightSQL+"), 0) AS weighted_load, "+
+			"MIN(tasks.start_task) FILTER (WHERE tasks.status = 'in_progress') AS oldest_started_at, "+
+			"COALESCE(AVG(EXTRACT(EPOCH FROM (? - tasks.start_task))) FILTER (WHERE tasks.status = 'in_progress'), 0) AS avg_in_progress_seconds", now).
+		Joins("LEFT JOIN task_users tu ON tu.user_id = users.id").
+		Joins(taskJoin, joinArgs...).
+		Joins("LEFT JOIN LATERAL (SELECT COUNT(*) AS assignees FROM task_users x WHERE x.task_id = tasks.id) ac ON true").
+		Group("users.id").
+		Order("weighted_load DESC, users.id").
+		Scan(&rows).Error
+	if err != nil {
+		r.logger.Error("GetWorkload failed", "err", err)
+	}
+	return rows, err
+}
+
+// GetLoggedTime суммирует worklogs по задачам и пользователям; записи обрезаются
+// по границам периода, поэтому длинный таймер на стыке не попадает в период целиком
+func (r *reportRepo) GetLoggedTime(filter models.LoggedTimeFilter) ([]models.LoggedTimeRow, error) {
+	query := r.db.Model(&models.Worklog{}).
+		Select("worklogs.task_id, tasks.title AS task_title, worklogs.project_id, projects.title AS project_title, "+
+			"worklogs.user_id, users.full_name AS user_name, "+
+			"SUM(EXTRACT(EPOCH FROM LEAST(COALESCE(worklogs.ended_at, ?), ?) - GREATEST(worklogs.started_at, ?))) AS seconds",
+			filter.Now, filter.To, filter.From).
+		Joins("JOIN tasks ON tasks.id = worklogs.task_id").
+		Joins("JOIN projects ON projects.id = worklogs.project_id").
+		Joins("JOIN users ON users.id = worklogs.user_id").
+		Where("worklogs.started_at < ? AND COALESCE(worklogs.ended_at, ?) > ?", filter.To, filter.Now, filter.From)
+	if filter.ProjectID != nil {
+		query = query.Where("worklogs.project_id = ?", *filter.ProjectID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("worklogs.user_id = ?", *filter.UserID)
+	}
+
+	var rows []models.LoggedTimeRow
+	err := query.
+		Group("worklogs.task_id, tasks.title, worklogs.project_id, projects.title, worklogs.user_id, users.full_name").
+		Order("seconds DESC").
+		Scan(&rows).Error
+	if err != nil {
+		r.logger.Error("GetLoggedTime failed", "project_id", filter.ProjectID, "user_id", filter.UserID, "err", err)
+	}
+	return rows, err
+}
+
+// calendarTimeSQL режет историю статусов на отрезки «от перехода до следующего перехода»
+// и суммирует отрезки in_progress, обрезанные по периоду. Задачи без истории не учитываются.
+const calendarTimeSQL = `
+WITH spans AS (
+	SELECT h.task_id, h.project_id, h.to_status, h.changed_at AS span_start,
+		LEAD(h.changed_at) OVER (PARTITION BY h.task_id ORDER BY h.changed_at, h.id) AS span_end
+	FROM task_status_changes h
+	WHERE (CAST(@project_id AS bigint) = 0 OR h.project_id = @project_id)
+)
+SELECT spans.task_id, tasks.title AS task_title, spans.project_id,
+	SUM(EXTRACT(EPOCH FROM LEAST(COALESCE(spans.span_end, @now), @to) - GREATEST(spans.span_start, @from))) AS seconds
+FROM spans
+JOIN tasks ON tasks.id = spans.task_id AND tasks.deleted_at IS NULL
+WHERE spans.to_status = 'in_progress'
+	AND spans.span_start < @to
+	AND COALESCE(spans.span_end, @now) > @from
+GROUP BY spans.task_id, tasks.title, spans.project_id`
+
+func (r *reportRepo) GetCalendarTime(projectID *uint, from, to, now time.Time) ([]models.CalendarTimeRow, error) {
+	var id uint
+	if projectID != nil {
+		id = *projectID
+	}
+
+	var rows []models.CalendarTimeRow
+	err := r.db.Raw(calendarTimeSQL, map[string]any{
+		"project_id": id,
+		"from":       from,
+		"to":         to,
+		"now":        now,
+	}).Scan(&rows).Error
+	if err != nil {
+		r.logger.Error("GetCalendarTime failed", "project_id", id, "err", err)
+	}
+	return rows, err
+}
+
+// GetTeam возвращает команду и ID её участников
+func (r *reportRepo) GetTeam(teamID uint) (*models.Team, []uint, error) {
+	var team models.Team
+	if err := r.db.First(&team, teamID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var memberIDs []uint
+	err := r.db.Table("team_users").Where("team_id = ?", teamID).Order("user_id").Pluck("user_id", &memberIDs).Error
+	if err != nil {
+		r.logger.Error("GetTeam members failed", "team_id", teamID, "err", err)
+		return nil, nil, err
+	}
+	return &team, memberIDs, nil
+}
+
+// CountAssignedTasks считает задачи проекта, назначенные хотя бы на одного из пользователей
+func (r *reportRepo) CountAssignedTasks(projectID uint, assigneeIDs []uint) (total int, done int, err error) {
+	var counts struct {
+		Total int
+		Done  int
+	}
+	err = r.db.Model(&models.Task{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE tasks.status = 'done') AS done").
+		Where("tasks.project_id = ?", projectID).
+		Where(assignedToSQL, assigneeIDs).
+		Scan(&counts).Error
+	if err != nil {
+		r.logger.Error("CountAssignedTasks failed", "project_id", projectID, "err", err)
+	}
+	return counts.Total, counts.Done, err
+}
+
+// teamContributorsSQL — вклад каждого участника в проект команды: закрытые в [from, to) задачи,
+// задачи в работе сейчас и записанное за период время. Участники без вклада тоже попадают в список.
+const teamContributorsSQL = `
+SELECT users.id AS user_id, users.full_name AS name,
+	COALESCE(done.count, 0) AS completed_tasks,
+	COALESCE(active.count, 0) AS in_progress_tasks,
+	COALESCE(logged.seconds, 0) AS logged_seconds
+FROM users
+LEFT JOIN LATERAL (
+	SELECT COUNT(*) AS count
+	FROM tasks
+	JOIN task_users tu ON tu.task_id = tasks.id AND tu.user_id = users.id
+	LEFT JOIN LATERAL (SELECT MAX(h.changed_at) AS done_at FROM task_status_changes h
+		WHERE h.task_id = tasks.id AND h.to_status = 'done') fd ON true
+	WHERE tasks.project_id = @project_id AND tasks.deleted_at IS NULL AND tasks.status = 'done'
+		AND COALESCE(fd.done_at, tasks.finish_task) >= @from AND COALESCE(fd.done_at, tasks.finish_task) < @to
+) done ON true
+LEFT JOIN LATERAL (
+	SELECT COUNT(*) AS count
+	FROM tasks
+	JOIN task_users tu ON tu.task_id = tasks.id AND tu.user_id = users.id
+	WHERE tasks.project_id = @project_id AND tasks.deleted_at IS NULL AND tasks.status = 'in_progress'
+) active ON true
+LEFT JOIN LATERAL (
+	SELECT SUM(EXTRACT(EPOCH FROM LEAST(COALESCE(w.ended_at, @now), @to) - GREATEST(w.started_at, @from))) AS seconds
+	FROM worklogs w
+	WHERE w.user_id = users.id AND w.project_id = @project_id AND w.deleted_at IS NULL
+		AND w.started_at < @to AND COALESCE(w.ended_at, @now) > @from
+) logged ON true
+WHERE users.id IN @members AND users.deleted_at IS NULL
+ORDER BY completed_tasks DESC, logged_seconds DESC, users.id`
+
+func (r *reportRepo) GetTeamContributors(projectID uint, memberIDs []uint, from, to, now time.Time) ([]models.TeamContributorRow, error) {
+	var rows []models.TeamContributorRow
+	err := r.db.Raw(teamContributorsSQL, map[string]any{
+		"project_id": projectID,
+		"members":    memberIDs,
+		"from":       from,
+		"to":         to,
+		"now":        now,
+	}).Scan(&rows).Error
+	if err != nil {
+		r.logger.Error("GetTeamContributors failed", "project_id", projectID, "err", err)
+	}
+	return rows, err
+}
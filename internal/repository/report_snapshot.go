@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReportSnapshotRepository interface {
+	// Refresh пересчитывает снимки дня day по живым задачам; пустой projectIDs — все проекты
+	Refresh(day, now time.Time, projectIDs []uint) error
+	Get(projectID uint, day time.Time) (*models.ReportSnapshot, error)
+	// GetOnOrBefore возвращает последний снимок не позже day
+	GetOnOrBefore(projectID uint, day time.Time) (*models.ReportSnapshot, error)
+	List(projectID uint, from, to time.Time) ([]models.ReportSnapshot, error)
+}
+
+type reportSnapshotRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewReportSnapshotRepository(db *gorm.DB, logger *slog.Logger) ReportSnapshotRepository {
+	return &reportSnapshotRepository{db: db, logger: logger}
+}
+
+// refreshSnapshotsSQL считает показатели всех выбранных проектов одним проходом по задачам
+// и обновляет строку дня на месте, поэтому повторный пересчёт безопасен
+const refreshSnapshotsSQL = `
+INSERT INTO report_snapshots (project_id, day, total_tasks, todo_tasks, in_progress_tasks, done_tasks, overdue_tasks,
+	estimate_total, estimate_done, completion_percent, avg_cycle_seconds, refreshed_at)
+SELECT projects.id, CAST(@day AS date),
+	COUNT(tasks.id),
+	COUNT(tasks.id) FILTER (WHERE tasks.status = 'todo'),
+	COUNT(tasks.id) FILTER (WHERE tasks.status = 'in_progress'),
+	COUNT(tasks.id) FILTER (WHERE tasks.status = 'done'),
+	COUNT(tasks.id) FILTER (WHERE tasks.status <> 'done' AND tasks.due_date < @now),
+	COALESCE(SUM(tasks.estimate), 0),
+	COALESCE(SUM(tasks.estimate) FILTER (WHERE tasks.status = 'done'), 0),
+	COALESCE(ROUND(100.0 * COUNT(tasks.id) FILTER (WHERE tasks.status = 'done') / NULLIF(COUNT(tasks.id), 0), 2), 0),
+	COALESCE(AVG(EXTRACT(EPOCH FROM tasks.finish_task - tasks.start_task))
+		FILTER (WHERE tasks.status = 'done' AND tasks.start_task IS NOT NULL AND tasks.finish_task IS NOT NULL), 0),
+	@now
+FROM projects
+LEFT JOIN tasks ON tasks.project_id = projects.id AND tasks.deleted_at IS NULL
+WHERE projects.deleted_at IS NULL AND (@all OR projects.id IN @ids)
+GROUP BY projects.id
+ON CONFLICT (project_id, day) DO UPDATE SET
+	total_tasks = EXCLUDED.total_tasks,
+	todo_tasks = EXCLUDED.todo_tasks,
+	in_progress_tasks = EXCLUDED.in_progress_tasks,
+	done_tasks = EXCLUDED.done_tasks,
+	overdue_tasks = EXCLUDED.overdue_tasks,
+	estimate_total = EXCLUDED.estimate_total,
+	estimate_done = EXCLUDED.estimate_done,
+	completion_percent = EXCLUDED.completion_percent,
+	avg_cycle_seconds = EXCLUDED.avg_cycle_seconds,
+	refreshed_at = EXCLUDED.refreshed_at`
+
+func (r *reportSnapshotRepository) Refresh(day, now time.Time, projectIDs []uint) error {
+	ids := projectIDs
+	if len(ids) == 0 {
+		// IN с пустым списком — синтаксическая ошибка, а при all список всё равно не проверяется
+		ids = []uint{0}
+	}
+
+	err := r.db.Exec(refreshSnapshotsSQL, map[string]any{
+		"day": snapshotDay(day),
+		"now": now,
+		"all": len(projectIDs) == 0,
+		"ids": ids,
+	}).Error
+	if err != nil {
+		r.logger.Error("RefreshSnapshots failed", "day", day, "projects", len(projectIDs), "err", err)
+	}
+	return err
+}
+
+func (r *reportSnapshotRepository) Get(projectID uint, day time.Time) (*models.ReportSnapshot, error) {
+	var snapshot models.ReportSnapshot
+	if err := r.db.Where("project_id = ? AND day = CAST(? AS date)", projectID, snapshotDay(day)).First(&snapshot).Error; err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (r *reportSnapshotRepository) GetOnOrBefore(projectID uint, day time.Time) (*models.ReportSnapshot, error) {
+	var snapshot models.ReportSnapshot
+	err := r.db.Where("project_id = ? AND day <= CAST(? AS date)", projectID, snapshotDay(day)).
+		Order("day DESC").
+		First(&snapshot).Error
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (r *reportSnapshotRepository) List(projectID uint, from, to time.Time) ([]models.ReportSnapshot, error) {
+	var snapshots []models.ReportSnapshot
+	err := r.db.Where("project_id = ? AND day BETWEEN CAST(? AS date) AND CAST(? AS date)", projectID, snapshotDay(from), snapshotDay(to)).
+		Order("day").
+		Find(&snapshots).Error
+	if err != nil {
+		r.logger.Error("ListSnapshots failed", "project_id", projectID, "err", err)
+	}
+	return snapshots, err
+}
+
+// snapshotDay передаёт день строкой: приведение timestamptz к date зависит от часового пояса сессии
+func snapshotDay(day time.Time) string {
+	return day.Format("2006-01-02")
+}
@@ -10,12 +10,14 @@ import (
 
 type TaskRepository interface {
 	WithDB(db *gorm.DB) TaskRepository
-	CreateTask(req *models.TaskCreateReq) error
+	CreateTask(req *models.TaskCreateReq) (*models.Task, error)
 	UpdateTask(id uint, req models.TaskUpdateReq) error
 	DeleteTask(id uint) error
 	ListTasks(filter *models.TaskFilter) ([]*models.Task, error)
+	EachTaskBatch(filter *models.TaskFilter, batchSize int, fn func([]*models.Task) error) error
 	GetTaskByID(id uint) (*models.Task, error)
 	CountTasksByStatusByProjectID(project_id uint, task_id uint, status string) (int64, error)
+	AddStatusChange(change *models.TaskStatusChange) error
 }
 
 type taskRepository struct {
@@ -27,7 +29,7 @@ func NewTaskRepository(db *gorm.DB, logger *slog.Logger) TaskRepository {
 	return &taskRepository{db: db, logger: logger}
 }
 
-func (r *taskRepository) CreateTask(req *models.TaskCreateReq) error {
+func (r *taskRepository) CreateTask(req *models.TaskCreateReq) (*models.Task, error) {
 	task := models.Task{
 		Title:       req.Title,
 		Description: req.Description,
@@ -38,14 +40,16 @@ func (r *taskRepository) CreateTask(req *models.TaskCreateReq) error {
 		LimitUser:   req.LimitUser,
 		StartTask:   req.StartTask,
 		FinishTask:  req.FinishTask,
+		DueDate:     req.DueDate,
+		Estimate:    req.Estimate,
 	}
 	res := r.db.Create(&task)
 	if res.Error != nil {
 		r.logger.Error("CreateTask failed")
-		return res.Error
+		return nil, res.Error
 	}
 	r.logger.Info("CreateTask success", "rows", res.RowsAffected)
-	return nil
+	return &task, nil
 }
 
 func (r *taskRepository) UpdateTask(id uint, req models.TaskUpdateReq) error {
@@ -76,6 +80,12 @@ func (r *taskRepository) UpdateTask(id uint, req models.TaskUpdateReq) error {
 	if req.FinishTask != nil {
 		updates["finish_task"] = *req.FinishTask
 	}
+	if req.DueDate != nil {
+		updates["due_date"] = *req.DueDate
+	}
+	if req.Estimate != nil {
+		updates["estimate"] = *req.Estimate
+	}
 
 	// Если есть поля для обновления
 	if len(updates) > 0 {
@@ -105,6 +115,97 @@ func (r *taskRepository) DeleteTask(id uint) error {
 func (r *taskRepository) ListTasks(filter *models.TaskFilter) ([]*models.Task, error) {
 	var tasks []*models.Task
 
+	query := r.listQuery(filter)
+
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	// Загружаем задачи с пользователями
+	if err := query.Preload("Users").Find(&tasks).Error; err != nil {
+		r.logger.Error("ListTask failed", "err", err)
+		return nil, err
+	}
+	r.logger.Info("ListTask success", "count", len(tasks))
+	return tasks, nil
+}
+
+// EachTaskBatch отдаёт все задачи по фильтру пачками по batchSize, не загружая их разом.
+// Задачи читаются одним курсором в порядке списка: без OFFSET база не перечитывает
+// пропущенные строки, а задачи, созданные или удалённые во время выгрузки, не сдвигают пачки.
+// Limit и Offset фильтра не учитываются.
+func (r *taskRepository) EachTaskBatch(filter *models.TaskFilter, batchSize int, fn func([]*models.Task) error) error {
+	rows, err := r.listQuery(filter).Select("tasks.*").Order("tasks.id").Rows()
+	if err != nil {
+		r.logger.Error("EachTaskBatch failed", "err", err)
+		return err
+	}
+	defer rows.Close()
+
+	batch := make([]*models.Task, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := r.loadUsers(batch); err != nil {
+			return err
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		batch = make([]*models.Task, 0, batchSize)
+		return nil
+	}
+
+	for rows.Next() {
+		var task models.Task
+		if err := r.db.ScanRows(rows, &task); err != nil {
+			r.logger.Error("EachTaskBatch scan failed", "err", err)
+			return err
+		}
+		batch = append(batch, &task)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("EachTaskBatch failed", "err", err)
+		return err
+	}
+	return flush()
+}
+
+// loadUsers подгружает исполнителей пачки одним запросом, как Preload("Users")
+func (r *taskRepository) loadUsers(tasks []*models.Task) error {
+	ids := make([]uint, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+
+	var withUsers []*models.Task
+	if err := r.db.Select("id").Preload("Users").Where("id IN ?", ids).Find(&withUsers).Error; err != nil {
+		r.logger.Error("EachTaskBatch failed to load users", "err", err)
+		return err
+	}
+
+	users := make(map[uint][]models.User, len(withUsers))
+	for _, task := range withUsers {
+		users[task.ID] = task.Users
+	}
+	for _, task := range tasks {
+		task.Users = users[task.ID]
+	}
+	return nil
+}
+
+// listQuery строит запрос списка задач с фильтрами и сортировкой, без пагинации
+func (r *taskRepository) listQuery(filter *models.TaskFilter) *gorm.DB {
 	query := r.db.Model(&models.Task{})
 
 	if filter.Status != nil {
@@ -147,23 +248,7 @@ func (r *taskRepository) ListTasks(filter *models.TaskFilter) ([]*models.Task, e
 		sortOrder = "ASC"
 	}
 	orderClause := sortField + " " + sortOrder + ", created_at DESC"
-	query = query.Order(orderClause)
-
-	if filter.Limit > 0 {
-		query = query.Limit(filter.Limit)
-	}
-
-	if filter.Offset > 0 {
-		query = query.Offset(filter.Offset)
-	}
-
-	// Загружаем задачи с пользователями
-	if err := query.Preload("Users").Find(&tasks).Error; err != nil {
-		r.logger.Error("ListTask failed", "err", err)
-		return nil, err
-	}
-	r.logger.Info("ListTask success", "count", len(tasks))
-	return tasks, nil
+	return query.Order(orderClause)
 }
 
 func (r *taskRepository) GetTaskByID(id uint) (*models.Task, error) {
@@ -176,6 +261,15 @@ func (r *taskRepository) GetTaskByID(id uint) (*models.Task, error) {
 	return &task, nil
 }
 
+// AddStatusChange дописывает переход в историю статусов задачи
+func (r *taskRepository) AddStatusChange(change *models.TaskStatusChange) error {
+	if err := r.db.Create(change).Error; err != nil {
+		r.logger.Error("AddStatusChange failed", "task_id", change.TaskID, "err", err)
+		return err
+	}
+	return nil
+}
+
 func (r *taskRepository) WithDB(db *gorm.DB) TaskRepository {
 	return &taskRepository{db: db, logger: r.logger}
 }
@@ -4,6 +4,7 @@ import (
 	"back-minijira-petproject1/internal/models"
 	"errors"
 	"log/slog"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -13,12 +14,17 @@ type UserRepository interface {
 	GetUserByID(id uint) (models.User, []uint, error)
 	UpdateUser(req *models.User) error
 	DeleteUser(id uint) error
-	AssignTasksToUser(user *models.User, taskIDs []uint) error
+	AssignTasksToUser(user *models.User, taskIDs []uint) ([]models.Task, error)
 	GetUserByEmail(email string) (models.User, error)
 	GetUserVerifyToken(token string) (models.User, error)
 	UpdateUserVerification(id uint, isVerified bool, token string) error
 	CountUsers() (int64, error)
 	ListUsers() ([]models.User, error)
+	GetUsersByIDs(ids []uint) ([]models.User, error)
+	SetResetTokenHash(id uint, hash string, expiresAt time.Time) error
+	GetUserByResetTokenHash(hash string) (models.User, error)
+	UpdatePassword(id uint, passwordHash string) error
+	WithDB(db *gorm.DB) UserRepository
 }
 
 type userRepository struct {
@@ -77,9 +83,10 @@ func (r *userRepository) DeleteUser(id uint) error {
 	return nil
 }
 
-func (r *userRepository) AssignTasksToUser(user *models.User, taskIDs []uint) error {
+// AssignTasksToUser заменяет задачи пользователя и возвращает те, что теперь на нём
+func (r *userRepository) AssignTasksToUser(user *models.User, taskIDs []uint) ([]models.Task, error) {
 	if len(taskIDs) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	r.logger.Info("assignTasksToUser called", "user_id", user.ID, "task_ids", taskIDs)
@@ -87,15 +94,15 @@ func (r *userRepository) AssignTasksToUser(user *models.User, taskIDs []uint) er
 	var tasks []models.Task
 	if err := r.db.Where("id IN ?", taskIDs).Find(&tasks).Error; err != nil {
 		r.logger.Error("assignTasksToUser: invalid task IDs", "error", err)
-		return errors.New("некорректное айди")
+		return nil, errors.New("некорректное айди")
 	}
 
 	if err := r.db.Model(user).Association("Tasks").Replace(tasks); err != nil {
 		r.logger.Error("assignTasksToUser: failed replacing tasks", "error", err)
-		return err
+		return nil, err
 	}
 
-	return nil
+	return tasks, nil
 }
 
 func (r *userRepository) GetUserByEmail(email string) (models.User, error) {
@@ -145,3 +152,47 @@ func (r *userRepository) ListUsers() ([]models.User, error) {
 	r.logger.Info("ListUsers success", "count", len(users))
 	return users, nil
 }
+
+func (r *userRepository) GetUsersByIDs(ids []uint) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := r.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		r.logger.Error("GetUsersByIDs failed", "ids", ids, "err", err)
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepository) SetResetTokenHash(id uint, hash string, expiresAt time.Time) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"reset_token_hash":       hash,
+			"reset_token_expires_at": expiresAt,
+		}).Error
+}
+
+func (r *userRepository) GetUserByResetTokenHash(hash string) (models.User, error) {
+	var user models.User
+	if err := r.db.Where("reset_token_hash = ?", hash).First(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// UpdatePassword меняет хэш пароля и гасит токен сброса, чтобы ссылкой нельзя было воспользоваться повторно
+func (r *userRepository) UpdatePassword(id uint, passwordHash string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"password_hash":          passwordHash,
+			"reset_token_hash":       "",
+			"reset_token_expires_at": nil,
+		}).Error
+}
+
+func (r *userRepository) WithDB(db *gorm.DB) UserRepository {
+	return &userRepository{db: db, logger: r.logger}
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	Create(webhook *models.Webhook) error
+	Update(webhook *models.Webhook) error
+	Delete(id uint) error
+	GetByID(id uint) (*models.Webhook, error)
+	ListByProject(projectID uint) ([]models.Webhook, error)
+	ListSubscribed(projectID uint, eventType string) ([]models.Webhook, error)
+
+	CreateDeliveries(deliveries []models.WebhookDelivery) error
+	ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	MarkDeliverySuccess(id uint, attempts, responseStatus int, responseBody string) error
+	MarkDeliveryFailed(id uint, attempts int, nextAttemptAt time.Time, responseStatus int, responseBody, lastErr string, dead bool) error
+	ListDeliveries(webhookID uint, limit, offset int) ([]models.WebhookDelivery, error)
+	GetDelivery(id uint) (*models.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewWebhookRepository(db *gorm.DB, logger *slog.Logger) WebhookRepository {
+	return &webhookRepository{db: db, logger: logger}
+}
+
+func (r *webhookRepository) Create(webhook *models.Webhook) error {
+	if err := r.db.Create(webhook).Error; err != nil {
+		r.logger.Error("CreateWebhook failed", "project_id", webhook.ProjectID, "err", err)
+		return err
+	}
+	r.logger.Info("CreateWebhook success", "id", webhook.ID, "project_id", webhook.ProjectID)
+	return nil
+}
+
+func (r *webhookRepository) Update(webhook *models.Webhook) error {
+	if err := r.db.Save(webhook).Error; err != nil {
+		r.logger.Error("UpdateWebhook failed", "id", webhook.ID, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (r *webhookRepository) Delete(id uint) error {
+	res := r.db.Delete(&models.Webhook{}, id)
+	if res.Error != nil {
+		r.logger.Error("DeleteWebhook failed", "id", id, "err", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) GetByID(id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := r.db.First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) ListByProject(projectID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := r.db.Where("project_id = ?", projectID).Order("id").Find(&webhooks).Error; err != nil {
+		r.logger.Error("ListWebhooks failed", "project_id", projectID, "err", err)
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// ListSubscribed — активные вебхуки проекта, подписанные на событие
+func (r *webhookRepository) ListSubscribed(projectID uint, eventType string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.
+		Where("project_id = ? AND active = ?", projectID, true).
+		Where("',' || events || ',' LIKE ?", "%,"+eventType+",%").
+		Find(&webhooks).Error
+	if err != nil {
+		r.logger.Error("ListSubscribed webhooks failed", "project_id", projectID, "event", eventType, "err", err)
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := r.db.Create(&deliveries).Error; err != nil {
+		r.logger.Error("CreateDeliveries failed", "err", err)
+		return err
+	}
+	return nil
+}
+
+// ClaimDueDeliveries работает как EmailOutboxRepository.ClaimDue: блокирует готовые доставки
+// и сдвигает им next_attempt_at на время аренды
+func (r *webhookRepository) ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(deliveries))
+		for _, d := range deliveries {
+			ids = append(ids, d.ID)
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		r.logger.Error("ClaimDueDeliveries failed", "err", err)
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) MarkDeliverySuccess(id uint, attempts, responseStatus int, responseBody string) error {
+	now := time.Now()
+	return r.db.Model(&models.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":          models.WebhookDeliverySuccess,
+			"attempts":        attempts,
+			"response_status": responseStatus,
+			"response_body":   responseBody,
+			"last_error":      "",
+			"delivered_at":    now,
+		}).Error
+}
+
+func (r *webhookRepository) MarkDeliveryFailed(id uint, attempts int, nextAttemptAt time.Time, responseStatus int, responseBody, lastErr string, dead bool) error {
+	status := models.WebhookDeliveryPending
+	if dead {
+		status = models.WebhookDeliveryDead
+	}
+	return r.db.Model(&models.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":          status,
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"response_status": responseStatus,
+			"response_body":   responseBody,
+			"last_error":      lastErr,
+		}).Error
+}
+
+func (r *webhookRepository) ListDeliveries(webhookID uint, limit, offset int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	query := r.db.Where("webhook_id = ?", webhookID).Order("created_at DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	if err := query.Find(&deliveries).Error; err != nil {
+		r.logger.Error("ListDeliveries failed", "webhook_id", webhookID, "err", err)
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) GetDelivery(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"errors"
+	"log/slog"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WorklogRepository interface {
+	WithDB(db *gorm.DB) WorklogRepository
+	Create(worklog *models.Worklog) error
+	Save(worklog *models.Worklog) error
+	Delete(id uint) error
+	GetByID(id uint) (*models.Worklog, error)
+	GetRunning(userID uint) (*models.Worklog, error)
+	ListByTask(taskID uint) ([]models.Worklog, error)
+	LockUser(userID uint) error
+}
+
+type worklogRepository struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+func NewWorklogRepository(db *gorm.DB, logger *slog.Logger) WorklogRepository {
+	return &worklogRepository{db: db, logger: logger}
+}
+
+func (r *worklogRepository) WithDB(db *gorm.DB) WorklogRepository {
+	return &worklogRepository{db: db, logger: r.logger}
+}
+
+func (r *worklogRepository) Create(worklog *models.Worklog) error {
+	if err := r.db.Create(worklog).Error; err != nil {
+		r.logger.Error("CreateWorklog failed", "task_id", worklog.TaskID, "user_id", worklog.UserID, "err", err)
+		return err
+	}
+	return nil
+}
+
+func (r *worklogRepository) Save(worklog *models.Worklog) error {
+	if err := r.db.Save(worklog).Error; err != nil {
+		r.logger.Error("SaveWorklog failed", "id", worklog.ID, "err", err)
+		return err
+	}
+	return nil
+}
+
+// Delete мягко удаляет запись; индекс запущенного таймера учитывает только строки с deleted_at IS NULL
+func (r *worklogRepository) Delete(id uint) error {
+	res := r.db.Delete(&models.Worklog{}, id)
+	if res.Error != nil {
+		r.logger.Error("DeleteWorklog failed", "id", id, "err", res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *worklogRepository) GetByID(id uint) (*models.Worklog, error) {
+	var worklog models.Worklog
+	if err := r.db.First(&worklog, id).Error; err != nil {
+		return nil, err
+	}
+	return &worklog, nil
+}
+
+// GetRunning возвращает запущенный таймер пользователя или nil, если его нет
+func (r *worklogRepository) GetRunning(userID uint) (*models.Worklog, error) {
+	var worklog models.Worklog
+	err := r.db.Where("user_id = ? AND ended_at IS NULL", userID).First(&worklog).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &worklog, nil
+}
+
+func (r *worklogRepository) ListByTask(taskID uint) ([]models.Worklog, error) {
+	var worklogs []models.Worklog
+	err := r.db.Where("task_id = ?", taskID).Order("started_at DESC, id DESC").Find(&worklogs).Error
+	return worklogs, err
+}
+
+// LockUser блокирует строку пользователя до конца транзакции, чтобы два запроса
+// не запустили ему таймеры одновременно
+func (r *worklogRepository) LockUser(userID uint) error {
+	var user models.User
+	return r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userID).Error
+}
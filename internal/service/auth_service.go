@@ -8,9 +8,11 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type AuthService interface {
@@ -18,16 +20,24 @@ type AuthService interface {
 	Login(req models.LoginRequest) (string, error)
 	GetUserByID(id uint) (models.User, error)
 	VerifyEmail(token string) error
+	RequestPasswordReset(email string) error
+	ResetPassword(req models.ResetPasswordRequest) error
 }
 
+// passwordResetTTL — сколько живёт ссылка на сброс пароля
+const passwordResetTTL = time.Hour
+
+var ErrInvalidResetToken = errors.New("invalid or expired token")
+
 type authService struct {
+	db           *gorm.DB
 	repo         repository.UserRepository
 	logger       *slog.Logger
 	emailService *EmailService
 }
 
-func NewAuthService(repo repository.UserRepository, logger *slog.Logger) AuthService {
-	return &authService{repo: repo, logger: logger, emailService: NewEmailService()}
+func NewAuthService(db *gorm.DB, repo repository.UserRepository, emailService *EmailService, logger *slog.Logger) AuthService {
+	return &authService{db: db, repo: repo, logger: logger, emailService: emailService}
 }
 
 func (s *authService) Register(req models.RegisterRequest) error {
@@ -53,19 +63,23 @@ func (s *authService) Register(req models.RegisterRequest) error {
 		IsVerified:   false,
 		VerifyToken:  verifyToken,
 		IsAdmin:      count == 0,
+		Locale:       models.NormalizeLocale(req.Locale),
 	}
 
-	if err := s.repo.CreateUser(&user); err != nil {
-		return err
-	}
+	// Пользователь и письмо подтверждения сохраняются атомарно, отправка идёт в фоне
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithDB(tx).CreateUser(&user); err != nil {
+			return err
+		}
 
-	verifyLink := fmt.Sprintf("http://localhost:8080/auth/verify?token=%s", verifyToken)
-	if err := s.emailService.SendVerificationEmail(user.Email, user.FullName, verifyLink); err != nil {
-		s.logger.Error("email send failed", "error", err)
-		return fmt.Errorf("failed to send verification email: %w", err)
-	}
+		verifyLink := fmt.Sprintf("%s/auth/verify?token=%s", AppBaseURL(), verifyToken)
+		if err := s.emailService.WithDB(tx).QueueVerificationEmail(user, verifyLink); err != nil {
+			s.logger.Error("email enqueue failed", "error", err)
+			return fmt.Errorf("failed to queue verification email: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (s *authService) Login(req models.LoginRequest) (string, error) {
@@ -97,6 +111,50 @@ func (s *authService) VerifyEmail(token string) error {
 	return s.repo.UpdateUserVerification(user.ID, user.IsVerified, user.VerifyToken)
 }
 
+// RequestPasswordReset отправляет ссылку на сброс пароля. Для неизвестного адреса
+// ошибка не возвращается, чтобы по ответу нельзя было перебирать зарегистрированные почты.
+func (s *authService) RequestPasswordReset(email string) error {
+	user, err := s.repo.GetUserByEmail(email)
+	if err != nil {
+		s.logger.Info("password reset requested for unknown email", "op", "service.auth.RequestPasswordReset")
+		return nil
+	}
+
+	token := uuid.New().String()
+	expiresAt := time.Now().Add(passwordResetTTL)
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithDB(tx).SetResetTokenHash(user.ID, hashToken(token), expiresAt); err != nil {
+			return err
+		}
+
+		resetLink := fmt.Sprintf("%s?reset_token=%s", PasswordResetURL(), token)
+		if err := s.emailService.WithDB(tx).QueuePasswordResetEmail(user, resetLink, int(passwordResetTTL.Hours())); err != nil {
+			s.logger.Error("email enqueue failed", "error", err)
+			return fmt.Errorf("failed to queue password reset email: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s *authService) ResetPassword(req models.ResetPasswordRequest) error {
+	user, err := s.repo.GetUserByResetTokenHash(hashToken(req.Token))
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	if user.ResetTokenExpiresAt == nil || time.Now().After(*user.ResetTokenExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdatePassword(user.ID, string(hash))
+}
+
 func (s *authService) GetUserByID(id uint) (models.User, error) {
 	user, _, err := s.repo.GetUserByID(id)
 	return user, err
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeUserRepo хранит пользователей в памяти; неиспользуемые методы остаются от встроенного интерфейса
+type fakeUserRepo struct {
+	repository.UserRepository
+	users []models.User
+}
+
+func (r *fakeUserRepo) WithDB(*gorm.DB) repository.UserRepository { return r }
+
+func (r *fakeUserRepo) CountUsers() (int64, error) { return int64(len(r.users)), nil }
+
+func (r *fakeUserRepo) GetUserByEmail(email string) (models.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return models.User{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) CreateUser(user *models.User) error {
+	user.ID = uint(len(r.users) + 1)
+	r.users = append(r.users, *user)
+	return nil
+}
+
+// fakeOutboxRepo — outbox в памяти: ClaimDue отдаёт все ещё не отправленные письма
+type fakeOutboxRepo struct {
+	repository.EmailOutboxRepository
+	messages []models.EmailOutbox
+}
+
+func (r *fakeOutboxRepo) WithDB(*gorm.DB) repository.EmailOutboxRepository { return r }
+
+func (r *fakeOutboxRepo) Enqueue(msg *models.EmailOutbox) error {
+	msg.ID = uint(len(r.messages) + 1)
+	msg.Status = models.EmailStatusPending
+	r.messages = append(r.messages, *msg)
+	return nil
+}
+
+func (r *fakeOutboxRepo) ClaimDue(time.Time, time.Duration, int) ([]models.EmailOutbox, error) {
+	var due []models.EmailOutbox
+	for _, m := range r.messages {
+		if m.Status == models.EmailStatusPending {
+			due = append(due, m)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeOutboxRepo) MarkSent(id uint) error {
+	r.messages[id-1].Status = models.EmailStatusSent
+	return nil
+}
+
+func TestRegisterSendsVerificationEmail(t *testing.T) {
+	t.Setenv("APP_BASE_URL", "https://jira.example.com/")
+
+	templates, err := LoadEmailTemplates()
+	if err != nil {
+		t.Fatalf("load templates: %v", err)
+	}
+
+	mailer := NewMemoryMailer()
+	outbox := &fakeOutboxRepo{}
+	users := &fakeUserRepo{}
+	emailService := NewEmailService(mailer, templates, outbox)
+	authService := NewAuthService(newTestDB(t), users, emailService, newTestLogger())
+	outboxService := NewEmailOutboxService(outbox, emailService, newTestLogger()).(*emailOutboxService)
+
+	err = authService.Register(models.RegisterRequest{
+		FullName: "Ivan Petrov",
+		Email:    "ivan@example.com",
+		Password: "secret123",
+		Locale:   "en",
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	if got := len(mailer.Messages()); got != 0 {
+		t.Fatalf("email must be sent by the outbox worker, not by Register; sent %d", got)
+	}
+
+	outboxService.deliverDue()
+
+	messages := mailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 email, got %d", len(messages))
+	}
+	msg := messages[0]
+	if msg.To != "ivan@example.com" {
+		t.Errorf("To = %q, want ivan@example.com", msg.To)
+	}
+
+	link := "https://jira.example.com/auth/verify?token=" + users.users[0].VerifyToken
+	if !strings.Contains(msg.Text, link) {
+		t.Errorf("text body does not contain verification link %q:\n%s", link, msg.Text)
+	}
+	if !strings.Contains(msg.HTML, link) {
+		t.Errorf("html body does not contain verification link %q", link)
+	}
+	if outbox.messages[0].Status != models.EmailStatusSent {
+		t.Errorf("outbox status = %q, want %q", outbox.messages[0].Status, models.EmailStatusSent)
+	}
+}
+
+func (r *fakeUserRepo) SetResetTokenHash(id uint, hash string, expiresAt time.Time) error {
+	r.users[id-1].ResetTokenHash = hash
+	r.users[id-1].ResetTokenExpiresAt = &expiresAt
+	return nil
+}
+
+func (r *fakeUserRepo) GetUserByResetTokenHash(hash string) (models.User, error) {
+	for _, u := range r.users {
+		if hash != "" && u.ResetTokenHash == hash {
+			return u, nil
+		}
+	}
+	return models.User{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) UpdatePassword(id uint, passwordHash string) error {
+	r.users[id-1].PasswordHash = passwordHash
+	r.users[id-1].ResetTokenHash = ""
+	r.users[id-1].ResetTokenExpiresAt = nil
+	return nil
+}
+
+func TestPasswordResetStoresOnlyTokenHash(t *testing.T) {
+	t.Setenv("PASSWORD_RESET_URL", "https://app.example.com/index.html")
+
+	templates, err := LoadEmailTemplates()
+	if err != nil {
+		t.Fatalf("load templates: %v", err)
+	}
+
+	outbox := &fakeOutboxRepo{}
+	users := &fakeUserRepo{users: []models.User{{Email: "ivan@example.com", Locale: "ru"}}}
+	users.users[0].ID = 1
+	authService := NewAuthService(newTestDB(t), users, NewEmailService(NewMemoryMailer(), templates, outbox), newTestLogger())
+
+	if err := authService.RequestPasswordReset("ivan@example.com"); err != nil {
+		t.Fatalf("request reset: %v", err)
+	}
+	if len(outbox.messages) != 1 {
+		t.Fatalf("expected 1 queued email, got %d", len(outbox.messages))
+	}
+
+	prefix := "https://app.example.com/index.html?reset_token="
+	body := outbox.messages[0].Body
+	start := strings.Index(body, prefix)
+	if start == -1 {
+		t.Fatalf("reset link %q not found in email:\n%s", prefix, body)
+	}
+	token := strings.Fields(body[start+len(prefix):])[0]
+
+	stored := users.users[0].ResetTokenHash
+	if stored == token || stored != hashToken(token) {
+		t.Fatalf("stored reset token must be sha256 of the emailed token, got %q", stored)
+	}
+
+	if err := authService.ResetPassword(models.ResetPasswordRequest{Token: stored, Password: "new-secret"}); err != ErrInvalidResetToken {
+		t.Errorf("reset with the stored hash: err = %v, want ErrInvalidResetToken", err)
+	}
+	if err := authService.ResetPassword(models.ResetPasswordRequest{Token: token, Password: "new-secret"}); err != nil {
+		t.Fatalf("reset with emailed token: %v", err)
+	}
+	if err := authService.ResetPassword(models.ResetPasswordRequest{Token: token, Password: "again"}); err != ErrInvalidResetToken {
+		t.Errorf("reused token: err = %v, want ErrInvalidResetToken", err)
+	}
+}
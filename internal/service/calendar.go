@@ -0,0 +1,262 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrCalendarTokenNotFound = errors.New("calendar token not found")
+
+// CalendarService выдаёт персональную iCal-ленту: задачи пользователя как VTODO
+// и сроки окончания его проектов как VEVENT
+type CalendarService interface {
+	CreateToken(userID uint) (models.CalendarTokenResponse, error)
+	RevokeToken(userID uint) error
+	Feed(token string) ([]byte, error)
+}
+
+type calendarService struct {
+	repo     repository.CalendarRepository
+	users    repository.UserRepository
+	settings NotificationSettingsService
+	logger   *slog.Logger
+}
+
+func NewCalendarService(repo repository.CalendarRepository, users repository.UserRepository, settings NotificationSettingsService, logger *slog.Logger) CalendarService {
+	return &calendarService{repo: repo, users: users, settings: settings, logger: logger}
+}
+
+// APIBaseURL — внешний адрес API для ссылок, которые открывает не фронтенд (например, календари)
+func APIBaseURL() string {
+	if base := os.Getenv("API_BASE_URL"); base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	return AppBaseURL()
+}
+
+// CreateToken выпускает новый токен; предыдущая ссылка сразу перестаёт работать
+func (s *calendarService) CreateToken(userID uint) (models.CalendarTokenResponse, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return models.CalendarTokenResponse{}, err
+	}
+	token := hex.EncodeToString(b)
+
+	now := time.Now()
+	record := models.CalendarToken{UserID: userID, TokenHash: hashToken(token)}
+	record.CreatedAt, record.UpdatedAt = now, now
+	if err := s.repo.SaveToken(&record); err != nil {
+		return models.CalendarTokenResponse{}, err
+	}
+
+	s.logger.Info("calendar token issued", "op", "service.calendar.CreateToken", "user_id", userID)
+	return models.CalendarTokenResponse{
+		Token:     token,
+		URL:       fmt.Sprintf("%s/calendar/%s.ics", APIBaseURL(), token),
+		CreatedAt: now,
+	}, nil
+}
+
+func (s *calendarService) RevokeToken(userID uint) error {
+	rows, err := s.repo.DeleteToken(userID)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrCalendarTokenNotFound
+	}
+	s.logger.Info("calendar token revoked", "op", "service.calendar.RevokeToken", "user_id", userID)
+	return nil
+}
+
+func (s *calendarService) Feed(token string) ([]byte, error) {
+	token = strings.TrimSuffix(token, ".ics")
+	if token == "" {
+		return nil, ErrCalendarTokenNotFound
+	}
+
+	record, err := s.repo.GetByTokenHash(hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, ErrCalendarTokenNotFound
+	}
+
+	users, err := s.users.GetUsersByIDs([]uint{record.UserID})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, ErrCalendarTokenNotFound
+	}
+	user := users[0]
+
+	tasks, err := s.repo.GetUserTasks(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := s.repo.GetUserProjects(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := s.settings.Resolve([]uint{user.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.repo.TouchToken(record.ID, now); err != nil {
+		s.logger.Warn("failed to update calendar token usage", "op", "service.calendar.Feed", "user_id", user.ID, "err", err)
+	}
+
+	return buildCalendar(user, tasks, projects, prefs[user.ID].Location(), now), nil
+}
+
+// hashToken — в базе лежат только хэши токенов, утечка таблицы их не раскрывает
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// calendarStatus переводит статус задачи в STATUS из RFC 5545
+var calendarStatus = map[string]string{
+	"todo":        "NEEDS-ACTION",
+	"in_progress": "IN-PROCESS",
+	"done":        "COMPLETED",
+}
+
+var calendarMilestoneSummary = map[string]string{
+	models.LocaleRU: "Окончание проекта «%s»",
+	models.LocaleEN: "Project ends: %s",
+}
+
+func buildCalendar(user models.User, tasks []models.Task, projects []models.Project, loc *time.Location, now time.Time) []byte {
+	domain := calendarDomain()
+	var b bytes.Buffer
+	w := icalWriter{buf: &b}
+
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:-//MiniJira//Calendar//" + strings.ToUpper(models.NormalizeLocale(user.Locale)))
+	w.line("CALSCALE:GREGORIAN")
+	w.line("METHOD:PUBLISH")
+	w.line("X-WR-CALNAME:" + icalEscape("MiniJira — "+user.FullName))
+	w.line("X-PUBLISHED-TTL:PT1H")
+	w.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+
+	for _, t := range tasks {
+		w.line("BEGIN:VTODO")
+		w.line(fmt.Sprintf("UID:task-%d@%s", t.ID, domain))
+		w.line("DTSTAMP:" + icalTime(now))
+		w.line("LAST-MODIFIED:" + icalTime(t.UpdatedAt))
+		w.line("SUMMARY:" + icalEscape(t.Title))
+		if t.Description != "" {
+			w.line("DESCRIPTION:" + icalEscape(t.Description))
+		}
+		w.line(fmt.Sprintf("URL:%s/tasks/%d", AppBaseURL(), t.ID))
+		// DUE должен быть позже DTSTART, иначе клиенты отбрасывают запись целиком
+		if t.StartTask != nil && (t.DueDate == nil || t.DueDate.After(*t.StartTask)) {
+			w.line("DTSTART:" + icalTime(*t.StartTask))
+		}
+		if t.DueDate != nil {
+			w.line("DUE:" + icalTime(*t.DueDate))
+		}
+		if status, ok := calendarStatus[t.Status]; ok {
+			w.line("STATUS:" + status)
+		}
+		if t.Status == "done" && t.FinishTask != nil {
+			w.line("COMPLETED:" + icalTime(*t.FinishTask))
+		}
+		switch t.Priority {
+		case 2:
+			w.line("PRIORITY:1")
+		case 1:
+			w.line("PRIORITY:5")
+		}
+		w.line("END:VTODO")
+	}
+
+	summary := calendarMilestoneSummary[models.NormalizeLocale(user.Locale)]
+	for _, p := range projects {
+		// Веха — событие на весь день по часовому поясу пользователя
+		day := p.TimeEnd.In(loc)
+		w.line("BEGIN:VEVENT")
+		w.line(fmt.Sprintf("UID:project-%d-end@%s", p.ID, domain))
+		w.line("DTSTAMP:" + icalTime(now))
+		w.line("LAST-MODIFIED:" + icalTime(p.UpdatedAt))
+		w.line("SUMMARY:" + icalEscape(fmt.Sprintf(summary, p.Title)))
+		if p.Description != "" {
+			w.line("DESCRIPTION:" + icalEscape(p.Description))
+		}
+		w.line(fmt.Sprintf("URL:%s/projects/%d", AppBaseURL(), p.ID))
+		w.line("DTSTART;VALUE=DATE:" + day.Format("20060102"))
+		w.line("DTEND;VALUE=DATE:" + day.AddDate(0, 0, 1).Format("20060102"))
+		w.line("TRANSP:TRANSPARENT")
+		w.line("END:VEVENT")
+	}
+
+	w.line("END:VCALENDAR")
+	return b.Bytes()
+}
+
+// calendarDomain — правая часть UID, чтобы записи разных инсталляций не смешивались
+func calendarDomain() string {
+	if u, err := url.Parse(AppBaseURL()); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return "minijira"
+}
+
+func icalTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func icalEscape(s string) string {
+	return icalEscaper.Replace(s)
+}
+
+// icalWriter пишет строки через CRLF и переносит их длиннее 75 байт, не разрывая UTF-8 символы
+type icalWriter struct {
+	buf *bytes.Buffer
+}
+
+func (w icalWriter) line(s string) {
+	const limit = 75
+	first := true
+	for len(s) > 0 {
+		size := limit
+		if !first {
+			// Продолжение начинается с пробела, он тоже занимает байт
+			size = limit - 1
+			w.buf.WriteByte(' ')
+		}
+		if len(s) <= size {
+			w.buf.WriteString(s)
+			break
+		}
+		cut := size
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.buf.WriteString(s[:cut])
+		w.buf.WriteString("\r\n")
+		s = s[cut:]
+		first = false
+	}
+	w.buf.WriteString("\r\n")
+}
@@ -6,30 +6,64 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"slices"
 	"strings"
+
+	"gorm.io/gorm"
 )
 
 var (
-	ErrUserIdZero       = errors.New("user_id cannot be zero")
-	ErrUserIdTaskIdZero = errors.New("user_id or task_id cannot be zero")
-	ErrTextEmpty        = errors.New("text cannot be empty")
-	ErrChatableIdZero   = errors.New("chatable_id cannot be zero")
-	ErrInvalidChatType  = errors.New("invalid chatable_type: must be 'projects' or 'tasks'")
+	ErrUserIdZero          = errors.New("user_id cannot be zero")
+	ErrUserIdTaskIdZero    = errors.New("user_id or task_id cannot be zero")
+	ErrUserIdProjectIdZero = errors.New("user_id or project_id cannot be zero")
+	ErrTextEmpty           = errors.New("text cannot be empty")
+	ErrChatableIdZero      = errors.New("chatable_id cannot be zero")
+	ErrInvalidChatType     = errors.New("invalid chatable_type: must be 'projects', 'tasks', 'teams' or 'direct'")
+	ErrMessageNotFound     = errors.New("message not found in this chat")
+	ErrEmojiEmpty          = errors.New("emoji cannot be empty")
+	ErrParentNotFound      = errors.New("parent message not found in this chat")
+	ErrDirectTooFewUsers   = errors.New("direct conversation needs at least one other user")
+	ErrDirectUserNotFound  = errors.New("some users of the direct conversation do not exist")
 )
 
+var validChatTypes = map[string]bool{
+	"projects": true,
+	"tasks":    true,
+	"teams":    true,
+	"direct":   true,
+}
+
+// IsValidChatType сообщает, поддерживается ли тип чата (chatable_type)
+func IsValidChatType(chatableType string) bool {
+	return validChatTypes[chatableType]
+}
+
 type ChatService interface {
 	AddMessage(ctx context.Context, input models.ChatMessageCreateReq) (*models.ChatMessage, error)
-	GetMessages(ctx context.Context, chatableType string, chatableID uint) ([]models.ChatMessage, error)
+	GetMessages(ctx context.Context, chatableType string, chatableID uint, topLevelOnly bool) ([]models.ChatMessage, error)
+	GetThread(ctx context.Context, chatableType string, chatableID, messageID uint) (*models.ChatThreadResponse, error)
 	CanUserAccessTask(ctx context.Context, taskID, userID uint) (bool, error)
+	CanUserAccessProject(ctx context.Context, projectID, userID uint) (bool, error)
+	CanUserAccessTeam(ctx context.Context, teamID, userID uint) (bool, error)
+	CanUserAccessChat(ctx context.Context, chatableType string, chatableID uint, user models.User) (bool, error)
+	CreateDirectConversation(ctx context.Context, creatorID uint, userIDs []uint) (*models.DirectConversationResponse, error)
+	ListDirectConversations(ctx context.Context, userID uint) ([]models.DirectConversationResponse, error)
+	AddReaction(ctx context.Context, chatableType string, chatableID, messageID, userID uint, emoji string) ([]models.ReactionCount, error)
+	RemoveReaction(ctx context.Context, chatableType string, chatableID, messageID, userID uint, emoji string) ([]models.ReactionCount, error)
+	MarkAsRead(ctx context.Context, chatableType string, chatableID, userID uint, messageID *uint) error
+	GetUnread(ctx context.Context, userID uint) (*models.UnreadSummary, error)
+	GetSeenBy(ctx context.Context, chatableType string, chatableID uint) (*models.SeenByResponse, error)
 }
 
 type chatService struct {
-	repo   repository.ChatRepository
-	logger *slog.Logger
+	repo          repository.ChatRepository
+	notifications NotificationService
+	events        EventPublisher
+	logger        *slog.Logger
 }
 
-func NewChatService(repo repository.ChatRepository, logger *slog.Logger) ChatService {
-	return &chatService{repo: repo, logger: logger}
+func NewChatService(repo repository.ChatRepository, notifications NotificationService, events EventPublisher, logger *slog.Logger) ChatService {
+	return &chatService{repo: repo, notifications: notifications, events: events, logger: logger}
 }
 
 func (s *chatService) AddMessage(ctx context.Context, input models.ChatMessageCreateReq) (*models.ChatMessage, error) {
@@ -48,11 +82,7 @@ func (s *chatService) AddMessage(ctx context.Context, input models.ChatMessageCr
 		return nil, ErrChatableIdZero
 	}
 
-	validTypes := map[string]bool{
-		"projects": true,
-		"tasks":    true,
-	}
-	if !validTypes[input.ChatableType] {
+	if !IsValidChatType(input.ChatableType) {
 		s.logger.Warn("попытка добавить комментарий с неверным типом", "op", "chatService.AddMessage", "type", input.ChatableType)
 		return nil, ErrInvalidChatType
 	}
@@ -64,32 +94,213 @@ func (s *chatService) AddMessage(ctx context.Context, input models.ChatMessageCr
 		ChatableType: input.ChatableType,
 	}
 
+	if input.ParentID != nil {
+		parent, err := s.getChatMessage(ctx, input.ChatableType, input.ChatableID, *input.ParentID)
+		if err != nil {
+			if errors.Is(err, ErrMessageNotFound) {
+				return nil, ErrParentNotFound
+			}
+			return nil, err
+		}
+
+		// Треды одноуровневые: ответ на ответ попадает в тред корневого сообщения
+		rootID := parent.ID
+		if parent.ParentID != nil {
+			rootID = *parent.ParentID
+		}
+		msg.ParentID = &rootID
+	}
+
 	err := s.repo.Create(ctx, msg)
 	if err != nil {
 		s.logger.Error("ошибка при создании комментария", "op", "chatService.AddMessage", "error", err)
 		return nil, err
 	}
 
+	// Собственное сообщение автор считает прочитанным
+	if err := s.repo.MarkRead(ctx, msg.UserID, msg.ChatableType, msg.ChatableID, msg.ID); err != nil {
+		s.logger.Warn("не удалось обновить отметку о прочтении", "op", "chatService.AddMessage", "error", err, "message_id", msg.ID)
+	}
+
+	s.notifyNewMessage(ctx, msg, input.MentionIDs)
+	s.publishNewMessage(ctx, msg)
+
 	s.logger.Info("комментарий успешно создан", "op", "chatService.AddMessage", "message_id", msg.ID, "user_id", input.UserID)
 	return msg, nil
 }
 
-func (s *chatService) GetMessages(ctx context.Context, chatableType string, chatableID uint) ([]models.ChatMessage, error) {
+// publishNewMessage отдаёт сообщение интеграциям проекта. Личные переписки не публикуются.
+func (s *chatService) publishNewMessage(ctx context.Context, msg *models.ChatMessage) {
+	if msg.ChatableType == "direct" {
+		return
+	}
+
+	projectID, err := s.repo.GetChatProjectID(ctx, msg.ChatableType, msg.ChatableID)
+	if err != nil || projectID == 0 {
+		return
+	}
+
+	s.events.Publish(NewDomainEvent(models.EventChatMessageCreated, projectID, models.ChatMessageEventData{
+		ID:           msg.ID,
+		ChatableType: msg.ChatableType,
+		ChatableID:   msg.ChatableID,
+		ParentID:     msg.ParentID,
+		UserID:       msg.UserID,
+		Text:         msg.Text,
+	}))
+}
+
+// notifyNewMessage рассылает уведомления о новом сообщении. Ответы в треде получают только
+// участники треда, упомянутые пользователи получают отдельное уведомление вместо обычного.
+// Ошибки только логируются: сообщение уже сохранено.
+func (s *chatService) notifyNewMessage(ctx context.Context, msg *models.ChatMessage, mentionIDs []uint) {
+	participants, err := s.repo.GetChatParticipants(ctx, msg.ChatableType, msg.ChatableID)
+	if err != nil {
+		s.logger.Warn("не удалось получить участников чата", "op", "chatService.notifyNewMessage", "error", err, "message_id", msg.ID)
+		return
+	}
+
+	projectID, err := s.repo.GetChatProjectID(ctx, msg.ChatableType, msg.ChatableID)
+	if err != nil {
+		s.logger.Warn("не удалось определить проект чата", "op", "chatService.notifyNewMessage", "error", err, "message_id", msg.ID)
+	}
+
+	title, err := s.repo.GetChatTitle(ctx, msg.ChatableType, msg.ChatableID)
+	if err != nil {
+		s.logger.Warn("не удалось получить название чата", "op", "chatService.notifyNewMessage", "error", err, "message_id", msg.ID)
+	}
+
+	threadID := msg.ID
+	if msg.ParentID != nil {
+		threadID = *msg.ParentID
+	}
+
+	event := models.NotificationEvent{
+		ActorID:    msg.UserID,
+		Text:       truncateText(msg.Text, 200),
+		EntityName: title,
+		EntityType: msg.ChatableType,
+		EntityID:   msg.ChatableID,
+		ProjectID:  projectID,
+		ThreadID:   threadID,
+	}
+
+	// Упомянуть можно только того, у кого есть доступ к чату
+	var mentioned []uint
+	for _, id := range mentionIDs {
+		if slices.Contains(participants, id) {
+			mentioned = append(mentioned, id)
+		}
+	}
+	if len(mentioned) > 0 {
+		mention := event
+		mention.Type = models.NotificationChatMention
+		mention.Title = "Вас упомянули в обсуждении"
+		mention.RecipientIDs = mentioned
+		if err := s.notifications.Notify(mention); err != nil {
+			s.logger.Warn("не удалось отправить уведомления об упоминании", "op", "chatService.notifyNewMessage", "error", err)
+		}
+	}
+
+	event.Type = models.NotificationChatComment
+	event.Title = "Новый комментарий"
+	recipients := participants
+	if msg.ParentID != nil {
+		threadUsers, err := s.repo.GetThreadParticipants(ctx, *msg.ParentID)
+		if err != nil {
+			s.logger.Warn("не удалось получить участников треда", "op", "chatService.notifyNewMessage", "error", err, "parent_id", *msg.ParentID)
+			return
+		}
+		event.Type = models.NotificationChatReply
+		event.Title = "Новый ответ в обсуждении"
+		recipients = threadUsers
+	}
+
+	for _, id := range recipients {
+		if !slices.Contains(mentioned, id) {
+			event.RecipientIDs = append(event.RecipientIDs, id)
+		}
+	}
+	if err := s.notifications.Notify(event); err != nil {
+		s.logger.Warn("не удалось отправить уведомления о комментарии", "op", "chatService.notifyNewMessage", "error", err)
+	}
+}
+
+func truncateText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "…"
+}
+
+func (s *chatService) GetMessages(ctx context.Context, chatableType string, chatableID uint, topLevelOnly bool) ([]models.ChatMessage, error) {
 	if chatableID == 0 {
 		s.logger.Warn("попытка получить комментарии с нулевым ID", "op", "chatService.GetMessages", "type", chatableType)
 		return nil, ErrChatableIdZero
 	}
 
-	messages, err := s.repo.GetByChat(ctx, chatableType, chatableID)
+	messages, err := s.repo.GetByChat(ctx, chatableType, chatableID, topLevelOnly)
 	if err != nil {
 		s.logger.Error("ошибка при получении комментариев", "op", "chatService.GetMessages", "error", err, "type", chatableType, "id", chatableID)
 		return nil, err
 	}
 
+	if err := s.attachReplyCounts(ctx, messages); err != nil {
+		s.logger.Error("ошибка при подсчёте ответов", "op", "chatService.GetMessages", "error", err, "type", chatableType, "id", chatableID)
+		return nil, err
+	}
+
+	if err := s.attachReactions(ctx, messages); err != nil {
+		s.logger.Error("ошибка при получении реакций", "op", "chatService.GetMessages", "error", err, "type", chatableType, "id", chatableID)
+		return nil, err
+	}
+
 	s.logger.Info("комментарии успешно получены", "op", "chatService.GetMessages", "count", len(messages), "type", chatableType, "id", chatableID)
 	return messages, nil
 }
 
+func (s *chatService) GetThread(ctx context.Context, chatableType string, chatableID, messageID uint) (*models.ChatThreadResponse, error) {
+	parent, err := s.getChatMessage(ctx, chatableType, chatableID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Запрос по ответу возвращает весь тред его корневого сообщения
+	if parent.ParentID != nil {
+		parent, err = s.getChatMessage(ctx, chatableType, chatableID, *parent.ParentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	replies, err := s.repo.GetReplies(ctx, parent.ID)
+	if err != nil {
+		s.logger.Error("ошибка при получении ответов", "op", "chatService.GetThread", "error", err, "message_id", parent.ID)
+		return nil, err
+	}
+
+	participants, err := s.repo.GetThreadParticipants(ctx, parent.ID)
+	if err != nil {
+		s.logger.Error("ошибка при получении участников треда", "op", "chatService.GetThread", "error", err, "message_id", parent.ID)
+		return nil, err
+	}
+
+	all := append([]models.ChatMessage{*parent}, replies...)
+	if err := s.attachReactions(ctx, all); err != nil {
+		s.logger.Error("ошибка при получении реакций", "op", "chatService.GetThread", "error", err, "message_id", parent.ID)
+		return nil, err
+	}
+	all[0].ReplyCount = len(replies)
+
+	s.logger.Info("тред успешно получен", "op", "chatService.GetThread", "message_id", parent.ID, "replies", len(replies))
+	return &models.ChatThreadResponse{
+		Parent:         all[0],
+		Replies:        all[1:],
+		ParticipantIDs: participants,
+	}, nil
+}
+
 func (s *chatService) CanUserAccessTask(ctx context.Context, taskID, userID uint) (bool, error) {
 	if taskID == 0 || userID == 0 {
 		return false, ErrUserIdTaskIdZero
@@ -107,3 +318,352 @@ func (s *chatService) CanUserAccessTask(ctx context.Context, taskID, userID uint
 
 	return hasAccess, nil
 }
+
+func (s *chatService) AddReaction(ctx context.Context, chatableType string, chatableID, messageID, userID uint, emoji string) ([]models.ReactionCount, error) {
+	emoji = strings.TrimSpace(emoji)
+	if emoji == "" {
+		return nil, ErrEmojiEmpty
+	}
+	if userID == 0 {
+		return nil, ErrUserIdZero
+	}
+
+	if _, err := s.getChatMessage(ctx, chatableType, chatableID, messageID); err != nil {
+		return nil, err
+	}
+
+	reaction := &models.ChatReaction{MessageID: messageID, UserID: userID, Emoji: emoji}
+	if err := s.repo.AddReaction(ctx, reaction); err != nil {
+		s.logger.Error("ошибка при добавлении реакции", "op", "chatService.AddReaction", "error", err, "message_id", messageID)
+		return nil, err
+	}
+
+	s.logger.Info("реакция добавлена", "op", "chatService.AddReaction", "message_id", messageID, "user_id", userID, "emoji", emoji)
+	return s.messageReactions(ctx, messageID)
+}
+
+func (s *chatService) RemoveReaction(ctx context.Context, chatableType string, chatableID, messageID, userID uint, emoji string) ([]models.ReactionCount, error) {
+	emoji = strings.TrimSpace(emoji)
+	if emoji == "" {
+		return nil, ErrEmojiEmpty
+	}
+
+	if _, err := s.getChatMessage(ctx, chatableType, chatableID, messageID); err != nil {
+		return nil, err
+	}
+
+	removed, err := s.repo.RemoveReaction(ctx, messageID, userID, emoji)
+	if err != nil {
+		s.logger.Error("ошибка при удалении реакции", "op", "chatService.RemoveReaction", "error", err, "message_id", messageID)
+		return nil, err
+	}
+
+	s.logger.Info("реакция удалена", "op", "chatService.RemoveReaction", "message_id", messageID, "user_id", userID, "emoji", emoji, "rows", removed)
+	return s.messageReactions(ctx, messageID)
+}
+
+// getChatMessage загружает сообщение и проверяет, что оно принадлежит указанному чату
+func (s *chatService) getChatMessage(ctx context.Context, chatableType string, chatableID, messageID uint) (*models.ChatMessage, error) {
+	msg, err := s.repo.GetByID(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		s.logger.Error("ошибка при получении сообщения", "op", "chatService.getChatMessage", "error", err, "message_id", messageID)
+		return nil, err
+	}
+
+	if msg.ChatableType != chatableType || msg.ChatableID != chatableID {
+		s.logger.Warn("сообщение не принадлежит чату", "op", "chatService.getChatMessage",
+			"message_id", messageID, "type", chatableType, "id", chatableID)
+		return nil, ErrMessageNotFound
+	}
+
+	return msg, nil
+}
+
+func (s *chatService) messageReactions(ctx context.Context, messageID uint) ([]models.ReactionCount, error) {
+	reactions, err := s.repo.GetReactions(ctx, []uint{messageID})
+	if err != nil {
+		return nil, err
+	}
+	counts := aggregateReactions(reactions)[messageID]
+	if counts == nil {
+		counts = []models.ReactionCount{}
+	}
+	return counts, nil
+}
+
+func (s *chatService) attachReactions(ctx context.Context, messages []models.ChatMessage) error {
+	ids := make([]uint, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+
+	reactions, err := s.repo.GetReactions(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byMessage := aggregateReactions(reactions)
+	for i := range messages {
+		messages[i].Reactions = byMessage[messages[i].ID]
+		if messages[i].Reactions == nil {
+			messages[i].Reactions = []models.ReactionCount{}
+		}
+	}
+	return nil
+}
+
+func (s *chatService) attachReplyCounts(ctx context.Context, messages []models.ChatMessage) error {
+	ids := make([]uint, 0, len(messages))
+	for _, m := range messages {
+		if m.ParentID == nil {
+			ids = append(ids, m.ID)
+		}
+	}
+
+	counts, err := s.repo.CountReplies(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range messages {
+		messages[i].ReplyCount = counts[messages[i].ID]
+	}
+	return nil
+}
+
+// aggregateReactions группирует реакции по сообщению и эмодзи, сохраняя порядок первой реакции
+func aggregateReactions(reactions []models.ChatReaction) map[uint][]models.ReactionCount {
+	result := make(map[uint][]models.ReactionCount)
+	for _, r := range reactions {
+		counts := result[r.MessageID]
+		idx := slices.IndexFunc(counts, func(c models.ReactionCount) bool { return c.Emoji == r.Emoji })
+		if idx == -1 {
+			counts = append(counts, models.ReactionCount{Emoji: r.Emoji})
+			idx = len(counts) - 1
+		}
+		counts[idx].Count++
+		counts[idx].UserIDs = append(counts[idx].UserIDs, r.UserID)
+		result[r.MessageID] = counts
+	}
+	return result
+}
+
+func (s *chatService) CanUserAccessProject(ctx context.Context, projectID, userID uint) (bool, error) {
+	if projectID == 0 || userID == 0 {
+		return false, ErrUserIdProjectIdZero
+	}
+
+	hasAccess, err := s.repo.IsUserInProjectTeam(ctx, projectID, userID)
+	if err != nil {
+		s.logger.Error("ошибка при проверке доступа пользователя", "op", "chatService.CanUserAccessProject", "error", err, "project_id", projectID, "user_id", userID)
+		return false, err
+	}
+
+	if !hasAccess {
+		s.logger.Warn("пользователь не состоит в командах проекта", "op", "chatService.CanUserAccessProject", "project_id", projectID, "user_id", userID)
+	}
+
+	return hasAccess, nil
+}
+
+func (s *chatService) CanUserAccessTeam(ctx context.Context, teamID, userID uint) (bool, error) {
+	if teamID == 0 || userID == 0 {
+		return false, ErrUserIdZero
+	}
+
+	hasAccess, err := s.repo.IsUserInTeam(ctx, teamID, userID)
+	if err != nil {
+		s.logger.Error("ошибка при проверке доступа пользователя", "op", "chatService.CanUserAccessTeam", "error", err, "team_id", teamID, "user_id", userID)
+		return false, err
+	}
+
+	if !hasAccess {
+		s.logger.Warn("пользователь не состоит в команде", "op", "chatService.CanUserAccessTeam", "team_id", teamID, "user_id", userID)
+	}
+
+	return hasAccess, nil
+}
+
+// CanUserAccessChat — единая проверка доступа на чтение и запись в чат.
+// Администраторы имеют доступ ко всем чатам, кроме личных переписок.
+func (s *chatService) CanUserAccessChat(ctx context.Context, chatableType string, chatableID uint, user models.User) (bool, error) {
+	if chatableType == "direct" {
+		return s.repo.IsUserInDirect(ctx, chatableID, user.ID)
+	}
+
+	if user.IsAdmin {
+		return true, nil
+	}
+
+	switch chatableType {
+	case "tasks":
+		return s.CanUserAccessTask(ctx, chatableID, user.ID)
+	case "projects":
+		return s.CanUserAccessProject(ctx, chatableID, user.ID)
+	case "teams":
+		return s.CanUserAccessTeam(ctx, chatableID, user.ID)
+	default:
+		return false, ErrInvalidChatType
+	}
+}
+
+// CreateDirectConversation создаёт личную переписку или возвращает существующую с тем же составом
+func (s *chatService) CreateDirectConversation(ctx context.Context, creatorID uint, userIDs []uint) (*models.DirectConversationResponse, error) {
+	members := []uint{creatorID}
+	for _, id := range userIDs {
+		if id != 0 && !slices.Contains(members, id) {
+			members = append(members, id)
+		}
+	}
+	if len(members) < 2 {
+		return nil, ErrDirectTooFewUsers
+	}
+	slices.Sort(members)
+
+	existing, err := s.repo.FindDirectByMembers(ctx, members)
+	if err != nil {
+		s.logger.Error("ошибка при поиске переписки", "op", "chatService.CreateDirectConversation", "error", err)
+		return nil, err
+	}
+	if existing != nil {
+		s.logger.Info("переписка уже существует", "op", "chatService.CreateDirectConversation", "conversation_id", existing.ID)
+		return buildDirectConversationResponse(existing), nil
+	}
+
+	conv, err := s.repo.CreateDirect(ctx, members)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDirectUserNotFound
+		}
+		s.logger.Error("ошибка при создании переписки", "op", "chatService.CreateDirectConversation", "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("переписка создана", "op", "chatService.CreateDirectConversation", "conversation_id", conv.ID, "users", members)
+	return buildDirectConversationResponse(conv), nil
+}
+
+func (s *chatService) ListDirectConversations(ctx context.Context, userID uint) ([]models.DirectConversationResponse, error) {
+	conversations, err := s.repo.ListDirectForUser(ctx, userID)
+	if err != nil {
+		s.logger.Error("ошибка при получении переписок", "op", "chatService.ListDirectConversations", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	responses := []models.DirectConversationResponse{}
+	for i := range conversations {
+		responses = append(responses, *buildDirectConversationResponse(&conversations[i]))
+	}
+	return responses, nil
+}
+
+func buildDirectConversationResponse(conv *models.DirectConversation) *models.DirectConversationResponse {
+	resp := &models.DirectConversationResponse{
+		ID:        conv.ID,
+		UserIDs:   []uint{},
+		CreatedAt: conv.CreatedAt,
+	}
+	for _, u := range conv.Users {
+		resp.UserIDs = append(resp.UserIDs, u.ID)
+	}
+	return resp
+}
+
+func (s *chatService) MarkAsRead(ctx context.Context, chatableType string, chatableID, userID uint, messageID *uint) error {
+	if userID == 0 {
+		return ErrUserIdZero
+	}
+
+	var lastID uint
+	if messageID != nil {
+		msg, err := s.getChatMessage(ctx, chatableType, chatableID, *messageID)
+		if err != nil {
+			return err
+		}
+		lastID = msg.ID
+	} else {
+		latest, err := s.repo.GetLatestMessageID(ctx, chatableType, chatableID)
+		if err != nil {
+			s.logger.Error("ошибка при получении последнего сообщения", "op", "chatService.MarkAsRead", "error", err, "type", chatableType, "id", chatableID)
+			return err
+		}
+		lastID = latest
+	}
+
+	if err := s.repo.MarkRead(ctx, userID, chatableType, chatableID, lastID); err != nil {
+		s.logger.Error("ошибка при отметке чата прочитанным", "op", "chatService.MarkAsRead", "error", err, "type", chatableType, "id", chatableID)
+		return err
+	}
+
+	s.logger.Info("чат отмечен прочитанным", "op", "chatService.MarkAsRead", "type", chatableType, "id", chatableID, "user_id", userID, "message_id", lastID)
+	return nil
+}
+
+func (s *chatService) GetUnread(ctx context.Context, userID uint) (*models.UnreadSummary, error) {
+	if userID == 0 {
+		return nil, ErrUserIdZero
+	}
+
+	counts, err := s.repo.GetUnreadCounts(ctx, userID)
+	if err != nil {
+		s.logger.Error("ошибка при подсчёте непрочитанных", "op", "chatService.GetUnread", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	summary := &models.UnreadSummary{
+		Tasks:    []models.UnreadCount{},
+		Projects: []models.UnreadCount{},
+		Teams:    []models.UnreadCount{},
+		Direct:   []models.UnreadCount{},
+	}
+	for _, c := range counts {
+		switch c.ChatableType {
+		case "tasks":
+			summary.Tasks = append(summary.Tasks, c)
+		case "projects":
+			summary.Projects = append(summary.Projects, c)
+		case "teams":
+			summary.Teams = append(summary.Teams, c)
+		case "direct":
+			summary.Direct = append(summary.Direct, c)
+		}
+		summary.Total += c.Unread
+	}
+
+	return summary, nil
+}
+
+// GetSeenBy возвращает пользователей, прочитавших последнее сообщение чата (кроме его автора)
+func (s *chatService) GetSeenBy(ctx context.Context, chatableType string, chatableID uint) (*models.SeenByResponse, error) {
+	latestID, err := s.repo.GetLatestMessageID(ctx, chatableType, chatableID)
+	if err != nil {
+		s.logger.Error("ошибка при получении последнего сообщения", "op", "chatService.GetSeenBy", "error", err, "type", chatableType, "id", chatableID)
+		return nil, err
+	}
+
+	resp := &models.SeenByResponse{MessageID: latestID, UserIDs: []uint{}}
+	if latestID == 0 {
+		return resp, nil
+	}
+
+	latest, err := s.repo.GetByID(ctx, latestID)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs, err := s.repo.GetSeenBy(ctx, chatableType, chatableID, latestID)
+	if err != nil {
+		s.logger.Error("ошибка при получении отметок о прочтении", "op", "chatService.GetSeenBy", "error", err, "message_id", latestID)
+		return nil, err
+	}
+
+	for _, id := range userIDs {
+		if id != latest.UserID {
+			resp.UserIDs = append(resp.UserIDs, id)
+		}
+	}
+	return resp, nil
+}
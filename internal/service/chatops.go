@@ -0,0 +1,377 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	chatOpsQueueSize   = 256
+	chatOpsTimeout     = 10 * time.Second
+	chatOpsMaxAttempts = 3
+	chatOpsBaseBackoff = 2 * time.Second
+	chatOpsMaxExcerpt  = 300
+)
+
+var (
+	ErrChatOpsNotFound     = errors.New("chat-ops integration not found")
+	ErrChatOpsNoWebhook    = errors.New("chat-ops integration has no webhook url")
+	ErrChatOpsInvalidToken = errors.New("invalid slash command token")
+)
+
+// ChatOpsService транслирует события проекта во входящий вебхук Slack/Mattermost
+type ChatOpsService interface {
+	EventPublisher
+	Run(ctx context.Context)
+	Get(projectID uint) (models.ChatOpsResponse, error)
+	Save(projectID uint, req models.ChatOpsSaveReq) (models.ChatOpsResponse, error)
+	Delete(projectID uint) error
+	// SendTest отправляет проверочное сообщение сразу и возвращает ошибку получателя
+	SendTest(ctx context.Context, projectID uint) error
+}
+
+// slackMessage — тело входящего вебхука; Mattermost принимает тот же формат
+type slackMessage struct {
+	Text     string `json:"text"`
+	Channel  string `json:"channel,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+type chatOpsMessage struct {
+	projectID uint
+	url       string
+	payload   slackMessage
+}
+
+type chatOpsService struct {
+	repo        repository.ChatOpsRepository
+	projectRepo repository.ProjectRepository
+	users       repository.UserRepository
+	chatRepo    repository.ChatRepository
+	client      *http.Client
+	queue       chan chatOpsMessage
+	logger      *slog.Logger
+}
+
+func NewChatOpsService(repo repository.ChatOpsRepository, projectRepo repository.ProjectRepository, users repository.UserRepository, chatRepo repository.ChatRepository, logger *slog.Logger) ChatOpsService {
+	return &chatOpsService{
+		repo:        repo,
+		projectRepo: projectRepo,
+		users:       users,
+		chatRepo:    chatRepo,
+		client:      &http.Client{Timeout: chatOpsTimeout},
+		queue:       make(chan chatOpsMessage, chatOpsQueueSize),
+		logger:      logger,
+	}
+}
+
+// Publish форматирует событие и ставит сообщение в очередь. Сообщения в чат — не источник
+// истины, поэтому очередь в памяти: при переполнении или остановке сервера они теряются.
+func (s *chatOpsService) Publish(event models.DomainEvent) {
+	if event.ProjectID == 0 || !slices.Contains(models.ChatOpsEventTypes, event.Type) {
+		return
+	}
+
+	integration, err := s.repo.GetByProject(event.ProjectID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Error("failed to load chat-ops integration", "op", "service.chatops.Publish", "project_id", event.ProjectID, "err", err)
+		}
+		return
+	}
+	if !integration.Active || integration.WebhookURL == "" || !slices.Contains(splitEvents(integration.Events), event.Type) {
+		return
+	}
+
+	text := s.format(event)
+	if text == "" {
+		return
+	}
+
+	msg := chatOpsMessage{
+		projectID: event.ProjectID,
+		url:       integration.WebhookURL,
+		payload:   slackMessage{Text: text, Channel: integration.Channel, Username: integration.Username},
+	}
+	select {
+	case s.queue <- msg:
+	default:
+		s.logger.Warn("chat-ops queue is full, message dropped", "op", "service.chatops.Publish", "project_id", event.ProjectID, "type", event.Type)
+	}
+}
+
+// Run отправляет сообщения из очереди, пока не отменён ctx
+func (s *chatOpsService) Run(ctx context.Context) {
+	s.logger.Info("chat-ops worker started", "op", "service.chatops.Run")
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("chat-ops worker stopped", "op", "service.chatops.Run")
+			return
+		case msg := <-s.queue:
+			s.deliver(ctx, msg)
+		}
+	}
+}
+
+func (s *chatOpsService) deliver(ctx context.Context, msg chatOpsMessage) {
+	for attempt := 1; ; attempt++ {
+		err := s.post(ctx, msg.url, msg.payload)
+		if err == nil {
+			return
+		}
+		if attempt >= chatOpsMaxAttempts || ctx.Err() != nil {
+			s.logger.Error("chat-ops message gave up", "op", "service.chatops.deliver", "project_id", msg.projectID, "attempts", attempt, "err", err)
+			return
+		}
+
+		delay := retryBackoff(attempt, chatOpsBaseBackoff, chatOpsTimeout)
+		s.logger.Warn("chat-ops message failed, will retry", "op", "service.chatops.deliver", "project_id", msg.projectID, "attempt", attempt, "err", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (s *chatOpsService) post(ctx context.Context, url string, payload slackMessage) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MiniJira-ChatOps")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webhookMaxResponse))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// format собирает текст сообщения в разметке Slack; пустая строка — событие не показываем
+func (s *chatOpsService) format(event models.DomainEvent) string {
+	switch data := event.Data.(type) {
+	case models.TaskEventData:
+		return fmt.Sprintf(":new: Новая задача %s", slackTaskLink(data))
+
+	case models.TaskAssignedData:
+		names := s.userNames(data.AssigneeIDs)
+		if len(names) == 0 {
+			return ""
+		}
+		return fmt.Sprintf(":bust_in_silhouette: %s: назначено на %s", slackTaskLink(data.Task), slackEscape(strings.Join(names, ", ")))
+
+	case models.TaskStatusChangedData:
+		text := fmt.Sprintf(":arrows_counterclockwise: %s: %s → %s", slackTaskLink(data.Task), data.OldStatus, data.NewStatus)
+		if names := s.userNames([]uint{data.ActorID}); len(names) > 0 {
+			text += " (" + slackEscape(names[0]) + ")"
+		}
+		return text
+
+	case models.ChatMessageEventData:
+		author := "Кто-то"
+		if names := s.userNames([]uint{data.UserID}); len(names) > 0 {
+			author = names[0]
+		}
+		title, err := s.chatRepo.GetChatTitle(context.Background(), data.ChatableType, data.ChatableID)
+		if err != nil {
+			s.logger.Warn("failed to load chat title", "op", "service.chatops.format", "type", data.ChatableType, "id", data.ChatableID, "err", err)
+		}
+
+		target := fmt.Sprintf("<%s/chat/%s/%d/|%s>", AppBaseURL(), data.ChatableType, data.ChatableID, slackEscape(title))
+		if data.ChatableType == "tasks" {
+			target = slackTaskLink(models.TaskEventData{ID: data.ChatableID, Title: title})
+		}
+		return fmt.Sprintf(":speech_balloon: %s в %s:\n>%s", slackEscape(author), target,
+			strings.ReplaceAll(slackEscape(truncateText(data.Text, chatOpsMaxExcerpt)), "\n", "\n>"))
+	}
+	return ""
+}
+
+func (s *chatOpsService) userNames(ids []uint) []string {
+	var filtered []uint
+	for _, id := range ids {
+		if id != 0 {
+			filtered = append(filtered, id)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	users, err := s.users.GetUsersByIDs(filtered)
+	if err != nil {
+		s.logger.Warn("failed to load users for chat-ops message", "op", "service.chatops.userNames", "err", err)
+		return nil
+	}
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.FullName)
+	}
+	return names
+}
+
+// TaskRef — короткое имя задачи, которое понимают slash-команды
+func TaskRef(id uint) string {
+	return fmt.Sprintf("TASK-%d", id)
+}
+
+func slackTaskLink(task models.TaskEventData) string {
+	return fmt.Sprintf("<%s/tasks/%d|%s: %s>", AppBaseURL(), task.ID, TaskRef(task.ID), slackEscape(task.Title))
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackEscape экранирует символы разметки Slack в пользовательском тексте
+func slackEscape(s string) string {
+	return slackEscaper.Replace(s)
+}
+
+func (s *chatOpsService) Get(projectID uint) (models.ChatOpsResponse, error) {
+	integration, err := s.getIntegration(projectID)
+	if err != nil {
+		return models.ChatOpsResponse{}, err
+	}
+	return buildChatOpsResponse(*integration), nil
+}
+
+// Save создаёт интеграцию проекта или обновляет переданные поля существующей
+func (s *chatOpsService) Save(projectID uint, req models.ChatOpsSaveReq) (models.ChatOpsResponse, error) {
+	if _, err := s.projectRepo.GetProjectByID(projectID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ChatOpsResponse{}, ErrProjectNotFound
+		}
+		return models.ChatOpsResponse{}, err
+	}
+
+	integration, err := s.repo.GetByProject(projectID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		integration = &models.ChatOpsIntegration{
+			ProjectID: projectID,
+			Events:    strings.Join(models.ChatOpsEventTypes, ","),
+			Active:    true,
+		}
+	} else if err != nil {
+		return models.ChatOpsResponse{}, err
+	}
+
+	if req.WebhookURL != nil {
+		integration.WebhookURL = *req.WebhookURL
+	}
+	if req.Channel != nil {
+		integration.Channel = *req.Channel
+	}
+	if req.Username != nil {
+		integration.Username = *req.Username
+	}
+	if req.Events != nil {
+		integration.Events = strings.Join(*req.Events, ",")
+	}
+	if req.CommandToken != nil {
+		integration.CommandTokenHash = ""
+		if *req.CommandToken != "" {
+			integration.CommandTokenHash = hashToken(*req.CommandToken)
+		}
+	}
+	if req.Active != nil {
+		integration.Active = *req.Active
+	}
+
+	isNew := integration.ID == 0
+	if err := s.repo.Save(integration); err != nil {
+		return models.ChatOpsResponse{}, err
+	}
+	// Gorm не пишет false в поле с default:true при создании
+	if isNew && !integration.Active {
+		if err := s.repo.Save(integration); err != nil {
+			return models.ChatOpsResponse{}, err
+		}
+	}
+
+	s.logger.Info("chat-ops integration saved", "op", "service.chatops.Save", "project_id", projectID)
+	return buildChatOpsResponse(*integration), nil
+}
+
+func (s *chatOpsService) Delete(projectID uint) error {
+	if err := s.repo.Delete(projectID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrChatOpsNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *chatOpsService) SendTest(ctx context.Context, projectID uint) error {
+	integration, err := s.getIntegration(projectID)
+	if err != nil {
+		return err
+	}
+	if integration.WebhookURL == "" {
+		return ErrChatOpsNoWebhook
+	}
+
+	return s.post(ctx, integration.WebhookURL, slackMessage{
+		Text:     ":white_check_mark: MiniJira подключена к этому каналу",
+		Channel:  integration.Channel,
+		Username: integration.Username,
+	})
+}
+
+func (s *chatOpsService) getIntegration(projectID uint) (*models.ChatOpsIntegration, error) {
+	integration, err := s.repo.GetByProject(projectID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChatOpsNotFound
+		}
+		return nil, err
+	}
+	return integration, nil
+}
+
+func buildChatOpsResponse(i models.ChatOpsIntegration) models.ChatOpsResponse {
+	return models.ChatOpsResponse{
+		ProjectID:       i.ProjectID,
+		WebhookURL:      i.WebhookURL,
+		Channel:         i.Channel,
+		Username:        i.Username,
+		Events:          splitEvents(i.Events),
+		CommandsEnabled: i.CommandTokenHash != "",
+		CommandURL:      fmt.Sprintf("%s/integrations/chatops/%d/command", APIBaseURL(), i.ProjectID),
+		Active:          i.Active,
+		UpdatedAt:       i.UpdatedAt,
+	}
+}
+
+func splitEvents(csv string) []string {
+	if csv == "" {
+		return []string{}
+	}
+	return strings.Split(csv, ",")
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	slashResponseEphemeral = "ephemeral"
+
+	chatOpsHelp = "Команды MiniJira:\n" +
+		"`/minijira create <название>` — создать задачу в проекте\n" +
+		"`/minijira status TASK-12 <todo|in_progress|done>` — сменить статус задачи\n" +
+		"`/minijira help` — эта справка"
+)
+
+// ChatOpsCommandService выполняет slash-команды /minijira в рамках проекта интеграции
+type ChatOpsCommandService interface {
+	// Handle проверяет токен команды и выполняет её. Ошибка возвращается только при неверном
+	// токене или сбое; ошибки пользователя уходят текстом в ответе.
+	Handle(projectID uint, cmd models.SlashCommand) (models.SlashCommandResponse, error)
+}
+
+type chatOpsCommandService struct {
+	repo        repository.ChatOpsRepository
+	taskService TaskService
+	logger      *slog.Logger
+}
+
+func NewChatOpsCommandService(repo repository.ChatOpsRepository, taskService TaskService, logger *slog.Logger) ChatOpsCommandService {
+	return &chatOpsCommandService{repo: repo, taskService: taskService, logger: logger}
+}
+
+func (s *chatOpsCommandService) Handle(projectID uint, cmd models.SlashCommand) (models.SlashCommandResponse, error) {
+	if err := s.authenticate(projectID, cmd.Token); err != nil {
+		return models.SlashCommandResponse{}, err
+	}
+
+	action, args, _ := strings.Cut(strings.TrimSpace(cmd.Text), " ")
+	args = strings.TrimSpace(args)
+
+	s.logger.Info("slash command received", "op", "service.chatopsCommand.Handle", "project_id", projectID, "action", action, "chat_user", cmd.UserName)
+
+	switch strings.ToLower(action) {
+	case "create":
+		return s.create(projectID, args, cmd.UserName)
+	case "status":
+		return s.status(projectID, args)
+	default:
+		return ephemeral(chatOpsHelp), nil
+	}
+}
+
+// authenticate сравнивает хэши за постоянное время, чтобы токен нельзя было подобрать по задержке
+func (s *chatOpsCommandService) authenticate(projectID uint, token string) error {
+	integration, err := s.repo.GetByProject(projectID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrChatOpsInvalidToken
+		}
+		return err
+	}
+	if !integration.Active || integration.CommandTokenHash == "" || token == "" {
+		return ErrChatOpsInvalidToken
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(integration.CommandTokenHash)) != 1 {
+		return ErrChatOpsInvalidToken
+	}
+	return nil
+}
+
+func (s *chatOpsCommandService) create(projectID uint, title, chatUser string) (models.SlashCommandResponse, error) {
+	if title == "" {
+		return ephemeral("Укажите название: `/minijira create <название>`"), nil
+	}
+	if len([]rune(title)) > 255 {
+		return ephemeral("Название задачи длиннее 255 символов"), nil
+	}
+
+	req := models.TaskCreateReq{
+		Title:     title,
+		Status:    "todo",
+		ProjectID: projectID,
+	}
+	if chatUser != "" {
+		req.Description = fmt.Sprintf("Создано из чата пользователем @%s", chatUser)
+	}
+
+	task, err := s.taskService.CreateTask(&req, 0)
+	if err != nil {
+		return models.SlashCommandResponse{}, err
+	}
+
+	return ephemeral(fmt.Sprintf("Создана задача %s", slackTaskLink(models.TaskEventData{ID: task.ID, Title: title}))), nil
+}
+
+func (s *chatOpsCommandService) status(projectID uint, args string) (models.SlashCommandResponse, error) {
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		return ephemeral("Формат: `/minijira status TASK-12 <todo|in_progress|done>`"), nil
+	}
+
+	taskID, ok := parseTaskRef(fields[0])
+	if !ok {
+		return ephemeral(fmt.Sprintf("Не понимаю номер задачи «%s», ожидается вид TASK-12", slackEscape(fields[0]))), nil
+	}
+
+	status := strings.ReplaceAll(strings.ToLower(fields[1]), "-", "_")
+	if !slices.Contains(models.WorkflowStatuses, status) {
+		return ephemeral("Статус должен быть todo, in_progress или done"), nil
+	}
+
+	task, err := s.taskService.GetTaskByID(taskID)
+	// Задачи других проектов для этой интеграции не существуют
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && task.ProjectID != projectID) {
+		return ephemeral(fmt.Sprintf("Задача %s не найдена в проекте", TaskRef(taskID))), nil
+	}
+	if err != nil {
+		return models.SlashCommandResponse{}, err
+	}
+	if task.Status == status {
+		return ephemeral(fmt.Sprintf("Задача %s уже в статусе %s", TaskRef(taskID), status)), nil
+	}
+
+	if err := s.taskService.UpdateTask(taskID, models.TaskUpdateReq{Status: &status}, 0); err != nil {
+		s.logger.Warn("slash command status change rejected", "op", "service.chatopsCommand.status", "task_id", taskID, "status", status, "err", err)
+		return ephemeral(fmt.Sprintf("Не удалось перевести %s из %s в %s: %s", TaskRef(taskID), task.Status, status, err.Error())), nil
+	}
+
+	return ephemeral(fmt.Sprintf("Статус %s: %s → %s", TaskRef(taskID), task.Status, status)), nil
+}
+
+// parseTaskRef понимает TASK-12, #12 и просто 12
+func parseTaskRef(ref string) (uint, bool) {
+	ref = strings.TrimPrefix(strings.ToUpper(ref), "TASK-")
+	ref = strings.TrimPrefix(ref, "#")
+	id, err := strconv.ParseUint(ref, 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func ephemeral(text string) models.SlashCommandResponse {
+	return models.SlashCommandResponse{ResponseType: slashResponseEphemeral, Text: text}
+}
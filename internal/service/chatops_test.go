@@ -0,0 +1,109 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type fakeChatOpsRepo struct {
+	integrations map[uint]*models.ChatOpsIntegration
+}
+
+func (r *fakeChatOpsRepo) GetByProject(projectID uint) (*models.ChatOpsIntegration, error) {
+	integration, ok := r.integrations[projectID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return integration, nil
+}
+
+func (r *fakeChatOpsRepo) Save(integration *models.ChatOpsIntegration) error {
+	r.integrations[integration.ProjectID] = integration
+	return nil
+}
+
+func (r *fakeChatOpsRepo) Delete(projectID uint) error {
+	delete(r.integrations, projectID)
+	return nil
+}
+
+func (r *fakeUserRepo) GetUsersByIDs(ids []uint) ([]models.User, error) {
+	var result []models.User
+	for _, u := range r.users {
+		for _, id := range ids {
+			if u.ID == id {
+				result = append(result, u)
+			}
+		}
+	}
+	return result, nil
+}
+
+func TestChatOpsPublishDeliversToIncomingWebhook(t *testing.T) {
+	received := make(chan slackMessage, 1)
+	chat := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slackMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("decode chat message: %v", err)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		received <- msg
+	}))
+	defer chat.Close()
+
+	repo := &fakeChatOpsRepo{integrations: map[uint]*models.ChatOpsIntegration{
+		7: {
+			ProjectID:  7,
+			WebhookURL: chat.URL,
+			Channel:    "dev",
+			Username:   "minijira",
+			Events:     models.EventTaskAssigned,
+			Active:     true,
+		},
+	}}
+	users := &fakeUserRepo{users: []models.User{{FullName: "Ivan Petrov"}}}
+	users.users[0].ID = 3
+
+	svc := NewChatOpsService(repo, nil, users, nil, newTestLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.Run(ctx)
+
+	task := &models.Task{Title: "Fix <login>", ProjectID: 7}
+	task.ID = 12
+	// Событие, на которое интеграция не подписана, в чат не уходит
+	svc.Publish(NewDomainEvent(models.EventTaskCreated, 7, taskEventData(task, "todo")))
+	svc.Publish(taskAssignedDomainEvent(task, []uint{3}, 0))
+
+	select {
+	case msg := <-received:
+		if !strings.Contains(msg.Text, "назначено на Ivan Petrov") {
+			t.Errorf("unexpected text %q", msg.Text)
+		}
+		if !strings.Contains(msg.Text, "Fix &lt;login&gt;") {
+			t.Errorf("task title is not escaped: %q", msg.Text)
+		}
+		if msg.Channel != "dev" || msg.Username != "minijira" {
+			t.Errorf("channel/username = %q/%q, want dev/minijira", msg.Channel, msg.Username)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("chat message was not delivered")
+	}
+
+	select {
+	case msg := <-received:
+		t.Errorf("unexpected extra message %q", msg.Text)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
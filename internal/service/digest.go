@@ -0,0 +1,242 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	digestCheckInterval = 15 * time.Minute
+	digestDefaultHour   = 8
+)
+
+// digestDueSoonWindow — насколько вперёд смотрим на сроки задач для каждой частоты
+var digestDueSoonWindow = map[string]time.Duration{
+	models.DigestDaily:  48 * time.Hour,
+	models.DigestWeekly: 7 * 24 * time.Hour,
+}
+
+type DigestService interface {
+	Run(ctx context.Context)
+	SendDue(now time.Time)
+}
+
+type digestService struct {
+	db           *gorm.DB
+	repo         repository.DigestRepository
+	settings     NotificationSettingsService
+	emailService *EmailService
+	logger       *slog.Logger
+	// hour — час отправки сводки в часовом поясе пользователя; недельная сводка уходит в понедельник
+	hour int
+}
+
+func NewDigestService(db *gorm.DB, repo repository.DigestRepository, settings NotificationSettingsService, emailService *EmailService, logger *slog.Logger) DigestService {
+	return &digestService{db: db, repo: repo, settings: settings, emailService: emailService, logger: logger, hour: digestHour()}
+}
+
+// digestHour — час рассылки из DIGEST_HOUR, по умолчанию digestDefaultHour
+func digestHour() int {
+	if v, err := strconv.Atoi(os.Getenv("DIGEST_HOUR")); err == nil && v >= 0 && v < 24 {
+		return v
+	}
+	return digestDefaultHour
+}
+
+// Run раз в digestCheckInterval рассылает сводки, время которых наступило
+func (s *digestService) Run(ctx context.Context) {
+	s.logger.Info("digest worker started", "op", "service.digest.Run", "hour", s.hour)
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.SendDue(time.Now())
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("digest worker stopped", "op", "service.digest.Run")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *digestService) SendDue(now time.Time) {
+	for _, frequency := range []string{models.DigestDaily, models.DigestWeekly} {
+		users, err := s.repo.ListRecipients(frequency)
+		if err != nil {
+			s.logger.Error("failed to list digest recipients", "op", "service.digest.SendDue", "frequency", frequency, "err", err)
+			continue
+		}
+
+		ids := make([]uint, 0, len(users))
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+		prefs, err := s.settings.Resolve(ids)
+		if err != nil {
+			s.logger.Error("failed to load notification settings", "op", "service.digest.SendDue", "frequency", frequency, "err", err)
+			continue
+		}
+
+		for _, user := range users {
+			p := prefs[user.ID]
+			slot := digestSlot(now.In(p.Location()), frequency, s.hour)
+			// Новый получатель (или все пользователи сразу после включения сводок) ничего не
+			// получает за уже прошедший слот: отметка ставится на него, письмо уйдёт в следующий
+			if user.DigestLastSentAt == nil {
+				if err := s.repo.MarkDigestSent(user.ID, slot); err != nil {
+					s.logger.Error("failed to initialise digest schedule", "op", "service.digest.SendDue", "user_id", user.ID, "err", err)
+				}
+				continue
+			}
+			if !user.DigestLastSentAt.Before(slot) {
+				continue
+			}
+			if err := s.sendDigest(user, p, frequency, slot, now); err != nil {
+				s.logger.Error("failed to send digest", "op", "service.digest.SendDue", "user_id", user.ID, "err", err)
+			}
+		}
+	}
+}
+
+// sendDigest собирает сводку с момента прошлой отправки. Пустая сводка не отправляется,
+// но отметка всё равно сдвигается, чтобы не пересчитывать её каждые 15 минут.
+func (s *digestService) sendDigest(user models.User, prefs NotificationPrefs, frequency string, slot, now time.Time) error {
+	since := previousDigestSlot(slot, frequency)
+	if user.DigestLastSentAt != nil {
+		since = *user.DigestLastSentAt
+	}
+
+	data, err := s.collect(user, prefs, frequency, since, now)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if !data.IsEmpty() {
+			emailService := s.emailService.WithDB(tx).DeferUntil(prefs.QuietUntil(now))
+			if err := emailService.QueueDigestEmail(user, data); err != nil {
+				return err
+			}
+		}
+		if err := s.repo.WithDB(tx).MarkDigestSent(user.ID, now); err != nil {
+			return err
+		}
+
+		s.logger.Info("digest processed", "op", "service.digest.sendDigest", "user_id", user.ID, "frequency", frequency, "empty", data.IsEmpty())
+		return nil
+	})
+}
+
+// collect учитывает настройки: в сводку попадают только события с каналом digest и не из заглушённых проектов
+func (s *digestService) collect(user models.User, prefs NotificationPrefs, frequency string, since, now time.Time) (DigestEmailData, error) {
+	data := DigestEmailData{Since: since}
+
+	tasks, err := s.repo.GetDueSoonTasks(user.ID, now.Add(digestDueSoonWindow[frequency]))
+	if err != nil {
+		return data, err
+	}
+	for _, t := range tasks {
+		if prefs.Muted(t.ProjectID) {
+			continue
+		}
+		data.DueSoon = append(data.DueSoon, DigestTask{
+			Title:   t.Title,
+			DueDate: *t.DueDate,
+			Link:    fmt.Sprintf("%s/tasks/%d", AppBaseURL(), t.ID),
+		})
+	}
+
+	updates, err := s.notificationsSince(user.ID, prefs, []string{models.NotificationTaskAssigned, models.NotificationTaskStatusChanged}, since, false)
+	if err != nil {
+		return data, err
+	}
+	for _, n := range updates {
+		item := DigestItem{Title: n.EntityName, Text: n.Text}
+		if item.Title == "" {
+			item.Title = n.Title
+		}
+		if n.Type == models.NotificationTaskAssigned {
+			data.Assigned = append(data.Assigned, item)
+		} else {
+			data.StatusChanges = append(data.StatusChanges, item)
+		}
+	}
+
+	mentions, err := s.notificationsSince(user.ID, prefs, []string{models.NotificationChatMention}, since, true)
+	if err != nil {
+		return data, err
+	}
+	for _, n := range mentions {
+		data.Mentions = append(data.Mentions, DigestItem{Title: n.Title, Text: n.Text})
+	}
+
+	activity, err := s.notificationsSince(user.ID, prefs, []string{models.NotificationChatComment, models.NotificationChatReply, models.NotificationTeamAdded}, since, false)
+	if err != nil {
+		return data, err
+	}
+	for _, n := range activity {
+		item := DigestItem{Title: n.EntityName, Text: n.Text}
+		if item.Title == "" {
+			item.Title = n.Title
+		}
+		data.Activity = append(data.Activity, item)
+	}
+
+	return data, nil
+}
+
+// notificationsSince выбирает уведомления только тех типов и проектов, которые пользователь оставил для сводки
+func (s *digestService) notificationsSince(userID uint, prefs NotificationPrefs, types []string, since time.Time, unreadOnly bool) ([]repository.DigestNotification, error) {
+	var allowed []string
+	for _, t := range types {
+		if prefs.Allows(t, models.ChannelDigest, 0) {
+			allowed = append(allowed, t)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, nil
+	}
+
+	notifications, err := s.repo.GetNotificationsSince(userID, allowed, since, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	result := notifications[:0]
+	for _, n := range notifications {
+		if !prefs.Muted(n.ProjectID) {
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
+
+// digestSlot — последний момент отправки сводки не позже now
+func digestSlot(now time.Time, frequency string, hour int) time.Time {
+	slot := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if frequency == models.DigestWeekly {
+		daysSinceMonday := (int(slot.Weekday()) + 6) % 7
+		slot = slot.AddDate(0, 0, -daysSinceMonday)
+	}
+	if slot.After(now) {
+		slot = previousDigestSlot(slot, frequency)
+	}
+	return slot
+}
+
+func previousDigestSlot(slot time.Time, frequency string) time.Time {
+	if frequency == models.DigestWeekly {
+		return slot.AddDate(0, 0, -7)
+	}
+	return slot.AddDate(0, 0, -1)
+}
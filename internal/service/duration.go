@@ -0,0 +1,35 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// formatDuration — длительность для людей: «2d 3h 15m», «45m», «30s».
+// Сутки считаются календарными, по 24 часа; секунды показываются только до минуты.
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int64(d.Seconds()))
+	}
+
+	d = d.Round(time.Minute)
+	days := int64(d / (24 * time.Hour))
+	hours := int64(d % (24 * time.Hour) / time.Hour)
+	minutes := int64(d % time.Hour / time.Minute)
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	outboxPollInterval = 5 * time.Second
+	outboxBatchSize    = 20
+	// outboxLease — сколько письмо считается занятым воркером, пока идёт отправка
+	outboxLease       = 2 * time.Minute
+	outboxMaxAttempts = 8
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = time.Hour
+)
+
+var (
+	ErrEmailNotFound    = errors.New("email not found")
+	ErrEmailAlreadySent = errors.New("email has already been sent")
+)
+
+type EmailOutboxService interface {
+	Run(ctx context.Context)
+	ListFailed(limit, offset int) ([]models.EmailOutbox, error)
+	Resend(id uint) error
+}
+
+type emailOutboxService struct {
+	repo         repository.EmailOutboxRepository
+	emailService *EmailService
+	logger       *slog.Logger
+}
+
+func NewEmailOutboxService(repo repository.EmailOutboxRepository, emailService *EmailService, logger *slog.Logger) EmailOutboxService {
+	return &emailOutboxService{repo: repo, emailService: emailService, logger: logger}
+}
+
+// Run доставляет письма из outbox, пока не отменён ctx
+func (s *emailOutboxService) Run(ctx context.Context) {
+	s.logger.Info("email outbox worker started", "op", "service.emailOutbox.Run")
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.deliverDue()
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("email outbox worker stopped", "op", "service.emailOutbox.Run")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *emailOutboxService) deliverDue() {
+	messages, err := s.repo.ClaimDue(time.Now(), outboxLease, outboxBatchSize)
+	if err != nil {
+		s.logger.Error("failed to claim emails", "op", "service.emailOutbox.deliverDue", "err", err)
+		return
+	}
+
+	for _, msg := range messages {
+		s.deliver(msg)
+	}
+}
+
+func (s *emailOutboxService) deliver(msg models.EmailOutbox) {
+	sendErr := s.emailService.SendEmail(MailMessage{
+		To:      msg.To,
+		Subject: msg.Subject,
+		Text:    msg.Body,
+		HTML:    msg.HTMLBody,
+		ReplyTo: msg.ReplyTo,
+	})
+	if sendErr == nil {
+		if err := s.repo.MarkSent(msg.ID); err != nil {
+			s.logger.Error("failed to mark email sent", "id", msg.ID, "err", err)
+			return
+		}
+		s.logger.Info("email sent", "op", "service.emailOutbox.deliver", "id", msg.ID, "to", msg.To)
+		return
+	}
+
+	attempts := msg.Attempts + 1
+	dead := attempts >= outboxMaxAttempts
+	next := time.Now().Add(retryBackoff(attempts, outboxBaseBackoff, outboxMaxBackoff))
+
+	if err := s.repo.MarkFailed(msg.ID, attempts, next, sendErr.Error(), dead); err != nil {
+		s.logger.Error("failed to record email failure", "id", msg.ID, "err", err)
+		return
+	}
+
+	if dead {
+		s.logger.Error("email moved to dead-letter", "op", "service.emailOutbox.deliver", "id", msg.ID, "to", msg.To, "attempts", attempts, "err", sendErr)
+		return
+	}
+	s.logger.Warn("email delivery failed, will retry", "op", "service.emailOutbox.deliver", "id", msg.ID, "attempts", attempts, "next_attempt_at", next, "err", sendErr)
+}
+
+func (s *emailOutboxService) ListFailed(limit, offset int) ([]models.EmailOutbox, error) {
+	messages, err := s.repo.ListFailed(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if messages == nil {
+		messages = []models.EmailOutbox{}
+	}
+	return messages, nil
+}
+
+func (s *emailOutboxService) Resend(id uint) error {
+	msg, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEmailNotFound
+		}
+		return err
+	}
+
+	if msg.Status == models.EmailStatusSent {
+		return ErrEmailAlreadySent
+	}
+
+	return s.repo.Requeue(id)
+}
+
+// retryBackoff — экспоненциальная задержка base*2^(attempts-1), ограниченная max
+func retryBackoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
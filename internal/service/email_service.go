@@ -1,116 +1,147 @@
 package service
 
 import (
-	"crypto/tls"
-	"fmt"
-	"net/smtp"
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
 	"os"
+	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
+	"gorm.io/gorm"
 )
 
 type EmailService struct {
-	host     string
-	port     string
-	user     string
-	pass     string
-	from     string
-	disabled bool // Для разработки - отключить отправку email
+	mailer    Mailer
+	templates *EmailTemplates
+	outbox    repository.EmailOutboxRepository
+	// notBefore — письма не уходят раньше этого момента (тихие часы получателя)
+	notBefore time.Time
 }
 
-func NewEmailService() *EmailService {
-	err := godotenv.Load(".env")
-	if err != nil {
-		panic(err)
+// NewEmailService принимает транспорт извне: в проде — NewMailerFromEnv, в тестах — MemoryMailer
+func NewEmailService(mailer Mailer, templates *EmailTemplates, outbox repository.EmailOutboxRepository) *EmailService {
+	return &EmailService{
+		mailer:    mailer,
+		templates: templates,
+		outbox:    outbox,
 	}
+}
 
-	// Проверяем, отключена ли отправка email (для разработки)
-	disabled := os.Getenv("SMTP_DISABLED") == "true"
-
-	return &EmailService{
-		host:     os.Getenv("SMTP_HOST"),
-		port:     os.Getenv("SMTP_PORT"),
-		user:     os.Getenv("SMTP_USER"),
-		pass:     os.Getenv("SMTP_PASS"),
-		from:     os.Getenv("SMTP_FROM"),
-		disabled: disabled,
+// AppBaseURL — адрес приложения для ссылок в письмах
+func AppBaseURL() string {
+	if url := os.Getenv("APP_BASE_URL"); url != "" {
+		return strings.TrimRight(url, "/")
 	}
+	return "http://localhost:8080"
 }
 
-func (s *EmailService) SendEmail(to, subject, body string) error {
-	// Если отправка email отключена (для разработки), просто возвращаем успех
-	if s.disabled {
-		return nil
+// PasswordResetURL — страница фронтенда, которая читает reset_token из адреса и отправляет
+// новый пароль в POST /auth/password/reset. API сам форму сброса не отдаёт.
+func PasswordResetURL() string {
+	if url := os.Getenv("PASSWORD_RESET_URL"); url != "" {
+		return url
 	}
+	return "http://localhost:3000/index.html"
+}
+
+// WithDB возвращает копию сервиса, которая ставит письма в outbox внутри транзакции db
+func (s *EmailService) WithDB(db *gorm.DB) *EmailService {
+	copied := *s
+	copied.outbox = s.outbox.WithDB(db)
+	return &copied
+}
 
-	addr := fmt.Sprintf("%s:%s", s.host, s.port)
+// DeferUntil возвращает копию сервиса, письма которой outbox отправит не раньше t.
+// Нулевое t ничего не откладывает.
+func (s *EmailService) DeferUntil(t time.Time) *EmailService {
+	copied := *s
+	copied.notBefore = t
+	return &copied
+}
 
-	msg := []byte(
-		"From: " + s.from + "\r\n" +
-			"To: " + to + "\r\n" +
-			"Subject: " + subject + "\r\n" +
-			"MIME-Version: 1.0\r\n" +
-			"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
-			body + "\r\n",
-	)
+// Queue сохраняет письмо в outbox; доставкой занимается EmailOutboxService
+func (s *EmailService) Queue(msg MailMessage) error {
+	return s.outbox.Enqueue(&models.EmailOutbox{
+		To:            msg.To,
+		Subject:       msg.Subject,
+		Body:          msg.Text,
+		HTMLBody:      msg.HTML,
+		ReplyTo:       msg.ReplyTo,
+		NextAttemptAt: s.notBefore,
+	})
+}
 
-	// Используем стандартный smtp.Dial, который правильно обрабатывает приветствие сервера
-	// Это более надежный способ для Yandex SMTP
-	c, err := smtp.Dial(addr)
+// QueueTemplate рендерит шаблон на языке получателя и ставит письмо в outbox
+func (s *EmailService) QueueTemplate(to, locale, name string, data any) error {
+	msg, err := s.templates.Render(locale, name, data)
 	if err != nil {
-		return fmt.Errorf("smtp dial failed: %w", err)
-	}
-	defer func() {
-		if c != nil {
-			c.Quit()
-		}
-	}()
-
-	if ok, _ := c.Extension("STARTTLS"); !ok {
-		return fmt.Errorf("server does not support STARTTLS")
+		return err
 	}
+	msg.To = to
+	return s.Queue(msg)
+}
 
-	tlsConfig := &tls.Config{
-		ServerName:         s.host,
-		InsecureSkipVerify: false,
-	}
+// SendEmail отправляет письмо сразу через настроенный транспорт
+func (s *EmailService) SendEmail(msg MailMessage) error {
+	return s.mailer.Send(msg)
+}
 
-	if err := c.StartTLS(tlsConfig); err != nil {
-		return fmt.Errorf("starttls failed: %w", err)
-	}
+func (s *EmailService) QueueVerificationEmail(user models.User, link string) error {
+	return s.QueueTemplate(user.Email, user.Locale, EmailTemplateVerification, VerificationEmailData{
+		Name: user.FullName,
+		Link: link,
+	})
+}
 
-	if ok, _ := c.Extension("AUTH"); !ok {
-		return fmt.Errorf("server does not support AUTH")
-	}
+func (s *EmailService) QueuePasswordResetEmail(user models.User, link string, validHours int) error {
+	return s.QueueTemplate(user.Email, user.Locale, EmailTemplatePasswordReset, PasswordResetEmailData{
+		Name:       user.FullName,
+		Link:       link,
+		ValidHours: validHours,
+	})
+}
 
-	auth := smtp.PlainAuth("", s.user, s.pass, s.host)
-	if err := c.Auth(auth); err != nil {
-		return fmt.Errorf("smtp auth failed: %w", err)
-	}
+func (s *EmailService) QueueAssignmentEmail(user models.User, actorName, taskTitle, link string) error {
+	return s.QueueTemplate(user.Email, user.Locale, EmailTemplateAssignment, AssignmentEmailData{
+		Name:      user.FullName,
+		ActorName: actorName,
+		TaskTitle: taskTitle,
+		Link:      link,
+	})
+}
 
-	if err := c.Mail(s.from); err != nil {
-		return fmt.Errorf("MAIL FROM failed: %w", err)
-	}
-	if err := c.Rcpt(to); err != nil {
-		return fmt.Errorf("RCPT TO failed: %w", err)
+// QueueMentionEmail — письмо об упоминании; при непустом replyTo ответ на письмо попадёт в чат
+func (s *EmailService) QueueMentionEmail(user models.User, actorName, excerpt, link, replyTo string) error {
+	msg, err := s.templates.Render(user.Locale, EmailTemplateMention, MentionEmailData{
+		Name:      user.FullName,
+		ActorName: actorName,
+		Excerpt:   excerpt,
+		Link:      link,
+		CanReply:  replyTo != "",
+	})
+	if err != nil {
+		return err
 	}
+	msg.To = user.Email
+	msg.ReplyTo = replyTo
+	return s.Queue(msg)
+}
+
+// QueueCommentEmail — письмо о новом комментарии в чате задачи
+func (s *EmailService) QueueCommentEmail(user models.User, data CommentEmailData, replyTo string) error {
+	data.Name = user.FullName
+	data.CanReply = replyTo != ""
 
-	w, err := c.Data()
+	msg, err := s.templates.Render(user.Locale, EmailTemplateComment, data)
 	if err != nil {
-		return fmt.Errorf("DATA open failed: %w", err)
+		return err
 	}
-	if _, err := w.Write(msg); err != nil {
-		return fmt.Errorf("write failed: %w", err)
-	}
-	if err := w.Close(); err != nil {
-		return fmt.Errorf("send failed: %w", err)
-	}
-
-	return nil
+	msg.To = user.Email
+	msg.ReplyTo = replyTo
+	return s.Queue(msg)
 }
 
-func (s *EmailService) SendVerificationEmail(to, name, link string) error {
-	subject := "Подтверждение аккаунта MiniJira"
-	body := fmt.Sprintf("Здравствуйте, %s!\n\nПерейдите по ссылке для подтверждения:\n%s", name, link)
-	return s.SendEmail(to, subject, body)
+func (s *EmailService) QueueDigestEmail(user models.User, data DigestEmailData) error {
+	data.Name = user.FullName
+	return s.QueueTemplate(user.Email, user.Locale, EmailTemplateDigest, data)
 }
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Имена шаблонов писем. Для каждой локали лежат пары name.txt.tmpl (subject + text) и name.html.tmpl (content)
+const (
+	EmailTemplateVerification  = "verification"
+	EmailTemplatePasswordReset = "password_reset"
+	EmailTemplateAssignment    = "assignment"
+	EmailTemplateMention       = "mention"
+	EmailTemplateComment       = "comment"
+	EmailTemplateDigest        = "digest"
+)
+
+var emailTemplateNames = []string{
+	EmailTemplateVerification,
+	EmailTemplatePasswordReset,
+	EmailTemplateAssignment,
+	EmailTemplateMention,
+	EmailTemplateComment,
+	EmailTemplateDigest,
+}
+
+var ErrEmailTemplateNotFound = errors.New("email template not found")
+
+//go:embed templates/email
+var embeddedEmailTemplates embed.FS
+
+type VerificationEmailData struct {
+	Name string
+	Link string
+}
+
+type PasswordResetEmailData struct {
+	Name       string
+	Link       string
+	ValidHours int
+}
+
+type AssignmentEmailData struct {
+	Name      string
+	ActorName string
+	TaskTitle string
+	Link      string
+}
+
+type MentionEmailData struct {
+	Name      string
+	ActorName string
+	Excerpt   string
+	Link      string
+	CanReply  bool
+}
+
+// ReplyEnabled — ответ на письмо попадёт в обсуждение; футер тогда не просит не отвечать
+func (d MentionEmailData) ReplyEnabled() bool { return d.CanReply }
+
+type CommentEmailData struct {
+	Name       string
+	ActorName  string
+	EntityName string
+	Excerpt    string
+	Link       string
+	// IsReply — комментарий в треде, на который подписан получатель
+	IsReply  bool
+	CanReply bool
+}
+
+func (d CommentEmailData) ReplyEnabled() bool { return d.CanReply }
+
+// emailTemplateFuncs доступны в HTML-шаблонах, включая layout и footer.
+// canReply — можно ли ответить на письмо; данные без ReplyEnabled считаются письмом без ответа.
+var emailTemplateFuncs = htmltemplate.FuncMap{
+	"canReply": func(data any) bool {
+		r, ok := data.(interface{ ReplyEnabled() bool })
+		return ok && r.ReplyEnabled()
+	},
+}
+
+type DigestEmailData struct {
+	Name          string
+	Since         time.Time
+	DueSoon       []DigestTask
+	Assigned      []DigestItem
+	StatusChanges []DigestItem
+	Mentions      []DigestItem
+	// Activity — комментарии, ответы и прочие события, которые пользователь перевёл в сводку
+	Activity []DigestItem
+}
+
+// IsEmpty — в сводке нечего показывать, письмо не отправляется
+func (d DigestEmailData) IsEmpty() bool {
+	return len(d.DueSoon) == 0 && len(d.Assigned) == 0 && len(d.StatusChanges) == 0 && len(d.Mentions) == 0 && len(d.Activity) == 0
+}
+
+type DigestTask struct {
+	Title   string
+	DueDate time.Time
+	Link    string
+}
+
+type DigestItem struct {
+	Title string
+	Text  string
+}
+
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// EmailTemplates — разобранные шаблоны писем по локалям
+type EmailTemplates struct {
+	sets map[string]map[string]emailTemplate
+}
+
+// LoadEmailTemplates берёт встроенные шаблоны; файлы из EMAIL_TEMPLATES_DIR
+// (та же структура: layout.html.tmpl, ru/..., en/...) перекрывают встроенные по одному.
+func LoadEmailTemplates() (*EmailTemplates, error) {
+	embedded, err := fs.Sub(embeddedEmailTemplates, "templates/email")
+	if err != nil {
+		return nil, err
+	}
+
+	var fsys fs.FS = embedded
+	if dir := os.Getenv("EMAIL_TEMPLATES_DIR"); dir != "" {
+		fsys = overlayFS{top: os.DirFS(dir), base: embedded}
+	}
+
+	return NewEmailTemplates(fsys)
+}
+
+func NewEmailTemplates(fsys fs.FS) (*EmailTemplates, error) {
+	layout, err := fs.ReadFile(fsys, "layout.html.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("read email layout: %w", err)
+	}
+
+	t := &EmailTemplates{sets: make(map[string]map[string]emailTemplate)}
+	for _, locale := range models.SupportedLocales {
+		footer, err := fs.ReadFile(fsys, locale+"/footer.html.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("read email footer %s: %w", locale, err)
+		}
+
+		set := make(map[string]emailTemplate, len(emailTemplateNames))
+		for _, name := range emailTemplateNames {
+			base := locale + "/" + name
+
+			textSrc, err := fs.ReadFile(fsys, base+".txt.tmpl")
+			if err != nil {
+				return nil, fmt.Errorf("read email template %s: %w", base, err)
+			}
+			textTmpl, err := texttemplate.New(name).Parse(string(textSrc))
+			if err != nil {
+				return nil, fmt.Errorf("parse email template %s.txt: %w", base, err)
+			}
+
+			htmlSrc, err := fs.ReadFile(fsys, base+".html.tmpl")
+			if err != nil {
+				return nil, fmt.Errorf("read email template %s: %w", base, err)
+			}
+			htmlTmpl := htmltemplate.New(name).Funcs(emailTemplateFuncs)
+			for _, src := range [][]byte{layout, footer, htmlSrc} {
+				if _, err := htmlTmpl.Parse(string(src)); err != nil {
+					return nil, fmt.Errorf("parse email template %s.html: %w", base, err)
+				}
+			}
+
+			set[name] = emailTemplate{text: textTmpl, html: htmlTmpl}
+		}
+		t.sets[locale] = set
+	}
+
+	return t, nil
+}
+
+// Render собирает письмо на языке пользователя; неизвестная локаль заменяется на русскую
+func (t *EmailTemplates) Render(locale, name string, data any) (MailMessage, error) {
+	set, ok := t.sets[models.NormalizeLocale(locale)]
+	if !ok {
+		set = t.sets[models.DefaultLocale]
+	}
+	tmpl, ok := set[name]
+	if !ok {
+		return MailMessage{}, fmt.Errorf("%w: %s", ErrEmailTemplateNotFound, name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return MailMessage{}, fmt.Errorf("render %s subject: %w", name, err)
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return MailMessage{}, fmt.Errorf("render %s text: %w", name, err)
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return MailMessage{}, fmt.Errorf("render %s html: %w", name, err)
+	}
+
+	return MailMessage{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// overlayFS отдаёт файл из top, если он там есть, иначе из base
+type overlayFS struct {
+	top  fs.FS
+	base fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.top.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return o.base.Open(name)
+}
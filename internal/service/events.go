@@ -0,0 +1,70 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventPublisher получает доменные события после коммита. Publish не возвращает ошибку:
+// сбой интеграции не должен откатывать изменение, реализации сами логируют проблемы.
+type EventPublisher interface {
+	Publish(event models.DomainEvent)
+}
+
+// MultiPublisher рассылает событие нескольким подписчикам по очереди
+type MultiPublisher []EventPublisher
+
+func (m MultiPublisher) Publish(event models.DomainEvent) {
+	for _, p := range m {
+		p.Publish(event)
+	}
+}
+
+func NewDomainEvent(eventType string, projectID uint, data any) models.DomainEvent {
+	return models.DomainEvent{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		ProjectID:  projectID,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+func taskEventData(task *models.Task, status string) models.TaskEventData {
+	return models.TaskEventData{
+		ID:          task.ID,
+		Title:       task.Title,
+		Status:      status,
+		ProjectID:   task.ProjectID,
+		Priority:    task.Priority,
+		DueDate:     task.DueDate,
+		AssigneeIDs: userIDs(task.Users),
+	}
+}
+
+func taskStatusChangedDomainEvent(task *models.Task, oldStatus, newStatus string, actorID uint) models.DomainEvent {
+	return NewDomainEvent(models.EventTaskStatusChanged, task.ProjectID, models.TaskStatusChangedData{
+		Task:      taskEventData(task, newStatus),
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		ActorID:   actorID,
+	})
+}
+
+func taskAssignedDomainEvent(task *models.Task, assigneeIDs []uint, actorID uint) models.DomainEvent {
+	return NewDomainEvent(models.EventTaskAssigned, task.ProjectID, models.TaskAssignedData{
+		Task:        taskEventData(task, task.Status),
+		AssigneeIDs: assigneeIDs,
+		ActorID:     actorID,
+	})
+}
+
+func projectCompletedEvent(projectID uint, title string) models.DomainEvent {
+	return NewDomainEvent(models.EventProjectCompleted, projectID, models.ProjectEventData{
+		ID:     projectID,
+		Title:  title,
+		Status: "done",
+	})
+}
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	inboundPollInterval = 10 * time.Second
+	// inboundMaxSize — письма больше этого размера не разбираются целиком
+	inboundMaxSize = 10 << 20
+	// inboundMaxText совпадает с ограничением длины комментария в ChatMessageCreateReq
+	inboundMaxText = 5000
+)
+
+var (
+	ErrInboundAutoReply      = errors.New("inbound email is an automatic reply")
+	ErrInboundNoReplyAddress = errors.New("inbound email has no valid reply address")
+	ErrInboundUnknownSender  = errors.New("inbound email sender is not a verified user")
+	ErrInboundSenderMismatch = errors.New("inbound email sender does not match the reply address")
+	ErrInboundForbidden      = errors.New("sender has no access to this chat")
+	ErrInboundEmpty          = errors.New("inbound email has no reply text")
+)
+
+// InboundEmailService превращает ответы на письма-уведомления в комментарии чата
+type InboundEmailService interface {
+	Run(ctx context.Context)
+	Process(ctx context.Context, r io.Reader) (*models.ChatMessage, error)
+}
+
+type inboundEmailService struct {
+	dir    string
+	users  repository.UserRepository
+	chat   ChatService
+	logger *slog.Logger
+}
+
+// NewInboundEmailService читает письма из maildir dir: MTA складывает их в new/,
+// обработанные переносятся в cur/ с флагом S (принято) или T (отклонено).
+func NewInboundEmailService(dir string, users repository.UserRepository, chat ChatService, logger *slog.Logger) InboundEmailService {
+	return &inboundEmailService{dir: dir, users: users, chat: chat, logger: logger}
+}
+
+func (s *inboundEmailService) Run(ctx context.Context) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(s.dir, sub), 0o755); err != nil {
+			s.logger.Error("failed to prepare inbound maildir", "op", "service.inboundEmail.Run", "dir", s.dir, "err", err)
+			return
+		}
+	}
+
+	s.logger.Info("inbound email poller started", "op", "service.inboundEmail.Run", "dir", s.dir)
+	ticker := time.NewTicker(inboundPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.processNew(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("inbound email poller stopped", "op", "service.inboundEmail.Run")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *inboundEmailService) processNew(ctx context.Context) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, "new"))
+	if err != nil {
+		s.logger.Error("failed to read inbound maildir", "op", "service.inboundEmail.processNew", "err", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		s.processFile(ctx, entry.Name())
+	}
+}
+
+func (s *inboundEmailService) processFile(ctx context.Context, name string) {
+	path := filepath.Join(s.dir, "new", name)
+	f, err := os.Open(path)
+	if err != nil {
+		s.logger.Error("failed to open inbound email", "op", "service.inboundEmail.processFile", "file", name, "err", err)
+		return
+	}
+
+	msg, procErr := s.Process(ctx, io.LimitReader(f, inboundMaxSize))
+	f.Close()
+
+	flag := "S"
+	if procErr != nil {
+		flag = "T"
+		s.logger.Warn("inbound email rejected", "op", "service.inboundEmail.processFile", "file", name, "err", procErr)
+	} else {
+		s.logger.Info("inbound email posted to chat", "op", "service.inboundEmail.processFile", "file", name, "message_id", msg.ID)
+	}
+
+	if err := os.Rename(path, filepath.Join(s.dir, "cur", name+":2,"+flag)); err != nil {
+		s.logger.Error("failed to move inbound email", "op", "service.inboundEmail.processFile", "file", name, "err", err)
+	}
+}
+
+// Process разбирает одно письмо и публикует ответ в чат с теми же проверками доступа, что и HTTP API
+func (s *inboundEmailService) Process(ctx context.Context, r io.Reader) (*models.ChatMessage, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("parse inbound email: %w", err)
+	}
+
+	if isAutoReply(msg.Header) {
+		return nil, ErrInboundAutoReply
+	}
+
+	target, ok := findReplyTarget(msg.Header)
+	if !ok {
+		return nil, ErrInboundNoReplyAddress
+	}
+
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		return nil, ErrInboundUnknownSender
+	}
+	user, err := s.users.GetUserByEmail(from.Address)
+	if err != nil || user.ID == 0 || !user.IsVerified {
+		return nil, ErrInboundUnknownSender
+	}
+	// Адрес ответа персональный: чужая пересланная ссылка не позволит писать от другого имени
+	if user.ID != target.UserID {
+		return nil, ErrInboundSenderMismatch
+	}
+
+	body, err := extractPlainText(msg.Header, msg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read inbound email body: %w", err)
+	}
+	// Место под «…» оставляем, чтобы обрезанный текст влез в лимит комментария
+	text := truncateText(StripQuotedReply(body), inboundMaxText-1)
+	if text == "" {
+		return nil, ErrInboundEmpty
+	}
+
+	allowed, err := s.chat.CanUserAccessChat(ctx, target.ChatableType, target.ChatableID, user)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrInboundForbidden
+	}
+
+	req := models.ChatMessageCreateReq{
+		UserID:       user.ID,
+		Text:         text,
+		ChatableID:   target.ChatableID,
+		ChatableType: target.ChatableType,
+	}
+	if target.ThreadID != 0 {
+		threadID := target.ThreadID
+		req.ParentID = &threadID
+	}
+
+	created, err := s.chat.AddMessage(ctx, req)
+	// Если тред успели удалить, ответ всё равно не теряется и попадает в чат верхним уровнем
+	if errors.Is(err, ErrParentNotFound) {
+		req.ParentID = nil
+		created, err = s.chat.AddMessage(ctx, req)
+	}
+	return created, err
+}
+
+func isAutoReply(h mail.Header) bool {
+	if v := strings.ToLower(strings.TrimSpace(h.Get("Auto-Submitted"))); v != "" && v != "no" {
+		return true
+	}
+	switch strings.ToLower(strings.TrimSpace(h.Get("Precedence"))) {
+	case "bulk", "junk", "list", "auto_reply":
+		return true
+	}
+	return h.Get("X-Autoreply") != "" || h.Get("X-Autorespond") != ""
+}
+
+// findReplyTarget ищет адрес reply+... среди получателей письма
+func findReplyTarget(h mail.Header) (ReplyTarget, bool) {
+	for _, key := range []string{"To", "Cc", "Delivered-To", "X-Original-To"} {
+		for _, value := range h[key] {
+			addrs, err := mail.ParseAddressList(value)
+			if err != nil {
+				continue
+			}
+			for _, a := range addrs {
+				if target, err := ParseReplyAddress(a.Address); err == nil {
+					return target, true
+				}
+			}
+		}
+	}
+	return ReplyTarget{}, false
+}
+
+// headerGetter — общее у mail.Header и textproto.MIMEHeader частей multipart
+type headerGetter interface {
+	Get(key string) string
+}
+
+// extractPlainText достаёт текст письма: text/plain предпочтительнее, HTML используется как запасной вариант
+func extractPlainText(h headerGetter, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	decoded := decodeTransfer(h.Get("Content-Transfer-Encoding"), body)
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		var plain, htmlText string
+		mr := multipart.NewReader(decoded, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+
+			if strings.HasPrefix(strings.ToLower(part.Header.Get("Content-Disposition")), "attachment") {
+				continue
+			}
+			text, err := extractPlainText(part.Header, part)
+			if err != nil {
+				return "", err
+			}
+
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			if partType == "text/html" {
+				if htmlText == "" {
+					htmlText = text
+				}
+			} else if plain == "" {
+				plain = text
+			}
+		}
+		if plain != "" {
+			return plain, nil
+		}
+		return htmlText, nil
+	}
+
+	if !strings.HasPrefix(mediaType, "text/") {
+		return "", nil
+	}
+
+	raw, err := io.ReadAll(decoded)
+	if err != nil {
+		return "", err
+	}
+	text := string(raw)
+	if !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, "")
+	}
+	if mediaType == "text/html" {
+		text = htmlToText(text)
+	}
+	return text, nil
+}
+
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+var (
+	htmlBlockRe = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>|</blockquote>`)
+	htmlQuoteRe = regexp.MustCompile(`(?is)<blockquote.*?</blockquote>`)
+	htmlTagRe   = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+func htmlToText(s string) string {
+	s = htmlQuoteRe.ReplaceAllString(s, "\n")
+	s = htmlBlockRe.ReplaceAllString(s, "\n")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	return html.UnescapeString(s)
+}
+
+var (
+	// Заголовки цитаты почтовых клиентов: «On ... wrote:», «... написал(а):», Outlook и т.п.
+	quoteHeaderRe = regexp.MustCompile(`(?i)^(on\s.+wrote:|.+\s(написал|написала|написал\(а\)|пишет):|-{2,}\s*(original message|исходное сообщение|пересылаемое сообщение)\s*-{2,}|_{10,})\s*$`)
+	quoteWroteRe  = regexp.MustCompile(`(?i)^.*(wrote|написал|написала|написал\(а\)|пишет):\s*$`)
+	outlookFromRe = regexp.MustCompile(`(?i)^(from|от):\s.+`)
+	signatureRe   = regexp.MustCompile(`(?i)^(-- ?|sent from my .+|отправлено (с|из) .+)$`)
+)
+
+// StripQuotedReply оставляет только новый текст ответа: всё начиная с цитаты,
+// заголовка цитаты или подписи отбрасывается
+func StripQuotedReply(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var kept []string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, ">") || quoteHeaderRe.MatchString(trimmed) || signatureRe.MatchString(trimmed) {
+			break
+		}
+		// Gmail переносит длинный заголовок цитаты: «On Mon, ... <a@b.c>» + «wrote:» на следующей строке
+		if strings.HasPrefix(strings.ToLower(trimmed), "on ") && i+1 < len(lines) && quoteWroteRe.MatchString(strings.TrimSpace(lines[i+1])) {
+			break
+		}
+		// Outlook начинает цитату блоком заголовков после пустой строки
+		if outlookFromRe.MatchString(trimmed) && (i == 0 || strings.TrimSpace(lines[i-1]) == "") {
+			break
+		}
+
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
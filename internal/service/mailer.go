@@ -0,0 +1,149 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+// MailMessage — письмо, которое передаётся транспорту
+type MailMessage struct {
+	To      string
+	Subject string
+	Text    string
+	// HTML — необязательная HTML-версия; при наличии письмо уходит как multipart/alternative
+	HTML string
+	// ReplyTo — адрес для ответа, например reply+... для ответа в чат по почте
+	ReplyTo string
+}
+
+// Mailer доставляет письма. Реализации: SMTP (STARTTLS), SMTPS (неявный TLS),
+// maildir на диске и MemoryMailer для тестов.
+type Mailer interface {
+	Send(msg MailMessage) error
+}
+
+// NewMailerFromEnv выбирает транспорт по MAIL_TRANSPORT: smtp, smtps, file, memory, disabled.
+// SMTP_DISABLED=true по-прежнему отключает отправку. Без MAIL_TRANSPORT используется smtp,
+// если задан SMTP_HOST, иначе отправка отключается с предупреждением — как до появления транспортов.
+func NewMailerFromEnv(logger *slog.Logger) (Mailer, error) {
+	transport := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_TRANSPORT")))
+	if os.Getenv("SMTP_DISABLED") == "true" {
+		transport = "disabled"
+	}
+	if transport == "" {
+		transport = "smtp"
+		if os.Getenv("SMTP_HOST") == "" || os.Getenv("SMTP_PORT") == "" {
+			logger.Warn("MAIL_TRANSPORT and SMTP_HOST are not set, emails will not be sent", "op", "service.NewMailerFromEnv")
+			transport = "disabled"
+		}
+	}
+
+	from := os.Getenv("SMTP_FROM")
+
+	switch transport {
+	case "smtp", "smtps":
+		cfg := smtpConfig{
+			host:        os.Getenv("SMTP_HOST"),
+			port:        os.Getenv("SMTP_PORT"),
+			user:        os.Getenv("SMTP_USER"),
+			pass:        os.Getenv("SMTP_PASS"),
+			from:        from,
+			implicitTLS: transport == "smtps",
+		}
+		if cfg.host == "" || cfg.port == "" {
+			return nil, fmt.Errorf("mail transport %q requires SMTP_HOST and SMTP_PORT", transport)
+		}
+		return newSMTPMailer(cfg), nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "tmp/mail"
+		}
+		return NewFileMailer(dir, from)
+	case "memory":
+		return NewMemoryMailer(), nil
+	case "disabled":
+		return disabledMailer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_TRANSPORT %q", transport)
+	}
+}
+
+// disabledMailer молча принимает письма — режим разработки без почтового сервера
+type disabledMailer struct{}
+
+func (disabledMailer) Send(MailMessage) error { return nil }
+
+// buildMessage собирает письмо в формате RFC 5322
+func buildMessage(from string, msg MailMessage) []byte {
+	var buf bytes.Buffer
+
+	writeHeader := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	writeHeader("From", from)
+	writeHeader("To", msg.To)
+	if msg.ReplyTo != "" {
+		writeHeader("Reply-To", msg.ReplyTo)
+	}
+	writeHeader("Subject", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	writeHeader("Message-ID", messageID(from))
+	writeHeader("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		writeHeader("Content-Type", "text/plain; charset=UTF-8")
+		buf.WriteString("\r\n")
+		buf.WriteString(normalizeCRLF(msg.Text))
+		buf.WriteString("\r\n")
+		return buf.Bytes()
+	}
+
+	mw := multipart.NewWriter(&buf)
+	writeHeader("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+	writePart(mw, "text/plain; charset=UTF-8", msg.Text)
+	writePart(mw, "text/html; charset=UTF-8", msg.HTML)
+	mw.Close()
+
+	return buf.Bytes()
+}
+
+// writePart пишет часть письма в quoted-printable, чтобы длинные строки HTML не ломали SMTP.
+// Ошибки записи в bytes.Buffer невозможны, поэтому не проверяются.
+func writePart(mw *multipart.Writer, contentType, body string) {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	part, _ := mw.CreatePart(header)
+	qp := quotedprintable.NewWriter(part)
+	qp.Write([]byte(normalizeCRLF(body)))
+	qp.Close()
+}
+
+func messageID(from string) string {
+	domain := "minijira.local"
+	if at := strings.LastIndex(from, "@"); at != -1 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
+
+func normalizeCRLF(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// fileMailer складывает письма в maildir: сначала в tmp/, затем атомарно переносит в new/
+type fileMailer struct {
+	dir     string
+	from    string
+	counter atomic.Uint64
+}
+
+func NewFileMailer(dir, from string) (Mailer, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("create maildir %s: %w", dir, err)
+		}
+	}
+	return &fileMailer{dir: dir, from: from}, nil
+}
+
+func (m *fileMailer) Send(msg MailMessage) error {
+	host, _ := os.Hostname()
+	name := fmt.Sprintf("%d.%d_%d.%s", time.Now().Unix(), os.Getpid(), m.counter.Add(1), host)
+
+	tmpPath := filepath.Join(m.dir, "tmp", name)
+	if err := os.WriteFile(tmpPath, buildMessage(m.from, msg), 0o644); err != nil {
+		return fmt.Errorf("write mail file: %w", err)
+	}
+	if err := os.Rename(tmpPath, filepath.Join(m.dir, "new", name)); err != nil {
+		return fmt.Errorf("move mail file: %w", err)
+	}
+	return nil
+}
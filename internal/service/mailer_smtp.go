@@ -0,0 +1,94 @@
+package service
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+)
+
+type smtpConfig struct {
+	host string
+	port string
+	user string
+	pass string
+	from string
+	// implicitTLS — TLS с первого байта (обычно порт 465) вместо STARTTLS
+	implicitTLS bool
+}
+
+type smtpMailer struct {
+	cfg smtpConfig
+}
+
+func newSMTPMailer(cfg smtpConfig) Mailer {
+	return &smtpMailer{cfg: cfg}
+}
+
+func (m *smtpMailer) Send(msg MailMessage) error {
+	addr := net.JoinHostPort(m.cfg.host, m.cfg.port)
+	tlsConfig := &tls.Config{
+		ServerName:         m.cfg.host,
+		InsecureSkipVerify: false,
+	}
+
+	var c *smtp.Client
+	if m.cfg.implicitTLS {
+		conn, err := tls.Dial("tcp", addr, tlsConfig)
+		if err != nil {
+			return fmt.Errorf("smtps dial failed: %w", err)
+		}
+		c, err = smtp.NewClient(conn, m.cfg.host)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("smtps handshake failed: %w", err)
+		}
+	} else {
+		// Используем стандартный smtp.Dial, который правильно обрабатывает приветствие сервера
+		// Это более надежный способ для Yandex SMTP
+		var err error
+		c, err = smtp.Dial(addr)
+		if err != nil {
+			return fmt.Errorf("smtp dial failed: %w", err)
+		}
+	}
+	defer c.Quit()
+
+	if !m.cfg.implicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server does not support STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls failed: %w", err)
+		}
+	}
+
+	if ok, _ := c.Extension("AUTH"); !ok {
+		return fmt.Errorf("server does not support AUTH")
+	}
+
+	auth := smtp.PlainAuth("", m.cfg.user, m.cfg.pass, m.cfg.host)
+	if err := c.Auth(auth); err != nil {
+		return fmt.Errorf("smtp auth failed: %w", err)
+	}
+
+	if err := c.Mail(m.cfg.from); err != nil {
+		return fmt.Errorf("MAIL FROM failed: %w", err)
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("RCPT TO failed: %w", err)
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("DATA open failed: %w", err)
+	}
+	if _, err := w.Write(buildMessage(m.cfg.from, msg)); err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("send failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import "testing"
+
+func TestNewMailerFromEnvWithoutTransport(t *testing.T) {
+	t.Setenv("SMTP_DISABLED", "")
+	t.Setenv("MAIL_TRANSPORT", "")
+	t.Setenv("SMTP_HOST", "")
+	t.Setenv("SMTP_PORT", "")
+
+	mailer, err := NewMailerFromEnv(newTestLogger())
+	if err != nil {
+		t.Fatalf("unset MAIL_TRANSPORT without SMTP_HOST must not fail startup: %v", err)
+	}
+	if _, ok := mailer.(disabledMailer); !ok {
+		t.Errorf("expected disabled mailer, got %T", mailer)
+	}
+
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", "587")
+	mailer, err = NewMailerFromEnv(newTestLogger())
+	if err != nil {
+		t.Fatalf("smtp from SMTP_HOST: %v", err)
+	}
+	if _, ok := mailer.(*smtpMailer); !ok {
+		t.Errorf("expected smtp mailer, got %T", mailer)
+	}
+}
+
+func TestNewMailerFromEnvExplicitSMTPRequiresHost(t *testing.T) {
+	t.Setenv("SMTP_DISABLED", "")
+	t.Setenv("MAIL_TRANSPORT", "smtps")
+	t.Setenv("SMTP_HOST", "")
+	t.Setenv("SMTP_PORT", "")
+
+	if _, err := NewMailerFromEnv(newTestLogger()); err == nil {
+		t.Error("explicit smtps without SMTP_HOST must fail")
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+type NotificationService interface {
+	Notify(event models.NotificationEvent) error
+	List(userID uint, filter models.NotificationFilter) ([]models.Notification, error)
+	MarkRead(id, userID uint) error
+	MarkAllRead(userID uint) (int64, error)
+	UnreadCount(userID uint) (int64, error)
+}
+
+type notificationService struct {
+	repo         repository.NotificationRepository
+	users        repository.UserRepository
+	settings     NotificationSettingsService
+	emailService *EmailService
+	logger       *slog.Logger
+}
+
+func NewNotificationService(repo repository.NotificationRepository, users repository.UserRepository, settings NotificationSettingsService, emailService *EmailService, logger *slog.Logger) NotificationService {
+	return &notificationService{repo: repo, users: users, settings: settings, emailService: emailService, logger: logger}
+}
+
+// Notify раскладывает событие получателям (кроме автора) по каналам из их настроек:
+// в ленту приложения, на почту и в сводку
+func (s *notificationService) Notify(event models.NotificationEvent) error {
+	var recipients []uint
+	for _, id := range event.RecipientIDs {
+		if id != 0 && id != event.ActorID && !slices.Contains(recipients, id) {
+			recipients = append(recipients, id)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	prefs, err := s.settings.Resolve(recipients)
+	if err != nil {
+		s.logger.Error("Notify: failed to load notification settings", "op", "service.notification.Notify", "type", event.Type, "err", err)
+		return err
+	}
+
+	notifications := make([]models.Notification, 0, len(recipients))
+	var emailRecipients []uint
+	for _, userID := range recipients {
+		p := prefs[userID]
+		inApp := p.Allows(event.Type, models.ChannelInApp, event.ProjectID)
+		digest := p.Allows(event.Type, models.ChannelDigest, event.ProjectID)
+		if p.Allows(event.Type, models.ChannelEmail, event.ProjectID) {
+			emailRecipients = append(emailRecipients, userID)
+		}
+		// Для сводки уведомление тоже сохраняется, но в ленте не показывается
+		if !inApp && !digest {
+			continue
+		}
+		notifications = append(notifications, models.Notification{
+			UserID:     userID,
+			ActorID:    event.ActorID,
+			Type:       event.Type,
+			Title:      event.Title,
+			Text:       event.Text,
+			EntityType: event.EntityType,
+			EntityID:   event.EntityID,
+			ProjectID:  event.ProjectID,
+			DigestOnly: !inApp,
+		})
+	}
+
+	if err := s.repo.CreateNotifications(notifications); err != nil {
+		s.logger.Error("Notify failed", "op", "service.notification.Notify", "type", event.Type, "err", err)
+		return err
+	}
+
+	s.logger.Info("Notify success", "op", "service.notification.Notify", "type", event.Type, "recipients", len(notifications), "email_recipients", len(emailRecipients))
+
+	s.sendEmails(event, emailRecipients, prefs)
+	return nil
+}
+
+// emailedNotificationTypes — события, для которых есть шаблон письма
+var emailedNotificationTypes = map[string]bool{
+	models.NotificationTaskAssigned: true,
+	models.NotificationChatMention:  true,
+	models.NotificationChatComment:  true,
+	models.NotificationChatReply:    true,
+}
+
+// sendEmails отправляет письма тем, кто выбрал почту для этого события; комментарии — только из чатов задач.
+// В тихие часы получателя письмо откладывается до их конца.
+// Ошибки только логируются: уведомления в приложении уже сохранены.
+func (s *notificationService) sendEmails(event models.NotificationEvent, recipients []uint, prefs map[uint]NotificationPrefs) {
+	if !emailedNotificationTypes[event.Type] || len(recipients) == 0 {
+		return
+	}
+	isComment := event.Type == models.NotificationChatComment || event.Type == models.NotificationChatReply
+	if isComment && event.EntityType != "tasks" {
+		return
+	}
+
+	users, err := s.users.GetUsersByIDs(append([]uint{event.ActorID}, recipients...))
+	if err != nil {
+		s.logger.Warn("failed to load notification recipients", "op", "service.notification.sendEmails", "type", event.Type, "err", err)
+		return
+	}
+
+	var actorName string
+	for _, u := range users {
+		if u.ID == event.ActorID {
+			actorName = u.FullName
+		}
+	}
+
+	now := time.Now()
+	for _, user := range users {
+		if !slices.Contains(recipients, user.ID) || user.Email == "" {
+			continue
+		}
+
+		emailService := s.emailService.DeferUntil(prefs[user.ID].QuietUntil(now))
+		var err error
+		switch event.Type {
+		case models.NotificationTaskAssigned:
+			link := fmt.Sprintf("%s/tasks/%d", AppBaseURL(), event.EntityID)
+			err = emailService.QueueAssignmentEmail(user, actorName, event.EntityName, link)
+		case models.NotificationChatMention:
+			err = emailService.QueueMentionEmail(user, actorName, event.Text, chatLink(event), chatReplyAddress(event, user.ID))
+		default:
+			err = emailService.QueueCommentEmail(user, CommentEmailData{
+				ActorName:  actorName,
+				EntityName: event.EntityName,
+				Excerpt:    event.Text,
+				Link:       chatLink(event),
+				IsReply:    event.Type == models.NotificationChatReply,
+			}, chatReplyAddress(event, user.ID))
+		}
+		if err != nil {
+			s.logger.Warn("failed to queue notification email", "op", "service.notification.sendEmails", "type", event.Type, "user_id", user.ID, "err", err)
+		}
+	}
+}
+
+func chatLink(event models.NotificationEvent) string {
+	return fmt.Sprintf("%s/chat/%s/%d/", AppBaseURL(), event.EntityType, event.EntityID)
+}
+
+// chatReplyAddress — персональный адрес для ответа по почте; у каждого получателя свой
+func chatReplyAddress(event models.NotificationEvent, userID uint) string {
+	return ReplyAddress(ReplyTarget{
+		ChatableType: event.EntityType,
+		ChatableID:   event.EntityID,
+		ThreadID:     event.ThreadID,
+		UserID:       userID,
+	})
+}
+
+func (s *notificationService) List(userID uint, filter models.NotificationFilter) ([]models.Notification, error) {
+	notifications, err := s.repo.ListByUser(userID, filter)
+	if err != nil {
+		s.logger.Error("ListNotifications failed", "user_id", userID, "err", err)
+		return nil, err
+	}
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+	return notifications, nil
+}
+
+func (s *notificationService) MarkRead(id, userID uint) error {
+	rows, err := s.repo.MarkRead(id, userID)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		s.logger.Warn("MarkRead: notification not found", "id", id, "user_id", userID)
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (s *notificationService) MarkAllRead(userID uint) (int64, error) {
+	return s.repo.MarkAllRead(userID)
+}
+
+func (s *notificationService) UnreadCount(userID uint) (int64, error) {
+	return s.repo.CountUnread(userID)
+}
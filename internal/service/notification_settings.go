@@ -0,0 +1,356 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidTimezone            = errors.New("invalid timezone")
+	ErrInvalidQuietHours          = errors.New("quiet hours must be HH:MM and start must differ from end")
+	ErrInvalidNotificationEvent   = errors.New("unknown notification event type")
+	ErrInvalidNotificationChannel = errors.New("channels must be in_app, email, digest or none alone")
+)
+
+// NotificationPrefs — итоговые настройки уведомлений пользователя с подставленными значениями по умолчанию
+type NotificationPrefs struct {
+	channels map[string][]string
+	location *time.Location
+	// quietStart/quietEnd — минуты от полуночи по часовому поясу пользователя
+	quietStart int
+	quietEnd   int
+	quiet      bool
+	muted      []uint
+}
+
+func defaultNotificationPrefs() NotificationPrefs {
+	return NotificationPrefs{channels: models.DefaultNotificationChannels, location: time.Local}
+}
+
+// Allows — нужно ли доставлять событие по каналу; заглушённый проект отключает все каналы
+func (p NotificationPrefs) Allows(eventType, channel string, projectID uint) bool {
+	if p.Muted(projectID) {
+		return false
+	}
+	return slices.Contains(p.channels[eventType], channel)
+}
+
+func (p NotificationPrefs) Muted(projectID uint) bool {
+	return projectID != 0 && slices.Contains(p.muted, projectID)
+}
+
+// Location — часовой пояс пользователя, по умолчанию время сервера
+func (p NotificationPrefs) Location() *time.Location {
+	return p.location
+}
+
+// QuietUntil возвращает конец тихих часов, если now в них попадает, иначе нулевое время
+func (p NotificationPrefs) QuietUntil(now time.Time) time.Time {
+	if !p.quiet {
+		return time.Time{}
+	}
+
+	local := now.In(p.location)
+	minute := local.Hour()*60 + local.Minute()
+
+	var inside bool
+	if p.quietStart < p.quietEnd {
+		inside = minute >= p.quietStart && minute < p.quietEnd
+	} else {
+		// Интервал через полночь, например 22:00–08:00
+		inside = minute >= p.quietStart || minute < p.quietEnd
+	}
+	if !inside {
+		return time.Time{}
+	}
+
+	end := time.Date(local.Year(), local.Month(), local.Day(), p.quietEnd/60, p.quietEnd%60, 0, 0, p.location)
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+type NotificationSettingsService interface {
+	Get(userID uint) (models.NotificationSettingsResponse, error)
+	Update(userID uint, req models.NotificationSettingsUpdateReq) (models.NotificationSettingsResponse, error)
+	// Resolve загружает настройки сразу для нескольких получателей
+	Resolve(userIDs []uint) (map[uint]NotificationPrefs, error)
+}
+
+type notificationSettingsService struct {
+	db     *gorm.DB
+	repo   repository.NotificationSettingsRepository
+	logger *slog.Logger
+	// digestHour — час рассылки сводки, как у digestService
+	digestHour int
+}
+
+func NewNotificationSettingsService(db *gorm.DB, repo repository.NotificationSettingsRepository, logger *slog.Logger) NotificationSettingsService {
+	return &notificationSettingsService{db: db, repo: repo, logger: logger, digestHour: digestHour()}
+}
+
+func (s *notificationSettingsService) Get(userID uint) (models.NotificationSettingsResponse, error) {
+	settings, err := s.repo.GetSettings([]uint{userID})
+	if err != nil {
+		return models.NotificationSettingsResponse{}, err
+	}
+	prefs, err := s.repo.GetPreferences([]uint{userID})
+	if err != nil {
+		return models.NotificationSettingsResponse{}, err
+	}
+	mutes, err := s.repo.GetMutes([]uint{userID})
+	if err != nil {
+		return models.NotificationSettingsResponse{}, err
+	}
+	frequency, err := s.repo.GetDigestFrequency(userID)
+	if err != nil {
+		return models.NotificationSettingsResponse{}, err
+	}
+
+	resp := models.NotificationSettingsResponse{
+		Events:          mergeChannels(prefs),
+		MutedProjectIDs: []uint{},
+		DigestFrequency: frequency,
+	}
+	if len(settings) > 0 {
+		resp.Timezone = settings[0].Timezone
+		if settings[0].QuietHoursStart != "" {
+			resp.QuietHours = &models.QuietHours{Start: settings[0].QuietHoursStart, End: settings[0].QuietHoursEnd}
+		}
+	}
+	for _, m := range mutes {
+		resp.MutedProjectIDs = append(resp.MutedProjectIDs, m.ProjectID)
+	}
+	return resp, nil
+}
+
+func (s *notificationSettingsService) Update(userID uint, req models.NotificationSettingsUpdateReq) (models.NotificationSettingsResponse, error) {
+	var prefs []models.NotificationPreference
+	for eventType, channels := range req.Events {
+		if !slices.Contains(models.NotificationEventTypes, eventType) {
+			return models.NotificationSettingsResponse{}, fmt.Errorf("%w: %s", ErrInvalidNotificationEvent, eventType)
+		}
+		normalized, err := normalizeChannels(channels)
+		if err != nil {
+			return models.NotificationSettingsResponse{}, err
+		}
+		prefs = append(prefs, models.NotificationPreference{UserID: userID, EventType: eventType, Channels: normalized})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithDB(tx)
+
+		settings := models.NotificationSettings{UserID: userID}
+		if req.Timezone != nil || req.QuietHours != nil || req.DigestFrequency != nil {
+			if current, err := repo.GetSettings([]uint{userID}); err != nil {
+				return err
+			} else if len(current) > 0 {
+				settings = current[0]
+			}
+		}
+
+		if req.Timezone != nil || req.QuietHours != nil {
+
+			if req.Timezone != nil {
+				if _, err := loadTimezone(*req.Timezone); err != nil {
+					return err
+				}
+				settings.Timezone = *req.Timezone
+			}
+			if req.QuietHours != nil {
+				start, end, err := validateQuietHours(*req.QuietHours)
+				if err != nil {
+					return err
+				}
+				settings.QuietHoursStart, settings.QuietHoursEnd = start, end
+			}
+
+			if err := repo.SaveSettings(&settings); err != nil {
+				return err
+			}
+		}
+
+		if err := repo.SavePreferences(prefs); err != nil {
+			return err
+		}
+
+		if req.MutedProjectIDs != nil {
+			if err := repo.ReplaceMutes(userID, *req.MutedProjectIDs); err != nil {
+				return err
+			}
+		}
+
+		if req.DigestFrequency != nil {
+			if err := s.updateDigestFrequency(repo, userID, *req.DigestFrequency, settings.Timezone); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Warn("UpdateNotificationSettings failed", "op", "service.notificationSettings.Update", "user_id", userID, "err", err)
+		return models.NotificationSettingsResponse{}, err
+	}
+
+	s.logger.Info("UpdateNotificationSettings success", "op", "service.notificationSettings.Update", "user_id", userID)
+	return s.Get(userID)
+}
+
+// updateDigestFrequency при смене частоты начинает расписание заново с текущего слота:
+// иначе после none → daily старая отметка отправила бы сводку сразу, за всё время отключения
+func (s *notificationSettingsService) updateDigestFrequency(repo repository.NotificationSettingsRepository, userID uint, frequency, timezone string) error {
+	current, err := repo.GetDigestFrequency(userID)
+	if err != nil {
+		return err
+	}
+	if current == frequency {
+		return nil
+	}
+
+	var lastSentAt *time.Time
+	if frequency != models.DigestNone {
+		// Сохранённый пояс мог исчезнуть из tzdata — тогда слот считается по времени сервера
+		loc, err := loadTimezone(timezone)
+		if err != nil {
+			loc = time.Local
+		}
+		slot := digestSlot(time.Now().In(loc), frequency, s.digestHour)
+		lastSentAt = &slot
+	}
+	return repo.SetDigestFrequency(userID, frequency, lastSentAt)
+}
+
+func (s *notificationSettingsService) Resolve(userIDs []uint) (map[uint]NotificationPrefs, error) {
+	result := make(map[uint]NotificationPrefs, len(userIDs))
+	for _, id := range userIDs {
+		result[id] = defaultNotificationPrefs()
+	}
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	settings, err := s.repo.GetSettings(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := s.repo.GetPreferences(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	mutes, err := s.repo.GetMutes(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	prefsByUser := make(map[uint][]models.NotificationPreference)
+	for _, p := range prefs {
+		prefsByUser[p.UserID] = append(prefsByUser[p.UserID], p)
+	}
+	for userID, userPrefs := range prefsByUser {
+		p := result[userID]
+		p.channels = mergeChannels(userPrefs)
+		result[userID] = p
+	}
+
+	for _, m := range mutes {
+		p := result[m.UserID]
+		p.muted = append(p.muted, m.ProjectID)
+		result[m.UserID] = p
+	}
+
+	for _, st := range settings {
+		p := result[st.UserID]
+		// Сохранённый пояс мог исчезнуть из tzdata — тогда остаётся время сервера
+		if loc, err := loadTimezone(st.Timezone); err == nil {
+			p.location = loc
+		}
+		if start, ok := parseClock(st.QuietHoursStart); ok {
+			if end, ok := parseClock(st.QuietHoursEnd); ok && start != end {
+				p.quiet, p.quietStart, p.quietEnd = true, start, end
+			}
+		}
+		result[st.UserID] = p
+	}
+
+	return result, nil
+}
+
+// mergeChannels накладывает сохранённые настройки на значения по умолчанию
+func mergeChannels(prefs []models.NotificationPreference) map[string][]string {
+	channels := make(map[string][]string, len(models.NotificationEventTypes))
+	for _, eventType := range models.NotificationEventTypes {
+		channels[eventType] = models.DefaultNotificationChannels[eventType]
+	}
+	for _, p := range prefs {
+		if p.Channels == "" {
+			channels[p.EventType] = []string{}
+			continue
+		}
+		channels[p.EventType] = strings.Split(p.Channels, ",")
+	}
+	return channels
+}
+
+// normalizeChannels проверяет список каналов и сохраняет его в фиксированном порядке; «none» — пустая строка
+func normalizeChannels(channels []string) (string, error) {
+	if slices.Contains(channels, models.ChannelNone) {
+		if len(channels) != 1 {
+			return "", ErrInvalidNotificationChannel
+		}
+		return "", nil
+	}
+
+	var result []string
+	for _, c := range []string{models.ChannelInApp, models.ChannelEmail, models.ChannelDigest} {
+		if slices.Contains(channels, c) {
+			result = append(result, c)
+		}
+	}
+	for _, c := range channels {
+		if !slices.Contains(result, c) {
+			return "", ErrInvalidNotificationChannel
+		}
+	}
+	return strings.Join(result, ","), nil
+}
+
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+func validateQuietHours(q models.QuietHours) (string, string, error) {
+	if q.Start == "" && q.End == "" {
+		return "", "", nil
+	}
+	start, okStart := parseClock(q.Start)
+	end, okEnd := parseClock(q.End)
+	if !okStart || !okEnd || start == end {
+		return "", "", ErrInvalidQuietHours
+	}
+	return q.Start, q.End, nil
+}
+
+// parseClock разбирает HH:MM в минуты от полуночи
+func parseClock(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
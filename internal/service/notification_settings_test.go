@@ -0,0 +1,82 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type fakeNotificationSettingsRepo struct {
+	repository.NotificationSettingsRepository
+	settings   []models.NotificationSettings
+	frequency  string
+	lastSentAt *time.Time
+	updates    int
+}
+
+func (r *fakeNotificationSettingsRepo) WithDB(*gorm.DB) repository.NotificationSettingsRepository {
+	return r
+}
+
+func (r *fakeNotificationSettingsRepo) GetSettings([]uint) ([]models.NotificationSettings, error) {
+	return r.settings, nil
+}
+
+func (r *fakeNotificationSettingsRepo) GetPreferences([]uint) ([]models.NotificationPreference, error) {
+	return nil, nil
+}
+
+func (r *fakeNotificationSettingsRepo) GetMutes([]uint) ([]models.ProjectMute, error) {
+	return nil, nil
+}
+
+func (r *fakeNotificationSettingsRepo) SavePreferences([]models.NotificationPreference) error {
+	return nil
+}
+
+func (r *fakeNotificationSettingsRepo) GetDigestFrequency(uint) (string, error) {
+	return r.frequency, nil
+}
+
+func (r *fakeNotificationSettingsRepo) SetDigestFrequency(_ uint, frequency string, lastSentAt *time.Time) error {
+	r.frequency, r.lastSentAt = frequency, lastSentAt
+	r.updates++
+	return nil
+}
+
+func TestDigestFrequencyChangeRestartsSchedule(t *testing.T) {
+	stale := time.Now().AddDate(0, -3, 0)
+	repo := &fakeNotificationSettingsRepo{
+		settings:   []models.NotificationSettings{{UserID: 7, Timezone: "Europe/Moscow"}},
+		frequency:  models.DigestNone,
+		lastSentAt: &stale,
+	}
+	svc := NewNotificationSettingsService(newTestDB(t), repo, newTestLogger())
+
+	weekly := models.DigestWeekly
+	resp, err := svc.Update(7, models.NotificationSettingsUpdateReq{DigestFrequency: &weekly})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if resp.DigestFrequency != models.DigestWeekly {
+		t.Fatalf("response frequency = %q, want weekly", resp.DigestFrequency)
+	}
+
+	// Отметка встаёт на текущий слот, а не остаётся трёхмесячной: иначе сводка ушла бы сразу
+	loc, _ := time.LoadLocation("Europe/Moscow")
+	want := digestSlot(time.Now().In(loc), models.DigestWeekly, digestHour())
+	if repo.lastSentAt == nil || !repo.lastSentAt.Equal(want) {
+		t.Fatalf("last sent at = %v, want %v", repo.lastSentAt, want)
+	}
+
+	// Та же частота ничего не сбрасывает
+	if _, err := svc.Update(7, models.NotificationSettingsUpdateReq{DigestFrequency: &weekly}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if repo.updates != 1 {
+		t.Fatalf("unchanged frequency must not touch the schedule, got %d updates", repo.updates)
+	}
+}
@@ -23,10 +23,11 @@ type projectService struct {
 	db     *gorm.DB
 	logger *slog.Logger
 	repo   repository.ProjectRepository
+	events EventPublisher
 }
 
-func NewProjectService(db *gorm.DB, logger *slog.Logger, repo repository.ProjectRepository) ProjectService {
-	return &projectService{db: db, logger: logger, repo: repo}
+func NewProjectService(db *gorm.DB, logger *slog.Logger, repo repository.ProjectRepository, events EventPublisher) ProjectService {
+	return &projectService{db: db, logger: logger, repo: repo, events: events}
 }
 
 func (s *projectService) Create(req *models.ProjectCreateReq) (*models.ProjectCreateResponse, error) {
@@ -137,5 +138,13 @@ func (s *projectService) UpdateProject(id uint, req models.ProjectUpdReq) error
 	}
 
 	s.logger.Info("update project by id successful", "op", "service.project.updateProject", "id", id)
+
+	if req.Status != nil && strings.EqualFold(*req.Status, "done") && !strings.EqualFold(project.Status, "done") {
+		title := project.Title
+		if req.Title != nil {
+			title = *req.Title
+		}
+		s.events.Publish(projectCompletedEvent(id, title))
+	}
 	return nil
 }
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const replyAddressPrefix = "reply+"
+
+var ErrReplyTokenInvalid = errors.New("invalid reply address")
+
+// ReplyTarget — куда попадёт ответ на письмо: чат, тред и получатель письма
+type ReplyTarget struct {
+	ChatableType string
+	ChatableID   uint
+	// ThreadID — корневое сообщение треда, 0 — ответ уходит в чат верхним уровнем
+	ThreadID uint
+	UserID   uint
+}
+
+// replyConfig читает REPLY_DOMAIN и секрет подписи (REPLY_SECRET, по умолчанию JWT_SECRET).
+// Без домена ответ по почте выключен.
+func replyConfig() (domain string, secret []byte, ok bool) {
+	domain = os.Getenv("REPLY_DOMAIN")
+	key := os.Getenv("REPLY_SECRET")
+	if key == "" {
+		key = os.Getenv("JWT_SECRET")
+	}
+	return domain, []byte(key), domain != "" && key != ""
+}
+
+// ReplyAddress строит адрес вида reply+tasks.12.40.7.<подпись>@REPLY_DOMAIN.
+// Возвращает пустую строку, если ответ по почте не настроен.
+func ReplyAddress(target ReplyTarget) string {
+	domain, secret, ok := replyConfig()
+	if !ok {
+		return ""
+	}
+
+	payload := fmt.Sprintf("%s.%d.%d.%d", target.ChatableType, target.ChatableID, target.ThreadID, target.UserID)
+	return replyAddressPrefix + payload + "." + signReplyPayload(secret, payload) + "@" + domain
+}
+
+// ParseReplyAddress проверяет подпись и домен адреса и достаёт из него ReplyTarget
+func ParseReplyAddress(address string) (ReplyTarget, error) {
+	domain, secret, ok := replyConfig()
+	if !ok {
+		return ReplyTarget{}, ErrReplyTokenInvalid
+	}
+
+	local, host, found := strings.Cut(strings.ToLower(strings.TrimSpace(address)), "@")
+	if !found || host != strings.ToLower(domain) || !strings.HasPrefix(local, replyAddressPrefix) {
+		return ReplyTarget{}, ErrReplyTokenInvalid
+	}
+
+	parts := strings.Split(strings.TrimPrefix(local, replyAddressPrefix), ".")
+	if len(parts) != 5 {
+		return ReplyTarget{}, ErrReplyTokenInvalid
+	}
+
+	payload := strings.Join(parts[:4], ".")
+	if !hmac.Equal([]byte(parts[4]), []byte(signReplyPayload(secret, payload))) {
+		return ReplyTarget{}, ErrReplyTokenInvalid
+	}
+
+	ids := make([]uint, 3)
+	for i, p := range parts[1:4] {
+		v, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return ReplyTarget{}, ErrReplyTokenInvalid
+		}
+		ids[i] = uint(v)
+	}
+
+	target := ReplyTarget{ChatableType: parts[0], ChatableID: ids[0], ThreadID: ids[1], UserID: ids[2]}
+	if !IsValidChatType(target.ChatableType) || target.ChatableID == 0 || target.UserID == 0 {
+		return ReplyTarget{}, ErrReplyTokenInvalid
+	}
+	return target, nil
+}
+
+// signReplyPayload — укороченный HMAC-SHA256: адрес должен помещаться в 64 символа локальной части
+func signReplyPayload(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
@@ -3,7 +3,6 @@ package service
 import (
 	"back-minijira-petproject1/internal/models"
 	"back-minijira-petproject1/internal/repository"
-	"fmt"
 	"log/slog"
 	"time"
 )
@@ -13,15 +12,25 @@ type ReportService interface {
 	AverageTime(projectID uint) (models.AvgTimeDTO, error)
 	CompletionPercent(projectID uint) (models.CompletionPercentDTO, error)
 	UserTracker(projectID uint, userID uint) (models.UserTrackerDTO, error)
+	Burndown(projectID uint, query models.BurndownQuery) (models.BurndownReport, error)
+	CumulativeFlow(projectID uint, query models.ReportRangeQuery) (models.CumulativeFlowReport, error)
+	CycleTime(projectID uint, query models.CycleTimeQuery) (models.CycleTimeReport, error)
+	Throughput(projectID uint, query models.ThroughputQuery) (models.ThroughputReport, error)
+	ThroughputAll(query models.ThroughputQuery) (models.ThroughputReport, error)
+	Portfolio() (models.PortfolioReport, error)
+	Workload(query models.WorkloadQuery) (models.WorkloadReport, error)
+	ProjectTime(projectID uint, query models.TimeReportQuery) (models.ProjectTimeReport, error)
+	Time(query models.TimeReportQuery) (models.TimeReport, error)
+	Team(teamID uint, query models.ThroughputQuery) (models.TeamReport, error)
 }
 
 type reportService struct {
 	repo repository.ReportRepository
-	logger slog.Logger
+	logger *slog.Logger
 }
 
 func NewReportService(report repository.ReportRepository, logger *slog.Logger) ReportService {
-	return &reportService{repo: report}
+	return &reportService{repo: report, logger: logger}
 }
 
 func (s *reportService) TopWorkers(projectID uint) ([]models.WorkerStats, error) {
@@ -50,7 +59,7 @@ func (s *reportService) AverageTime(projectID uint) (models.AvgTimeDTO, error) {
 		TasksCount:     len(tasks),
 		CompletedCount: len(tasks),
 		AverageSeconds: int64(avg.Seconds()),
-		AverageHuman:   avg.Round(time.Second).String(),
+		AverageHuman:   formatDuration(avg),
 	}, nil
 }
 
@@ -102,13 +111,28 @@ func (s *reportService) UserTracker(projectID uint, userID uint) (models.UserTra
 	}
 
 	tracker.TotalTimeSeconds = sum
-	tracker.TotalTimeHuman = fmt.Sprintf("%ds", sum)
+	tracker.TotalTimeHuman = formatDuration(time.Duration(sum) * time.Second)
 
 	if doneCount > 0 {
 		avg := sum / doneCount
 		tracker.AverageTimeSeconds = avg
-		tracker.AverageTimeHuman = fmt.Sprintf("%ds", avg)
+		tracker.AverageTimeHuman = formatDuration(time.Duration(avg) * time.Second)
 	}
 
+	// Записанное время — за всю жизнь проекта, включая запущенный таймер
+	now := time.Now()
+	logged, err := s.repo.GetLoggedTime(models.LoggedTimeFilter{
+		ProjectID: &projectID, UserID: &userID, From: time.Time{}, To: now, Now: now,
+	})
+	if err != nil {
+		return models.UserTrackerDTO{}, err
+	}
+	var loggedSeconds float64
+	for _, row := range logged {
+		loggedSeconds += row.Seconds
+	}
+	tracker.LoggedTimeSeconds = int64(loggedSeconds)
+	tracker.LoggedTimeHuman = formatDuration(time.Duration(loggedSeconds) * time.Second)
+
 	return tracker, nil
 }
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"time"
+)
+
+func (s *reportService) Burndown(projectID uint, query models.BurndownQuery) (models.BurndownReport, error) {
+	project, err := s.project(projectID)
+	if err != nil {
+		return models.BurndownReport{}, err
+	}
+
+	unit := query.Unit
+	switch unit {
+	case "":
+		hasEstimates, err := s.repo.HasEstimates(projectID)
+		if err != nil {
+			return models.BurndownReport{}, err
+		}
+		unit = models.ReportUnitCount
+		if hasEstimates {
+			unit = models.ReportUnitEstimate
+		}
+	case models.ReportUnitCount, models.ReportUnitEstimate:
+	default:
+		return models.BurndownReport{}, ErrInvalidReportUnit
+	}
+
+	// По умолчанию график тянется до дедлайна проекта, чтобы была видна идеальная линия
+	today := truncateDay(time.Now())
+	defaultTo := today
+	if project.TimeEnd != nil {
+		defaultTo = truncateDay(*project.TimeEnd)
+	}
+	from, to, interval, err := resolveReportRange(query.ReportRangeQuery, truncateDay(project.CreatedAt), defaultTo)
+	if err != nil {
+		return models.BurndownReport{}, err
+	}
+
+	rows, err := s.repo.GetBurndown(projectID, from, to, interval)
+	if err != nil {
+		return models.BurndownReport{}, err
+	}
+
+	report := models.BurndownReport{
+		ProjectID: projectID,
+		From:      from.Format(reportDateLayout),
+		To:        to.Format(reportDateLayout),
+		Interval:  interval,
+		Unit:      unit,
+		TimeEnd:   project.TimeEnd,
+		Points:    make([]models.BurndownPoint, 0, len(rows)),
+	}
+
+	// Идеальная линия идёт от остатка на первую дату к нулю на дату окончания проекта
+	var idealStart float64
+	if len(rows) > 0 {
+		scope, completed := burndownValues(rows[0], unit)
+		idealStart = scope - completed
+	}
+	var deadline *time.Time
+	if project.TimeEnd != nil {
+		if end := truncateDay(*project.TimeEnd); end.After(from) {
+			deadline = &end
+		}
+	}
+
+	for _, row := range rows {
+		date := row.Date.UTC()
+		point := models.BurndownPoint{Date: date.Format(reportDateLayout)}
+
+		if !date.After(today) {
+			scope, completed := burndownValues(row, unit)
+			remaining := scope - completed
+			point.Scope = &scope
+			point.Completed = &completed
+			point.Remaining = &remaining
+		}
+
+		if deadline != nil {
+			ideal := 0.0
+			if date.Before(*deadline) {
+				progress := float64(date.Sub(from)) / float64(deadline.Sub(from))
+				ideal = roundReport(idealStart * (1 - progress))
+			}
+			point.Ideal = &ideal
+		}
+
+		report.Points = append(report.Points, point)
+	}
+
+	return report, nil
+}
+
+func burndownValues(row models.BurndownRow, unit string) (scope, completed float64) {
+	if unit == models.ReportUnitEstimate {
+		return roundReport(row.ScopeEstimate), roundReport(row.DoneEstimate)
+	}
+	return float64(row.ScopeCount), float64(row.DoneCount)
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"math"
+	"slices"
+	"time"
+)
+
+type durationBucket struct {
+	label string
+	// upTo — верхняя граница, не включая её; 0 у последней корзины
+	upTo time.Duration
+}
+
+// durationBuckets — границы гистограммы. Они растут нелинейно: длительности задач
+// распределены с длинным хвостом, и равные корзины свели бы почти всё в первую.
+var durationBuckets = []durationBucket{
+	{"< 1h", time.Hour},
+	{"1h–4h", 4 * time.Hour},
+	{"4h–1d", 24 * time.Hour},
+	{"1d–2d", 2 * 24 * time.Hour},
+	{"2d–3d", 3 * 24 * time.Hour},
+	{"3d–1w", 7 * 24 * time.Hour},
+	{"1w–2w", 14 * 24 * time.Hour},
+	{"2w–4w", 28 * 24 * time.Hour},
+	{"≥ 4w", 0},
+}
+
+func (s *reportService) CycleTime(projectID uint, query models.CycleTimeQuery) (models.CycleTimeReport, error) {
+	project, err := s.project(projectID)
+	if err != nil {
+		return models.CycleTimeReport{}, err
+	}
+
+	from, err := parseReportDate(query.From, truncateDay(project.CreatedAt))
+	if err != nil {
+		return models.CycleTimeReport{}, err
+	}
+	to, err := parseReportDate(query.To, truncateDay(time.Now()))
+	if err != nil {
+		return models.CycleTimeReport{}, err
+	}
+	if to.Before(from) {
+		return models.CycleTimeReport{}, ErrInvalidReportRange
+	}
+
+	var assigneeIDs []uint
+	if query.AssigneeID != nil {
+		assigneeIDs = []uint{*query.AssigneeID}
+	}
+	// to включительно: задачи, завершённые в этот день, попадают в отчёт
+	rows, err := s.repo.GetCompletedTasks(projectID, from, to.AddDate(0, 0, 1), query.Priority, assigneeIDs)
+	if err != nil {
+		return models.CycleTimeReport{}, err
+	}
+	stats, reopened := cycleTimeStats(rows)
+
+	return models.CycleTimeReport{
+		ProjectID:      projectID,
+		From:           from.Format(reportDateLayout),
+		To:             to.Format(reportDateLayout),
+		Priority:       query.Priority,
+		AssigneeID:     query.AssigneeID,
+		CycleTimeStats: stats,
+		Reopened:       reopened,
+	}, nil
+}
+
+// cycleTimeStats делит закрытые задачи на обычные и переоткрытые и считает распределения для каждой группы
+func cycleTimeStats(rows []models.CompletedTaskRow) (models.CycleTimeStats, models.CycleTimeStats) {
+	var cycle, lead, reopenedCycle, reopenedLead []time.Duration
+	for _, row := range rows {
+		leadTime := row.DoneAt.Sub(row.CreatedAt)
+		var cycleTime *time.Duration
+		if row.StartedAt != nil && !row.StartedAt.After(row.DoneAt) {
+			d := row.DoneAt.Sub(*row.StartedAt)
+			cycleTime = &d
+		}
+
+		if row.Reopened {
+			reopenedLead = append(reopenedLead, leadTime)
+			if cycleTime != nil {
+				reopenedCycle = append(reopenedCycle, *cycleTime)
+			}
+			continue
+		}
+		lead = append(lead, leadTime)
+		if cycleTime != nil {
+			cycle = append(cycle, *cycleTime)
+		}
+	}
+
+	return models.CycleTimeStats{
+		CycleTime: durationStats(cycle),
+		LeadTime:  durationStats(lead),
+	}, models.CycleTimeStats{
+		CycleTime: durationStats(reopenedCycle),
+		LeadTime:  durationStats(reopenedLead),
+	}
+}
+
+func durationStats(durations []time.Duration) models.DurationStats {
+	stats := models.DurationStats{
+		Count:     len(durations),
+		Histogram: make([]models.HistogramBucket, len(durationBuckets)),
+	}
+	for i, b := range durationBuckets {
+		stats.Histogram[i].Label = b.label
+		if b.upTo > 0 {
+			upTo := int64(b.upTo.Seconds())
+			stats.Histogram[i].UpToSeconds = &upTo
+		}
+	}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+		i := slices.IndexFunc(durationBuckets, func(b durationBucket) bool {
+			return b.upTo == 0 || d < b.upTo
+		})
+		stats.Histogram[i].Count++
+	}
+
+	p50, p85 := percentile(sorted, 50), percentile(sorted, 85)
+	stats.MinSeconds = int64(sorted[0].Seconds())
+	stats.MaxSeconds = int64(sorted[len(sorted)-1].Seconds())
+	stats.MeanSeconds = int64((total / time.Duration(len(sorted))).Seconds())
+	stats.P50Seconds = int64(p50.Seconds())
+	stats.P75Seconds = int64(percentile(sorted, 75).Seconds())
+	stats.P85Seconds = int64(p85.Seconds())
+	stats.P95Seconds = int64(percentile(sorted, 95).Seconds())
+	stats.P50Human = formatDuration(p50)
+	stats.P85Human = formatDuration(p85)
+	return stats
+}
+
+// percentile — перцентиль с линейной интерполяцией между соседними рангами,
+// как percentile_cont в Postgres. sorted должен быть отсортирован по возрастанию.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	frac := rank - float64(lower)
+	return sorted[lower] + time.Duration(frac*float64(sorted[upper]-sorted[lower]))
+}
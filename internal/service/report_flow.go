@@ -0,0 +1,68 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"slices"
+	"time"
+)
+
+func (s *reportService) CumulativeFlow(projectID uint, query models.ReportRangeQuery) (models.CumulativeFlowReport, error) {
+	project, err := s.project(projectID)
+	if err != nil {
+		return models.CumulativeFlowReport{}, err
+	}
+
+	today := truncateDay(time.Now())
+	from, to, interval, err := resolveReportRange(query, truncateDay(project.CreatedAt), today)
+	if err != nil {
+		return models.CumulativeFlowReport{}, err
+	}
+	// Будущее ещё не наступило — ряд обрывается на сегодняшнем дне
+	if to.After(today) {
+		to = today
+	}
+
+	report := models.CumulativeFlowReport{
+		ProjectID: projectID,
+		From:      from.Format(reportDateLayout),
+		To:        to.Format(reportDateLayout),
+		Interval:  interval,
+		Statuses:  slices.Clone(models.WorkflowStatuses),
+		Points:    []models.CumulativeFlowPoint{},
+	}
+	if to.Before(from) {
+		return report, nil
+	}
+
+	rows, err := s.repo.GetCumulativeFlow(projectID, from, to, interval)
+	if err != nil {
+		return models.CumulativeFlowReport{}, err
+	}
+
+	byDate := make(map[string]*models.CumulativeFlowPoint)
+	for date := from; !date.After(to); date = nextReportBucket(date, interval) {
+		point := models.CumulativeFlowPoint{Date: date.Format(reportDateLayout), Counts: make(map[string]int)}
+		for _, status := range models.WorkflowStatuses {
+			point.Counts[status] = 0
+		}
+		report.Points = append(report.Points, point)
+	}
+	for i := range report.Points {
+		byDate[report.Points[i].Date] = &report.Points[i]
+	}
+
+	for _, row := range rows {
+		point, ok := byDate[row.Date.UTC().Format(reportDateLayout)]
+		if !ok {
+			continue
+		}
+		// Статусы вне процесса (например, из старых данных) тоже показываем, чтобы сумма сходилась
+		if !slices.Contains(report.Statuses, row.Status) {
+			report.Statuses = append(report.Statuses, row.Status)
+		}
+		point.Counts[row.Status] += row.Count
+		point.Total += row.Count
+	}
+
+	return report, nil
+}
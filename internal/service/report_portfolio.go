@@ -0,0 +1,104 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Пороги оценки здоровья проекта
+const (
+	// portfolioLagPercent — насколько процент выполнения может отставать от прошедшего срока
+	portfolioLagPercent = 20.0
+	// portfolioOverdueShare — доля просроченных задач, при которой проект считается сорванным
+	portfolioOverdueShare = 0.25
+	// portfolioDeadlineDays — «скоро дедлайн»: в эти дни проект должен быть почти готов
+	portfolioDeadlineDays = 7
+	portfolioNearlyDone   = 80.0
+)
+
+func (s *reportService) Portfolio() (models.PortfolioReport, error) {
+	now := time.Now()
+	rows, err := s.repo.GetPortfolio(now)
+	if err != nil {
+		return models.PortfolioReport{}, err
+	}
+
+	report := models.PortfolioReport{
+		GeneratedAt: now,
+		Totals: models.PortfolioTotals{
+			Projects: len(rows),
+			Health: map[string]int{
+				models.HealthOnTrack:  0,
+				models.HealthAtRisk:   0,
+				models.HealthOffTrack: 0,
+				models.HealthDone:     0,
+			},
+		},
+		Projects: make([]models.PortfolioProject, 0, len(rows)),
+	}
+
+	today := truncateDay(now)
+	for _, row := range rows {
+		project := models.PortfolioProject{
+			ProjectID:       row.ProjectID,
+			Title:           row.Title,
+			Status:          row.Status,
+			TimeEnd:         row.TimeEnd,
+			TotalTasks:      row.TotalTasks,
+			DoneTasks:       row.DoneTasks,
+			InProgressTasks: row.InProgressTasks,
+			OverdueTasks:    row.OverdueTasks,
+		}
+		if row.TotalTasks > 0 {
+			project.CompletionPercent = roundReport(float64(row.DoneTasks) / float64(row.TotalTasks) * 100)
+		}
+		if row.TimeEnd != nil {
+			end := truncateDay(*row.TimeEnd)
+			daysLeft := int(end.Sub(today).Hours() / 24)
+			project.DaysLeft = &daysLeft
+
+			if lifetime := end.Sub(truncateDay(row.CreatedAt)); lifetime > 0 {
+				elapsed := math.Min(math.Max(float64(today.Sub(truncateDay(row.CreatedAt)))/float64(lifetime), 0), 1)
+				expected := roundReport(elapsed * 100)
+				project.ExpectedPercent = &expected
+			}
+		}
+		project.Health, project.HealthReason = projectHealth(project)
+
+		report.Totals.Health[project.Health]++
+		report.Totals.InProgressTasks += row.InProgressTasks
+		report.Totals.OverdueTasks += row.OverdueTasks
+		report.Projects = append(report.Projects, project)
+	}
+
+	return report, nil
+}
+
+// projectHealth оценивает проект по срокам и просрочкам и объясняет оценку одной фразой
+func projectHealth(p models.PortfolioProject) (string, string) {
+	if strings.EqualFold(p.Status, "done") || (p.TotalTasks > 0 && p.DoneTasks == p.TotalTasks) {
+		return models.HealthDone, "all tasks are done"
+	}
+
+	if p.DaysLeft != nil && *p.DaysLeft < 0 {
+		return models.HealthOffTrack, fmt.Sprintf("deadline passed %d days ago", -*p.DaysLeft)
+	}
+	if p.TotalTasks > 0 && float64(p.OverdueTasks)/float64(p.TotalTasks) >= portfolioOverdueShare {
+		return models.HealthOffTrack, fmt.Sprintf("%d of %d tasks are overdue", p.OverdueTasks, p.TotalTasks)
+	}
+
+	if p.OverdueTasks > 0 {
+		return models.HealthAtRisk, fmt.Sprintf("%d tasks are overdue", p.OverdueTasks)
+	}
+	if p.DaysLeft != nil && *p.DaysLeft <= portfolioDeadlineDays && p.CompletionPercent < portfolioNearlyDone {
+		return models.HealthAtRisk, fmt.Sprintf("%d days left with %.0f%% done", *p.DaysLeft, p.CompletionPercent)
+	}
+	if p.ExpectedPercent != nil && *p.ExpectedPercent-p.CompletionPercent > portfolioLagPercent {
+		return models.HealthAtRisk, fmt.Sprintf("%.0f%% done while %.0f%% of time has passed", p.CompletionPercent, *p.ExpectedPercent)
+	}
+
+	return models.HealthOnTrack, "on schedule"
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	reportDateLayout = "2006-01-02"
+	// maxReportPoints ограничивает длину ряда, чтобы один запрос не строил график на десятилетия
+	maxReportPoints = 1000
+)
+
+var (
+	ErrInvalidReportRange    = errors.New("invalid report range")
+	ErrInvalidReportInterval = errors.New("interval must be day, week or month")
+	ErrInvalidReportUnit     = errors.New("unit must be count or estimate")
+)
+
+// project загружает проект отчёта, превращая отсутствие записи в ErrProjectNotFound
+func (s *reportService) project(projectID uint) (*models.Project, error) {
+	project, err := s.repo.GetProject(projectID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+	return project, nil
+}
+
+// resolveReportRange проверяет интервал и даты запроса, подставляя значения по умолчанию
+func resolveReportRange(query models.ReportRangeQuery, defaultFrom, defaultTo time.Time) (from, to time.Time, interval string, err error) {
+	interval = query.Interval
+	if interval == "" {
+		interval = models.ReportIntervalDay
+	}
+	switch interval {
+	case models.ReportIntervalDay, models.ReportIntervalWeek, models.ReportIntervalMonth:
+	default:
+		return from, to, "", ErrInvalidReportInterval
+	}
+
+	if from, err = parseReportDate(query.From, defaultFrom); err != nil {
+		return from, to, "", err
+	}
+	// Месяцы считаются с первого числа: от 31-го Go (AddDate) и Postgres (+ interval '1 month')
+	// шагают по-разному, и даты интервалов в отчёте разъехались бы с датами из базы
+	if interval == models.ReportIntervalMonth {
+		from = alignReportBucket(from, interval)
+	}
+	if to, err = parseReportDate(query.To, defaultTo); err != nil {
+		return from, to, "", err
+	}
+
+	if to.Before(from) || reportPoints(from, to, interval) > maxReportPoints {
+		return from, to, "", ErrInvalidReportRange
+	}
+	return from, to, interval, nil
+}
+
+// reportPoints — число интервалов между from и to включительно
+func reportPoints(from, to time.Time, interval string) int {
+	switch interval {
+	case models.ReportIntervalWeek:
+		return int(to.Sub(from)/(7*24*time.Hour)) + 1
+	case models.ReportIntervalMonth:
+		return (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	default:
+		return int(to.Sub(from)/(24*time.Hour)) + 1
+	}
+}
+
+func nextReportBucket(date time.Time, interval string) time.Time {
+	switch interval {
+	case models.ReportIntervalWeek:
+		return date.AddDate(0, 0, 7)
+	case models.ReportIntervalMonth:
+		return date.AddDate(0, 1, 0)
+	default:
+		return date.AddDate(0, 0, 1)
+	}
+}
+
+func previousReportBucket(date time.Time, interval string) time.Time {
+	switch interval {
+	case models.ReportIntervalWeek:
+		return date.AddDate(0, 0, -7)
+	case models.ReportIntervalMonth:
+		return date.AddDate(0, -1, 0)
+	default:
+		return date.AddDate(0, 0, -1)
+	}
+}
+
+// alignReportBucket сдвигает дату к началу её интервала так же, как date_trunc:
+// неделя начинается с понедельника, месяц — с первого числа
+func alignReportBucket(date time.Time, interval string) time.Time {
+	date = truncateDay(date)
+	switch interval {
+	case models.ReportIntervalWeek:
+		offset := (int(date.Weekday()) + 6) % 7
+		return date.AddDate(0, 0, -offset)
+	case models.ReportIntervalMonth:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return date
+	}
+}
+
+// parseReportDate разбирает дату из запроса; пустая строка означает значение по умолчанию
+func parseReportDate(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	date, err := time.Parse(reportDateLayout, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidReportRange
+	}
+	return date, nil
+}
+
+// truncateDay приводит момент к началу суток UTC — границе интервалов отчётов
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func roundReport(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// snapshotFlushInterval — как часто пересчитываются снимки проектов, где менялись задачи.
+	// Пачка правок подряд даёт один пересчёт, а не по запросу на каждую.
+	snapshotFlushInterval = 30 * time.Second
+	snapshotCompareDays   = 7
+	snapshotListDays      = 30
+)
+
+// ReportSnapshotService хранит дневные снимки показателей проектов, чтобы дашборд
+// не пересчитывал задачи на каждый запрос. Раз в сутки снимаются все проекты,
+// в течение дня строка текущего дня догоняет изменения задач по событиям.
+type ReportSnapshotService interface {
+	EventPublisher
+	Run(ctx context.Context)
+	// Refresh пересчитывает сегодняшние снимки сразу: одного проекта или всех при nil
+	Refresh(projectID *uint) error
+	// Summary — сегодняшний снимок проекта; отсутствующий или устаревший пересчитывается
+	Summary(projectID uint) (models.ReportSnapshotResponse, error)
+	List(projectID uint, query models.ReportRangeQuery) ([]models.ReportSnapshotResponse, error)
+	Compare(projectID uint, query models.SnapshotCompareQuery) (models.SnapshotComparison, error)
+}
+
+type reportSnapshotService struct {
+	repo    repository.ReportSnapshotRepository
+	reports repository.ReportRepository
+	logger  *slog.Logger
+
+	mu    sync.Mutex
+	dirty map[uint]struct{}
+	// snapshotDay — день последнего полного пересчёта; меняется только в Run
+	snapshotDay time.Time
+}
+
+func NewReportSnapshotService(repo repository.ReportSnapshotRepository, reports repository.ReportRepository, logger *slog.Logger) ReportSnapshotService {
+	return &reportSnapshotService{repo: repo, reports: reports, logger: logger, dirty: map[uint]struct{}{}}
+}
+
+// Publish помечает проект для пересчёта; сам пересчёт делает Run
+func (s *reportSnapshotService) Publish(event models.DomainEvent) {
+	if event.ProjectID == 0 || !strings.HasPrefix(event.Type, "task.") {
+		return
+	}
+	s.mu.Lock()
+	s.dirty[event.ProjectID] = struct{}{}
+	s.mu.Unlock()
+}
+
+// Run снимает все проекты при старте и с наступлением нового дня, а между этим
+// раз в snapshotFlushInterval пересчитывает изменённые проекты
+func (s *reportSnapshotService) Run(ctx context.Context) {
+	s.logger.Info("report snapshot worker started", "op", "service.snapshot.Run")
+	ticker := time.NewTicker(snapshotFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(time.Now())
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("report snapshot worker stopped", "op", "service.snapshot.Run")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *reportSnapshotService) tick(now time.Time) {
+	day := truncateDay(now)
+	if !s.snapshotDay.Equal(day) {
+		// Отметки, снятые до полного пересчёта, он и покрывает; новые дождутся следующего тика
+		s.takeDirty()
+		if err := s.repo.Refresh(day, now, nil); err != nil {
+			s.logger.Error("nightly snapshot failed", "op", "service.snapshot.tick", "day", day.Format(reportDateLayout), "err", err)
+			return
+		}
+		s.snapshotDay = day
+		s.logger.Info("all projects snapshotted", "op", "service.snapshot.tick", "day", day.Format(reportDateLayout))
+		return
+	}
+
+	ids := s.takeDirty()
+	if len(ids) == 0 {
+		return
+	}
+	if err := s.repo.Refresh(day, now, ids); err != nil {
+		s.logger.Error("incremental snapshot failed", "op", "service.snapshot.tick", "projects", len(ids), "err", err)
+		s.markDirty(ids)
+	}
+}
+
+func (s *reportSnapshotService) takeDirty() []uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := slices.Collect(maps.Keys(s.dirty))
+	clear(s.dirty)
+	return ids
+}
+
+func (s *reportSnapshotService) markDirty(ids []uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		s.dirty[id] = struct{}{}
+	}
+}
+
+// isDirty снимает отметку с проекта: вызывающий сам пересчитает его снимок
+func (s *reportSnapshotService) isDirty(projectID uint) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.dirty[projectID]
+	delete(s.dirty, projectID)
+	return ok
+}
+
+func (s *reportSnapshotService) Refresh(projectID *uint) error {
+	now := time.Now()
+	var ids []uint
+	if projectID != nil {
+		if err := s.checkProject(*projectID); err != nil {
+			return err
+		}
+		ids = []uint{*projectID}
+	}
+	if err := s.repo.Refresh(truncateDay(now), now, ids); err != nil {
+		return err
+	}
+	s.logger.Info("snapshots refreshed on demand", "op", "service.snapshot.Refresh", "project_id", projectID)
+	return nil
+}
+
+func (s *reportSnapshotService) Summary(projectID uint) (models.ReportSnapshotResponse, error) {
+	if err := s.checkProject(projectID); err != nil {
+		return models.ReportSnapshotResponse{}, err
+	}
+	snapshot, err := s.current(projectID)
+	if err != nil {
+		return models.ReportSnapshotResponse{}, err
+	}
+	return buildSnapshotResponse(*snapshot), nil
+}
+
+func (s *reportSnapshotService) List(projectID uint, query models.ReportRangeQuery) ([]models.ReportSnapshotResponse, error) {
+	if err := s.checkProject(projectID); err != nil {
+		return nil, err
+	}
+
+	today := truncateDay(time.Now())
+	from, err := parseReportDate(query.From, today.AddDate(0, 0, -snapshotListDays+1))
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseReportDate(query.To, today)
+	if err != nil {
+		return nil, err
+	}
+	if to.Before(from) || reportPoints(from, to, models.ReportIntervalDay) > maxReportPoints {
+		return nil, ErrInvalidReportRange
+	}
+
+	snapshots, err := s.repo.List(projectID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]models.ReportSnapshotResponse, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		resp = append(resp, buildSnapshotResponse(snapshot))
+	}
+	return resp, nil
+}
+
+// Compare сравнивает сегодняшний снимок с последним снимком не позже выбранного дня
+func (s *reportSnapshotService) Compare(projectID uint, query models.SnapshotCompareQuery) (models.SnapshotComparison, error) {
+	if err := s.checkProject(projectID); err != nil {
+		return models.SnapshotComparison{}, err
+	}
+
+	today := truncateDay(time.Now())
+	days := query.Days
+	if days == 0 {
+		days = snapshotCompareDays
+	}
+	day, err := parseReportDate(query.Date, today.AddDate(0, 0, -days))
+	if err != nil {
+		return models.SnapshotComparison{}, err
+	}
+	if !day.Before(today) {
+		return models.SnapshotComparison{}, ErrInvalidReportRange
+	}
+
+	current, err := s.current(projectID)
+	if err != nil {
+		return models.SnapshotComparison{}, err
+	}
+	comparison := models.SnapshotComparison{
+		ProjectID:  projectID,
+		ComparedTo: day.Format(reportDateLayout),
+		Current:    buildSnapshotResponse(*current),
+	}
+
+	previous, err := s.repo.GetOnOrBefore(projectID, day)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return comparison, nil
+		}
+		return models.SnapshotComparison{}, err
+	}
+	prev := buildSnapshotResponse(*previous)
+	comparison.Previous = &prev
+	comparison.Delta = &models.SnapshotDelta{
+		TotalTasks:        current.TotalTasks - previous.TotalTasks,
+		DoneTasks:         current.DoneTasks - previous.DoneTasks,
+		InProgressTasks:   current.InProgressTasks - previous.InProgressTasks,
+		OverdueTasks:      current.OverdueTasks - previous.OverdueTasks,
+		CompletionPercent: roundReport(current.CompletionPercent - previous.CompletionPercent),
+	}
+	return comparison, nil
+}
+
+// current возвращает сегодняшний снимок, пересчитывая его, если снимка нет или проект помечен изменённым
+func (s *reportSnapshotService) current(projectID uint) (*models.ReportSnapshot, error) {
+	now := time.Now()
+	today := truncateDay(now)
+	if !s.isDirty(projectID) {
+		snapshot, err := s.repo.Get(projectID, today)
+		if err == nil {
+			return snapshot, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Refresh(today, now, []uint{projectID}); err != nil {
+		s.markDirty([]uint{projectID})
+		return nil, err
+	}
+	return s.repo.Get(projectID, today)
+}
+
+func (s *reportSnapshotService) checkProject(projectID uint) error {
+	if _, err := s.reports.GetProject(projectID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProjectNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func buildSnapshotResponse(snapshot models.ReportSnapshot) models.ReportSnapshotResponse {
+	avgCycle := time.Duration(snapshot.AvgCycleSeconds) * time.Second
+	return models.ReportSnapshotResponse{
+		ProjectID:         snapshot.ProjectID,
+		Day:               snapshot.Day.Format(reportDateLayout),
+		TotalTasks:        snapshot.TotalTasks,
+		TodoTasks:         snapshot.TodoTasks,
+		InProgressTasks:   snapshot.InProgressTasks,
+		DoneTasks:         snapshot.DoneTasks,
+		OverdueTasks:      snapshot.OverdueTasks,
+		EstimateTotal:     roundReport(snapshot.EstimateTotal),
+		EstimateDone:      roundReport(snapshot.EstimateDone),
+		CompletionPercent: snapshot.CompletionPercent,
+		AvgCycleSeconds:   int64(avgCycle.Seconds()),
+		AvgCycleHuman:     formatDuration(avgCycle),
+		RefreshedAt:       snapshot.RefreshedAt,
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrTeamNotFound = errors.New("team not found")
+
+// Team считает показатели команды по задачам её проекта, назначенным на участников.
+// Период и интервалы — как у throughput; cycle time и вклад участников считаются за тот же период.
+func (s *reportService) Team(teamID uint, query models.ThroughputQuery) (models.TeamReport, error) {
+	team, memberIDs, err := s.repo.GetTeam(teamID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.TeamReport{}, ErrTeamNotFound
+		}
+		return models.TeamReport{}, err
+	}
+	project, err := s.project(team.ProjectID)
+	if err != nil {
+		return models.TeamReport{}, err
+	}
+
+	// Пустой список исполнителей означал бы «без фильтра»; у пустой команды задач нет
+	assigneeIDs := memberIDs
+	if len(assigneeIDs) == 0 {
+		assigneeIDs = []uint{0}
+	}
+
+	report := models.TeamReport{
+		TeamID:          team.ID,
+		Name:            team.Name,
+		ProjectID:       team.ProjectID,
+		MemberIDs:       memberIDs,
+		TopContributors: []models.TeamContributor{},
+	}
+	if report.MemberIDs == nil {
+		report.MemberIDs = []uint{}
+	}
+
+	report.Throughput, err = s.throughput(&project.ID, assigneeIDs, project.CreatedAt, query)
+	if err != nil {
+		return models.TeamReport{}, err
+	}
+	// Границы периода берём у throughput: они уже выровнены по интервалам
+	from, _ := time.Parse(reportDateLayout, report.Throughput.From)
+	to, _ := time.Parse(reportDateLayout, report.Throughput.To)
+	end := nextReportBucket(to, report.Throughput.Interval)
+
+	total, done, err := s.repo.CountAssignedTasks(project.ID, assigneeIDs)
+	if err != nil {
+		return models.TeamReport{}, err
+	}
+	report.Completion = models.CompletionPercentDTO{TotalTasks: total, DoneTasks: done}
+	if total > 0 {
+		report.Completion.Percent = roundReport(float64(done) / float64(total) * 100)
+	}
+
+	completed, err := s.repo.GetCompletedTasks(project.ID, from, end, nil, assigneeIDs)
+	if err != nil {
+		return models.TeamReport{}, err
+	}
+	report.CycleTime, _ = cycleTimeStats(completed)
+	report.AverageCycleHuman = formatDuration(time.Duration(report.CycleTime.CycleTime.MeanSeconds) * time.Second)
+
+	rows, err := s.repo.GetTeamContributors(project.ID, assigneeIDs, from, end, time.Now())
+	if err != nil {
+		return models.TeamReport{}, err
+	}
+	for _, row := range rows {
+		logged := time.Duration(row.LoggedSeconds) * time.Second
+		report.TopContributors = append(report.TopContributors, models.TeamContributor{
+			UserID:          row.UserID,
+			Name:            row.Name,
+			CompletedTasks:  row.CompletedTasks,
+			InProgressTasks: row.InProgressTasks,
+			LoggedSeconds:   int64(logged.Seconds()),
+			LoggedHuman:     formatDuration(logged),
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"time"
+)
+
+const defaultThroughputWindow = 4
+
+func (s *reportService) Throughput(projectID uint, query models.ThroughputQuery) (models.ThroughputReport, error) {
+	project, err := s.project(projectID)
+	if err != nil {
+		return models.ThroughputReport{}, err
+	}
+	return s.throughput(&projectID, nil, project.CreatedAt, query)
+}
+
+func (s *reportService) ThroughputAll(query models.ThroughputQuery) (models.ThroughputReport, error) {
+	// Без проекта нет естественного начала, поэтому по умолчанию берём последний квартал
+	return s.throughput(nil, nil, time.Now().AddDate(0, -3, 0), query)
+}
+
+// throughput строит ряд закрытых задач; assigneeIDs ограничивает задачи исполнителями, nil — все
+func (s *reportService) throughput(projectID *uint, assigneeIDs []uint, defaultFrom time.Time, query models.ThroughputQuery) (models.ThroughputReport, error) {
+	if query.Interval == "" {
+		query.Interval = models.ReportIntervalWeek
+	}
+	window := query.Window
+	if window == 0 {
+		window = defaultThroughputWindow
+	}
+
+	today := truncateDay(time.Now())
+	from, to, interval, err := resolveReportRange(query.ReportRangeQuery, truncateDay(defaultFrom), today)
+	if err != nil {
+		return models.ThroughputReport{}, err
+	}
+	from = alignReportBucket(from, interval)
+	to = alignReportBucket(to, interval)
+	end := nextReportBucket(to, interval)
+
+	// Предыдущий период той же длины и window-1 интервалов перед From для скользящего
+	// среднего загружаются одним запросом вместе с основным диапазоном
+	buckets := 0
+	for date := from; date.Before(end); date = nextReportBucket(date, interval) {
+		buckets++
+	}
+	history := max(buckets, window-1)
+	loadFrom := from
+	for range history {
+		loadFrom = previousReportBucket(loadFrom, interval)
+	}
+
+	rows, err := s.repo.GetThroughput(projectID, assigneeIDs, loadFrom, end, interval)
+	if err != nil {
+		return models.ThroughputReport{}, err
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Bucket.UTC().Format(reportDateLayout)] += row.Count
+	}
+
+	var series []int
+	for date := loadFrom; date.Before(end); date = nextReportBucket(date, interval) {
+		series = append(series, counts[date.Format(reportDateLayout)])
+	}
+
+	report := models.ThroughputReport{
+		ProjectID: projectID,
+		From:      from.Format(reportDateLayout),
+		To:        to.Format(reportDateLayout),
+		Interval:  interval,
+		Window:    window,
+		Points:    make([]models.ThroughputPoint, 0, buckets),
+	}
+
+	date := from
+	for i := history; i < len(series); i++ {
+		sum := 0
+		for _, n := range series[i-window+1 : i+1] {
+			sum += n
+		}
+		report.Points = append(report.Points, models.ThroughputPoint{
+			Date:           date.Format(reportDateLayout),
+			Completed:      series[i],
+			RollingAverage: roundReport(float64(sum) / float64(window)),
+		})
+		report.Total += series[i]
+		date = nextReportBucket(date, interval)
+	}
+	for _, n := range series[history-buckets : history] {
+		report.PreviousTotal += n
+	}
+	if report.PreviousTotal > 0 {
+		change := roundReport(float64(report.Total-report.PreviousTotal) / float64(report.PreviousTotal) * 100)
+		report.ChangePercent = &change
+	}
+
+	return report, nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"cmp"
+	"slices"
+	"time"
+)
+
+// timeReportDays — период отчёта по всем проектам по умолчанию
+const timeReportDays = 30
+
+// ProjectTime сравнивает записанное в проекте время с календарным временем задач в работе
+func (s *reportService) ProjectTime(projectID uint, query models.TimeReportQuery) (models.ProjectTimeReport, error) {
+	project, err := s.project(projectID)
+	if err != nil {
+		return models.ProjectTimeReport{}, err
+	}
+
+	now := time.Now()
+	from, to, err := resolveTimeRange(query, truncateDay(project.CreatedAt), now)
+	if err != nil {
+		return models.ProjectTimeReport{}, err
+	}
+
+	logged, err := s.repo.GetLoggedTime(models.LoggedTimeFilter{
+		ProjectID: &projectID, UserID: query.UserID, From: from, To: to, Now: now,
+	})
+	if err != nil {
+		return models.ProjectTimeReport{}, err
+	}
+	calendar, err := s.repo.GetCalendarTime(&projectID, from, to, now)
+	if err != nil {
+		return models.ProjectTimeReport{}, err
+	}
+
+	calendarByTask := make(map[uint]float64, len(calendar))
+	for _, row := range calendar {
+		calendarByTask[row.TaskID] = row.Seconds
+	}
+
+	tasks := map[uint]*models.TaskTime{}
+	users := map[uint]*models.UserTime{}
+	var loggedTotal float64
+	for _, row := range logged {
+		task, ok := tasks[row.TaskID]
+		if !ok {
+			task = &models.TaskTime{TaskID: row.TaskID, Title: row.TaskTitle}
+			tasks[row.TaskID] = task
+		}
+		task.LoggedSeconds += int64(row.Seconds)
+
+		user, ok := users[row.UserID]
+		if !ok {
+			user = &models.UserTime{UserID: row.UserID, Name: row.UserName}
+			users[row.UserID] = user
+		}
+		user.LoggedSeconds += int64(row.Seconds)
+		loggedTotal += row.Seconds
+	}
+	// Задачи, которые были в работе, но без записей, тоже показываем: это и есть неучтённое время.
+	// При фильтре по пользователю календарь задач без его записей к нему не относится.
+	if query.UserID == nil {
+		for _, row := range calendar {
+			if _, ok := tasks[row.TaskID]; !ok {
+				tasks[row.TaskID] = &models.TaskTime{TaskID: row.TaskID, Title: row.TaskTitle}
+			}
+		}
+	}
+
+	report := models.ProjectTimeReport{
+		ProjectID: projectID,
+		From:      from.Format(reportDateLayout),
+		To:        to.AddDate(0, 0, -1).Format(reportDateLayout),
+		Users:     make([]models.UserTime, 0, len(users)),
+		Tasks:     make([]models.TaskTime, 0, len(tasks)),
+	}
+
+	var calendarTotal float64
+	for _, task := range tasks {
+		seconds := calendarByTask[task.TaskID]
+		calendarTotal += seconds
+		task.TimeAmount = timeAmount(float64(task.LoggedSeconds), seconds)
+		report.Tasks = append(report.Tasks, *task)
+	}
+	for _, user := range users {
+		user.LoggedHuman = formatDuration(time.Duration(user.LoggedSeconds) * time.Second)
+		report.Users = append(report.Users, *user)
+	}
+	report.TimeAmount = timeAmount(loggedTotal, calendarTotal)
+
+	slices.SortFunc(report.Tasks, func(a, b models.TaskTime) int {
+		return cmp.Or(cmp.Compare(b.LoggedSeconds, a.LoggedSeconds), cmp.Compare(b.CalendarSeconds, a.CalendarSeconds), cmp.Compare(a.TaskID, b.TaskID))
+	})
+	sortUserTimes(report.Users)
+	return report, nil
+}
+
+// Time — записанное время по всем проектам: по людям с разбивкой по проектам и по проектам
+func (s *reportService) Time(query models.TimeReportQuery) (models.TimeReport, error) {
+	now := time.Now()
+	from, to, err := resolveTimeRange(query, truncateDay(now).AddDate(0, 0, -timeReportDays+1), now)
+	if err != nil {
+		return models.TimeReport{}, err
+	}
+
+	logged, err := s.repo.GetLoggedTime(models.LoggedTimeFilter{UserID: query.UserID, From: from, To: to, Now: now})
+	if err != nil {
+		return models.TimeReport{}, err
+	}
+
+	users := map[uint]*models.UserTime{}
+	userProjects := map[uint]map[uint]*models.ProjectLoggedTime{}
+	projects := map[uint]*models.ProjectTime{}
+	loggedTasks := map[uint]bool{}
+	for _, row := range logged {
+		user, ok := users[row.UserID]
+		if !ok {
+			user = &models.UserTime{UserID: row.UserID, Name: row.UserName}
+			users[row.UserID] = user
+			userProjects[row.UserID] = map[uint]*models.ProjectLoggedTime{}
+		}
+		user.LoggedSeconds += int64(row.Seconds)
+
+		byProject, ok := userProjects[row.UserID][row.ProjectID]
+		if !ok {
+			byProject = &models.ProjectLoggedTime{ProjectID: row.ProjectID, Title: row.ProjectTitle}
+			userProjects[row.UserID][row.ProjectID] = byProject
+		}
+		byProject.LoggedSeconds += int64(row.Seconds)
+
+		project, ok := projects[row.ProjectID]
+		if !ok {
+			project = &models.ProjectTime{ProjectID: row.ProjectID, Title: row.ProjectTitle}
+			projects[row.ProjectID] = project
+		}
+		project.LoggedSeconds += int64(row.Seconds)
+		loggedTasks[row.TaskID] = true
+	}
+
+	// Календарное время проекта — по задачам с записями выбранных людей, иначе сравнение теряет смысл
+	calendar, err := s.repo.GetCalendarTime(nil, from, to, now)
+	if err != nil {
+		return models.TimeReport{}, err
+	}
+	calendarByProject := map[uint]float64{}
+	for _, row := range calendar {
+		if loggedTasks[row.TaskID] {
+			calendarByProject[row.ProjectID] += row.Seconds
+		}
+	}
+
+	report := models.TimeReport{
+		From:     from.Format(reportDateLayout),
+		To:       to.AddDate(0, 0, -1).Format(reportDateLayout),
+		UserID:   query.UserID,
+		Users:    make([]models.UserTime, 0, len(users)),
+		Projects: make([]models.ProjectTime, 0, len(projects)),
+	}
+	for _, user := range users {
+		user.LoggedHuman = formatDuration(time.Duration(user.LoggedSeconds) * time.Second)
+		for _, p := range userProjects[user.UserID] {
+			p.LoggedHuman = formatDuration(time.Duration(p.LoggedSeconds) * time.Second)
+			user.Projects = append(user.Projects, *p)
+		}
+		slices.SortFunc(user.Projects, func(a, b models.ProjectLoggedTime) int {
+			return cmp.Or(cmp.Compare(b.LoggedSeconds, a.LoggedSeconds), cmp.Compare(a.ProjectID, b.ProjectID))
+		})
+		report.Users = append(report.Users, *user)
+	}
+	for _, project := range projects {
+		project.TimeAmount = timeAmount(float64(project.LoggedSeconds), calendarByProject[project.ProjectID])
+		report.Projects = append(report.Projects, *project)
+	}
+
+	sortUserTimes(report.Users)
+	slices.SortFunc(report.Projects, func(a, b models.ProjectTime) int {
+		return cmp.Or(cmp.Compare(b.LoggedSeconds, a.LoggedSeconds), cmp.Compare(a.ProjectID, b.ProjectID))
+	})
+	return report, nil
+}
+
+// resolveTimeRange возвращает полуинтервал [from, to): день to входит в отчёт целиком
+func resolveTimeRange(query models.TimeReportQuery, defaultFrom, now time.Time) (time.Time, time.Time, error) {
+	from, err := parseReportDate(query.From, defaultFrom)
+	if err != nil {
+		return from, from, err
+	}
+	to, err := parseReportDate(query.To, truncateDay(now))
+	if err != nil {
+		return from, to, err
+	}
+	if to.Before(from) || reportPoints(from, to, models.ReportIntervalDay) > maxReportPoints {
+		return from, to, ErrInvalidReportRange
+	}
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+func timeAmount(logged, calendar float64) models.TimeAmount {
+	amount := models.TimeAmount{
+		LoggedSeconds:   int64(logged),
+		LoggedHuman:     formatDuration(time.Duration(logged) * time.Second),
+		CalendarSeconds: int64(calendar),
+		CalendarHuman:   formatDuration(time.Duration(calendar) * time.Second),
+	}
+	if calendar > 0 {
+		percent := roundReport(logged / calendar * 100)
+		amount.LoggedPercent = &percent
+	}
+	return amount
+}
+
+func sortUserTimes(users []models.UserTime) {
+	slices.SortFunc(users, func(a, b models.UserTime) int {
+		return cmp.Or(cmp.Compare(b.LoggedSeconds, a.LoggedSeconds), cmp.Compare(a.UserID, b.UserID))
+	})
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"time"
+)
+
+// Workload сравнивает нагрузку каждого пользователя с его Capacity; перегруженные идут первыми
+func (s *reportService) Workload(query models.WorkloadQuery) (models.WorkloadReport, error) {
+	now := time.Now()
+	if query.ProjectID != nil {
+		if _, err := s.project(*query.ProjectID); err != nil {
+			return models.WorkloadReport{}, err
+		}
+	}
+
+	rows, err := s.repo.GetWorkload(now, query.ProjectID)
+	if err != nil {
+		return models.WorkloadReport{}, err
+	}
+
+	report := models.WorkloadReport{
+		GeneratedAt: now,
+		ProjectID:   query.ProjectID,
+		Users:       make([]models.WorkloadUser, 0, len(rows)),
+	}
+	var over, rest []models.WorkloadUser
+	for _, row := range rows {
+		user := models.WorkloadUser{
+			UserID:               row.UserID,
+			Name:                 row.Name,
+			Capacity:             row.Capacity,
+			OpenTasks:            row.OpenTasks,
+			InProgressTasks:      row.InProgressTasks,
+			WeightedLoad:         roundReport(row.WeightedLoad),
+			AvgInProgressSeconds: int64(row.AvgInProgressSeconds),
+		}
+		if row.Capacity > 0 {
+			utilization := roundReport(user.WeightedLoad / float64(row.Capacity) * 100)
+			user.UtilizationPercent = &utilization
+		}
+		user.OverCapacity = user.WeightedLoad > float64(row.Capacity)
+		if row.OldestStartedAt != nil {
+			age := now.Sub(*row.OldestStartedAt)
+			user.OldestInProgressSeconds = int64(age.Seconds())
+			user.OldestInProgressHuman = formatDuration(age)
+		}
+
+		if user.OverCapacity {
+			over = append(over, user)
+		} else if !query.OverCapacity {
+			rest = append(rest, user)
+		}
+	}
+
+	report.OverCapacity = len(over)
+	report.Users = append(append(report.Users, over...), rest...)
+	return report, nil
+}
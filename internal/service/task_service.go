@@ -4,6 +4,7 @@ import (
 	"back-minijira-petproject1/internal/models"
 	"back-minijira-petproject1/internal/repository"
 	"errors"
+	"fmt"
 	"log/slog"
 	"slices"
 	"strings"
@@ -15,22 +16,28 @@ import (
 type TaskService interface {
 	GetTaskByID(id uint) (*models.TaskResponse, error)
 	ListTasks(filter *models.TaskFilter) ([]*models.TaskResponse, error)
+	EachTask(filter *models.TaskFilter, fn func(*models.TaskResponse) error) error
 	DeleteTask(id uint) error
-	CreateTask(req *models.TaskCreateReq) error
-	UpdateTask(id uint, req models.TaskUpdateReq) error
+	CreateTask(req *models.TaskCreateReq, actorID uint) (*models.TaskResponse, error)
+	UpdateTask(id uint, req models.TaskUpdateReq, actorID uint) error
 	AssignTaskToUser(taskID uint, userID uint) error
-	UnassignTaskFromUser(taskID uint, userID uint) error
+	UnassignTaskFromUser(taskID uint, userID uint, actorID uint) error
 }
 
+// taskBatchSize — размер пачки задач при потоковой выгрузке списка
+const taskBatchSize = 500
+
 type taskService struct {
-	db          *gorm.DB
-	logger      *slog.Logger
-	repo        repository.TaskRepository
-	projectRepo repository.ProjectRepository
+	db            *gorm.DB
+	logger        *slog.Logger
+	repo          repository.TaskRepository
+	projectRepo   repository.ProjectRepository
+	notifications NotificationService
+	events        EventPublisher
 }
 
-func NewTaskService(db *gorm.DB, logger *slog.Logger, repo repository.TaskRepository, projectRepo repository.ProjectRepository) TaskService {
-	return &taskService{db: db, logger: logger, repo: repo, projectRepo: projectRepo}
+func NewTaskService(db *gorm.DB, logger *slog.Logger, repo repository.TaskRepository, projectRepo repository.ProjectRepository, notifications NotificationService, events EventPublisher) TaskService {
+	return &taskService{db: db, logger: logger, repo: repo, projectRepo: projectRepo, notifications: notifications, events: events}
 }
 
 func (s *taskService) GetTaskByID(id uint) (*models.TaskResponse, error) {
@@ -68,29 +75,67 @@ func (s *taskService) ListTasks(filter *models.TaskFilter) ([]*models.TaskRespon
 	return tasksResponse, nil
 }
 
+// EachTask обходит все задачи по фильтру без пагинации, подгружая их пачками; для выгрузок
+func (s *taskService) EachTask(filter *models.TaskFilter, fn func(*models.TaskResponse) error) error {
+	return s.repo.EachTaskBatch(filter, taskBatchSize, func(tasks []*models.Task) error {
+		for _, task := range tasks {
+			if err := fn(buildTaskResponse(task)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (s *taskService) DeleteTask(id uint) error {
+	task, err := s.repo.GetTaskByID(id)
+	if err != nil {
+		s.logger.Error("failed to get the task by id", "op", "service.task.DeleteTask", "id", id, "err", err)
+		return err
+	}
 	if err := s.repo.DeleteTask(id); err != nil {
 		s.logger.Error("failed delete task by id", "id", id, "err", err)
 		return err
 	}
 	s.logger.Info("delete task by id successful", "op", "service.project.DeleteTask")
+
+	s.events.Publish(NewDomainEvent(models.EventTaskDeleted, task.ProjectID, taskEventData(task, task.Status)))
 	return nil
 }
 
-func (s *taskService) CreateTask(req *models.TaskCreateReq) error {
-	if err := s.repo.CreateTask(req); err != nil {
+func (s *taskService) CreateTask(req *models.TaskCreateReq, actorID uint) (*models.TaskResponse, error) {
+	var task *models.Task
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		taskrepo := s.repo.WithDB(tx)
+
+		created, err := taskrepo.CreateTask(req)
+		if err != nil {
+			return err
+		}
+		task = created
+		// Начальная запись истории: от неё отчёты считают, с какого статуса задача стартовала
+		return recordStatusChange(taskrepo, task, "", task.Status, actorID)
+	})
+	if err != nil {
 		s.logger.Error("failed create task from req", "err", err, "req", req)
-		return err
+		return nil, err
 	}
 	s.logger.Info("create task from req successful", "op", "service.project.CreateTask")
-	return nil
+
+	// Исполнители, указанные при создании, получают такое же уведомление, как при назначении позже
+	s.notify(taskAssignedEvent(task, userIDs(task.Users), actorID))
+	s.events.Publish(NewDomainEvent(models.EventTaskCreated, task.ProjectID, taskEventData(task, task.Status)))
+	return buildTaskResponse(task), nil
 }
 
-func (s *taskService) UpdateTask(id uint, req models.TaskUpdateReq) error {
+func (s *taskService) UpdateTask(id uint, req models.TaskUpdateReq, actorID uint) error {
 	s.logger.Info("UpdateTask called", "op", "service.task.UpdateTask", "id", id,
 		"title", req.Title, "status", req.Status, "priority", req.Priority)
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	// Уведомления и доменные события отправляются только после успешного коммита транзакции
+	var events []models.NotificationEvent
+	var domainEvents []models.DomainEvent
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		taskrepo := s.repo.WithDB(tx)
 		projectRepo := s.projectRepo.WithDB(tx)
 
@@ -144,6 +189,8 @@ func (s *taskService) UpdateTask(id uint, req models.TaskUpdateReq) error {
 			Priority:    req.Priority,
 			StartTask:   req.StartTask,
 			FinishTask:  req.FinishTask,
+			DueDate:     req.DueDate,
+			Estimate:    req.Estimate,
 		}
 
 		if req.Status != nil {
@@ -208,6 +255,31 @@ func (s *taskService) UpdateTask(id uint, req models.TaskUpdateReq) error {
 		}
 
 
+		taskUsers := task.Users
+		if req.Users != nil {
+			taskUsers = *req.Users
+			assigned := newlyAssignedUserIDs(task.Users, *req.Users)
+			events = append(events, taskAssignedEvent(task, assigned, actorID))
+			if len(assigned) > 0 {
+				changed := *task
+				changed.Users = taskUsers
+				domainEvents = append(domainEvents, taskAssignedDomainEvent(&changed, assigned, actorID))
+			}
+		}
+		if updateReq.Status != nil {
+			newStatus := strings.ToLower(strings.TrimSpace(*updateReq.Status))
+			if newStatus != oldStatusTask {
+				if err := recordStatusChange(taskrepo, task, oldStatusTask, newStatus, actorID); err != nil {
+					return err
+				}
+				events = append(events, taskStatusChangedEvent(task, newStatus, userIDs(taskUsers), actorID))
+
+				changed := *task
+				changed.Users = taskUsers
+				domainEvents = append(domainEvents, taskStatusChangedDomainEvent(&changed, oldStatusTask, newStatus, actorID))
+			}
+		}
+
 		if req.Status != nil {
 			var doneTasksCount int64
 			var totalTasksCount int64
@@ -241,6 +313,16 @@ func (s *taskService) UpdateTask(id uint, req models.TaskUpdateReq) error {
 					}
 
 					if newProjStatus.Status != nil {
+						if *newProjStatus.Status == statusDone {
+							project, err := projectRepo.GetProjectByID(task.ProjectID)
+							if err != nil {
+								s.logger.Error("failed to get project", "project_id", task.ProjectID, "err", err)
+								return err
+							}
+							if !strings.EqualFold(project.Status, statusDone) {
+								domainEvents = append(domainEvents, projectCompletedEvent(task.ProjectID, project.Title))
+							}
+						}
 						if err := projectRepo.UpdateProject(task.ProjectID, newProjStatus); err != nil {
 							s.logger.Error("failed to update project status", "project_id", task.ProjectID,
 								"new_status", *newProjStatus.Status, "err", err)
@@ -264,8 +346,19 @@ func (s *taskService) UpdateTask(id uint, req models.TaskUpdateReq) error {
 		}
 
 		s.logger.Info("update task from req successful", "op", "service.project.UpdateTask")
+		// Правка без смены статуса не интересна вебхукам, но меняет отчёты проекта
+		if len(domainEvents) == 0 {
+			domainEvents = append(domainEvents, NewDomainEvent(models.EventTaskUpdated, task.ProjectID, taskEventData(task, task.Status)))
+		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.notify(events...)
+	s.publish(domainEvents...)
+	return nil
 }
 
 func buildTaskResponse(task *models.Task) *models.TaskResponse {
@@ -282,6 +375,8 @@ func buildTaskResponse(task *models.Task) *models.TaskResponse {
 		Users:       task.Users,
 		LimitUser:   task.LimitUser,
 		StartTask:   task.StartTask,
+		DueDate:     task.DueDate,
+		Estimate:    task.Estimate,
 	}
 
 	if task.FinishTask != nil {
@@ -303,7 +398,9 @@ func buildTaskResponse(task *models.Task) *models.TaskResponse {
 }
 
 func (s *taskService) AssignTaskToUser(taskID uint, userID uint) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	var events []models.NotificationEvent
+	var domainEvents []models.DomainEvent
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		taskrepo := s.repo.WithDB(tx)
 
 		task, err := taskrepo.GetTaskByID(taskID)
@@ -330,6 +427,10 @@ func (s *taskService) AssignTaskToUser(taskID uint, userID uint) error {
 			return err
 		}
 
+		assignedTask := *task
+		assignedTask.Users = append(slices.Clone(task.Users), user)
+		domainEvents = append(domainEvents, taskAssignedDomainEvent(&assignedTask, []uint{userID}, userID))
+
 		if strings.ToLower(strings.TrimSpace(task.Status)) == "todo" {
 			statusInProgress := "in_progress"
 			updateReq := models.TaskUpdateReq{
@@ -343,14 +444,31 @@ func (s *taskService) AssignTaskToUser(taskID uint, userID uint) error {
 				return err
 			}
 			s.logger.Info("task status changed to in_progress (user assigned)", "task_id", taskID, "user_id", userID)
+			if err := recordStatusChange(taskrepo, task, "todo", statusInProgress, userID); err != nil {
+				return err
+			}
+			events = append(events, taskStatusChangedEvent(task, statusInProgress, userIDs(task.Users), userID))
+
+			changed := *task
+			changed.Users = append(slices.Clone(task.Users), user)
+			domainEvents = append(domainEvents, taskStatusChangedDomainEvent(&changed, "todo", statusInProgress, userID))
 		}
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.notify(events...)
+	s.publish(domainEvents...)
+	return nil
 }
 
-func (s *taskService) UnassignTaskFromUser(taskID uint, userID uint) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+func (s *taskService) UnassignTaskFromUser(taskID uint, userID uint, actorID uint) error {
+	var events []models.NotificationEvent
+	var domainEvents []models.DomainEvent
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		taskrepo := s.repo.WithDB(tx)
 
 		task, err := taskrepo.GetTaskByID(taskID)
@@ -370,6 +488,9 @@ func (s *taskService) UnassignTaskFromUser(taskID uint, userID uint) error {
 			return err
 		}
 
+		// Когда снимают последнего исполнителя, сообщить о возврате в todo больше некому,
+		// поэтому уведомление получают исполнители до снятия (кроме автора действия)
+		previousUsers := task.Users
 		task, err = taskrepo.GetTaskByID(taskID)
 		if err != nil {
 			s.logger.Error("failed to reload task", "err", err)
@@ -388,8 +509,96 @@ func (s *taskService) UnassignTaskFromUser(taskID uint, userID uint) error {
 				return err
 			}
 			s.logger.Info("task status changed to todo (all users unassigned)", "task_id", taskID, "user_id", userID)
+			if err := recordStatusChange(taskrepo, task, "in_progress", statusTodo, actorID); err != nil {
+				return err
+			}
+			events = append(events, taskStatusChangedEvent(task, statusTodo, userIDs(previousUsers), actorID))
+			domainEvents = append(domainEvents, taskStatusChangedDomainEvent(task, "in_progress", statusTodo, actorID))
 		}
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.notify(events...)
+	s.publish(domainEvents...)
+	return nil
+}
+
+// recordStatusChange пишет переход в историю статусов; по ней строятся burndown и другие отчёты
+func recordStatusChange(repo repository.TaskRepository, task *models.Task, from, to string, actorID uint) error {
+	return repo.AddStatusChange(&models.TaskStatusChange{
+		TaskID:     task.ID,
+		ProjectID:  task.ProjectID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		ChangedAt:  time.Now(),
+	})
+}
+
+// notify рассылает уведомления; ошибка не отменяет уже закоммиченное изменение задачи
+func (s *taskService) notify(events ...models.NotificationEvent) {
+	for _, event := range events {
+		if len(event.RecipientIDs) == 0 {
+			continue
+		}
+		if err := s.notifications.Notify(event); err != nil {
+			s.logger.Warn("failed to send task notification", "op", "service.task.notify", "type", event.Type, "task_id", event.EntityID, "err", err)
+		}
+	}
+}
+
+// publish отдаёт доменные события интеграциям (вебхуки и т.п.) после коммита
+func (s *taskService) publish(events ...models.DomainEvent) {
+	for _, event := range events {
+		s.events.Publish(event)
+	}
+}
+
+func taskAssignedEvent(task *models.Task, recipientIDs []uint, actorID uint) models.NotificationEvent {
+	return models.NotificationEvent{
+		Type:         models.NotificationTaskAssigned,
+		ActorID:      actorID,
+		RecipientIDs: recipientIDs,
+		Title:        fmt.Sprintf("Вас назначили на задачу «%s»", task.Title),
+		EntityName:   task.Title,
+		EntityType:   "tasks",
+		EntityID:     task.ID,
+		ProjectID:    task.ProjectID,
+	}
+}
+
+func taskStatusChangedEvent(task *models.Task, newStatus string, recipientIDs []uint, actorID uint) models.NotificationEvent {
+	return models.NotificationEvent{
+		Type:         models.NotificationTaskStatusChanged,
+		ActorID:      actorID,
+		RecipientIDs: recipientIDs,
+		Title:        fmt.Sprintf("Статус задачи «%s» изменён", task.Title),
+		Text:         fmt.Sprintf("%s → %s", task.Status, newStatus),
+		EntityName:   task.Title,
+		EntityType:   "tasks",
+		EntityID:     task.ID,
+		ProjectID:    task.ProjectID,
+	}
+}
+
+func newlyAssignedUserIDs(oldUsers, newUsers []models.User) []uint {
+	var ids []uint
+	for _, u := range newUsers {
+		if !slices.ContainsFunc(oldUsers, func(old models.User) bool { return old.ID == u.ID }) {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids
+}
+
+func userIDs(users []models.User) []uint {
+	ids := make([]uint, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return ids
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 )
 
 type TeamService interface {
@@ -18,12 +19,13 @@ type TeamService interface {
 }
 
 type teamService struct {
-	repo   repository.TeamRepository
-	logger *slog.Logger
+	repo          repository.TeamRepository
+	notifications NotificationService
+	logger        *slog.Logger
 }
 
-func NewTeamService(repo repository.TeamRepository, logger *slog.Logger) TeamService {
-	return &teamService{repo: repo, logger: logger}
+func NewTeamService(repo repository.TeamRepository, notifications NotificationService, logger *slog.Logger) TeamService {
+	return &teamService{repo: repo, notifications: notifications, logger: logger}
 }
 
 func validateTeamName(name string) error {
@@ -94,12 +96,14 @@ func (s *teamService) Create(req models.TeamCreateReq, currentUser models.User)
 		UserIDs:   userIDs,
 	}
 
+	s.notifyTeamAdded(savedTeam, userIDs, currentUser.ID)
+
 	s.logger.Info("CreateTeam success", "team_id", resp.ID)
 	return &resp, nil
 }
 
 func (s *teamService) Update(id uint, req models.TeamUpdateReq, currentUser models.User) error {
-	team, _, err := s.repo.GetTeamByID(id)
+	team, oldUserIDs, err := s.repo.GetTeamByID(id)
 	if err != nil {
 		s.logger.Error("UpdateTeam GetTeamByID failed", "team_id", id, "err", err)
 		return err
@@ -147,6 +151,16 @@ func (s *teamService) Update(id uint, req models.TeamUpdateReq, currentUser mode
 		return err
 	}
 
+	if req.Users != nil {
+		var added []uint
+		for _, userID := range *req.Users {
+			if !slices.Contains(oldUserIDs, userID) {
+				added = append(added, userID)
+			}
+		}
+		s.notifyTeamAdded(team, added, currentUser.ID)
+	}
+
 	s.logger.Info("UpdateTeam success", "team_id", id)
 	return nil
 }
@@ -245,3 +259,22 @@ func (s *teamService) GetTeamsByProjectID(projectID uint) ([]models.TeamResponse
 	s.logger.Info("GetTeamsByProjectID success", "project_id", projectID, "count", len(responses))
 	return responses, nil
 }
+
+func (s *teamService) notifyTeamAdded(team models.Team, userIDs []uint, actorID uint) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	event := models.NotificationEvent{
+		Type:         models.NotificationTeamAdded,
+		ActorID:      actorID,
+		RecipientIDs: userIDs,
+		Title:        fmt.Sprintf("Вас добавили в команду %s", team.Name),
+		EntityType:   "teams",
+		EntityID:     team.ID,
+		ProjectID:    team.ProjectID,
+	}
+	if err := s.notifications.Notify(event); err != nil {
+		s.logger.Warn("team notification failed", "team_id", team.ID, "err", err)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"log/slog"
+	"slices"
 
 	"back-minijira-petproject1/internal/models"
 	"back-minijira-petproject1/internal/repository"
@@ -20,16 +21,18 @@ type UserService interface {
 }
 
 type userService struct {
-	repo   repository.UserRepository
-	db     *gorm.DB
-	logger *slog.Logger
+	repo          repository.UserRepository
+	db            *gorm.DB
+	logger        *slog.Logger
+	notifications NotificationService
 }
 
-func NewUserService(repo repository.UserRepository, db *gorm.DB, logger *slog.Logger) UserService {
+func NewUserService(repo repository.UserRepository, db *gorm.DB, logger *slog.Logger, notifications NotificationService) UserService {
 	return &userService{
-		repo:   repo,
-		db:     db,
-		logger: logger,
+		repo:          repo,
+		db:            db,
+		logger:        logger,
+		notifications: notifications,
 	}
 }
 
@@ -44,9 +47,11 @@ func (s *userService) CreateUser(req models.UserCreateReq) (models.UserResponse,
 		return models.UserResponse{}, err
 	}
 
-	if err := s.repo.AssignTasksToUser(&user, req.TaskIDs); err != nil {
+	tasks, err := s.repo.AssignTasksToUser(&user, req.TaskIDs)
+	if err != nil {
 		return models.UserResponse{}, err
 	}
+	s.notifyAssigned(user.ID, tasks, nil, 0)
 
 	_, taskIDs, err := s.repo.GetUserByID(user.ID)
 	if err != nil {
@@ -59,7 +64,10 @@ func (s *userService) CreateUser(req models.UserCreateReq) (models.UserResponse,
 		ID:       user.ID,
 		FullName: user.FullName,
 		IsAdmin:  user.IsAdmin,
+		Locale:   user.Locale,
 		TaskIDs:  taskIDs,
+
+		Capacity: user.Capacity,
 	}, nil
 }
 
@@ -78,12 +86,15 @@ func (s *userService) GetUserByID(id uint) (models.UserResponse, error) {
 		ID:       user.ID,
 		FullName: user.FullName,
 		IsAdmin:  user.IsAdmin,
+		Locale:   user.Locale,
 		TaskIDs:  taskIDs,
+
+		Capacity: user.Capacity,
 	}, nil
 }
 
 func (s *userService) UpdateUser(id uint, req models.UserUpdateReq, currentUser models.User) error {
-	user, _, err := s.repo.GetUserByID(id)
+	user, previousTaskIDs, err := s.repo.GetUserByID(id)
 	if err != nil {
 		return err
 	}
@@ -99,13 +110,45 @@ func (s *userService) UpdateUser(id uint, req models.UserUpdateReq, currentUser
 		user.FullName = *req.FullName
 	}
 
+	if req.Locale != nil {
+		user.Locale = models.NormalizeLocale(*req.Locale)
+	}
+
+	if req.Capacity != nil {
+		if !currentUser.IsAdmin {
+			return errors.New("only admins can change capacity")
+		}
+		user.Capacity = *req.Capacity
+	}
+
+	var assigned []models.Task
 	if req.TaskIDs != nil {
-		if err := s.repo.AssignTasksToUser(&user, req.TaskIDs); err != nil {
+		assigned, err = s.repo.AssignTasksToUser(&user, req.TaskIDs)
+		if err != nil {
 			return err
 		}
 	}
 
-	return s.repo.UpdateUser(&user)
+	if err := s.repo.UpdateUser(&user); err != nil {
+		return err
+	}
+
+	s.notifyAssigned(user.ID, assigned, previousTaskIDs, currentUser.ID)
+	return nil
+}
+
+// notifyAssigned сообщает пользователю о задачах, которых не было среди previousTaskIDs,
+// так же как при назначении через задачу. Ошибка уведомления не отменяет назначение.
+func (s *userService) notifyAssigned(userID uint, tasks []models.Task, previousTaskIDs []uint, actorID uint) {
+	for i := range tasks {
+		if slices.Contains(previousTaskIDs, tasks[i].ID) {
+			continue
+		}
+		event := taskAssignedEvent(&tasks[i], []uint{userID}, actorID)
+		if err := s.notifications.Notify(event); err != nil {
+			s.logger.Warn("failed to send task notification", "op", "service.user.notifyAssigned", "task_id", tasks[i].ID, "user_id", userID, "err", err)
+		}
+	}
 }
 
 func (s *userService) checkUserPermission(currentUser, targetUser models.User) error {
@@ -161,7 +204,10 @@ func (s *userService) ListUsers() ([]models.UserResponse, error) {
 			ID:       user.ID,
 			FullName: user.FullName,
 			IsAdmin:  user.IsAdmin,
+			Locale:   user.Locale,
 			TaskIDs:  taskIDs,
+
+			Capacity: user.Capacity,
 		})
 	}
 
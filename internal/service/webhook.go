@@ -0,0 +1,414 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	webhookPollInterval = 5 * time.Second
+	webhookBatchSize    = 20
+	webhookLease        = 2 * time.Minute
+	webhookMaxAttempts  = 8
+	webhookBaseBackoff  = 30 * time.Second
+	webhookMaxBackoff   = time.Hour
+	webhookTimeout      = 10 * time.Second
+	// webhookMaxResponse — сколько байт ответа получателя сохраняем в журнал доставок
+	webhookMaxResponse = 2048
+
+	WebhookSignatureHeader = "X-MiniJira-Signature-256"
+	WebhookEventHeader     = "X-MiniJira-Event"
+	WebhookDeliveryHeader  = "X-MiniJira-Delivery"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookInactive         = errors.New("webhook is inactive")
+	ErrProjectNotFound         = errors.New("project not found")
+)
+
+type WebhookService interface {
+	EventPublisher
+	Run(ctx context.Context)
+	Create(projectID uint, req models.WebhookCreateReq) (models.WebhookResponse, error)
+	List(projectID uint) ([]models.WebhookResponse, error)
+	Update(id uint, req models.WebhookUpdateReq) (models.WebhookResponse, error)
+	Delete(id uint) error
+	ListDeliveries(webhookID uint, limit, offset int) ([]models.WebhookDelivery, error)
+	Redeliver(deliveryID uint) (*models.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	repo        repository.WebhookRepository
+	projectRepo repository.ProjectRepository
+	client      *http.Client
+	logger      *slog.Logger
+}
+
+func NewWebhookService(repo repository.WebhookRepository, projectRepo repository.ProjectRepository, logger *slog.Logger) WebhookService {
+	return &webhookService{
+		repo:        repo,
+		projectRepo: projectRepo,
+		client:      &http.Client{Timeout: webhookTimeout},
+		logger:      logger,
+	}
+}
+
+// Publish записывает доставку каждому подписанному вебхуку проекта; отправкой занимается Run.
+// На внутренние события (task.updated, task.assigned и т.п.) подписаться нельзя,
+// поэтому для них база не запрашивается.
+func (s *webhookService) Publish(event models.DomainEvent) {
+	if event.ProjectID == 0 || !slices.Contains(models.WebhookEventTypes, event.Type) {
+		return
+	}
+
+	webhooks, err := s.repo.ListSubscribed(event.ProjectID, event.Type)
+	if err != nil {
+		s.logger.Error("failed to find webhooks for event", "op", "service.webhook.Publish", "type", event.Type, "err", err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("failed to marshal event", "op", "service.webhook.Publish", "type", event.Type, "err", err)
+		return
+	}
+
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, 0, len(webhooks))
+	for _, w := range webhooks {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     w.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+
+	if err := s.repo.CreateDeliveries(deliveries); err != nil {
+		s.logger.Error("failed to enqueue webhook deliveries", "op", "service.webhook.Publish", "type", event.Type, "err", err)
+		return
+	}
+	s.logger.Info("webhook deliveries enqueued", "op", "service.webhook.Publish", "type", event.Type, "project_id", event.ProjectID, "count", len(deliveries))
+}
+
+// Run отправляет доставки, пока не отменён ctx
+func (s *webhookService) Run(ctx context.Context) {
+	s.logger.Info("webhook worker started", "op", "service.webhook.Run")
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("webhook worker stopped", "op", "service.webhook.Run")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *webhookService) deliverDue(ctx context.Context) {
+	deliveries, err := s.repo.ClaimDueDeliveries(time.Now(), webhookLease, webhookBatchSize)
+	if err != nil {
+		s.logger.Error("failed to claim webhook deliveries", "op", "service.webhook.deliverDue", "err", err)
+		return
+	}
+
+	webhooks := make(map[uint]*models.Webhook)
+	// unavailable — вебхуки, которые не удалось загрузить из-за сбоя базы, а не из-за удаления
+	unavailable := make(map[uint]bool)
+	for _, d := range deliveries {
+		if unavailable[d.WebhookID] {
+			continue
+		}
+		w, ok := webhooks[d.WebhookID]
+		if !ok {
+			w, err = s.repo.GetByID(d.WebhookID)
+			if err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					// Доставку не трогаем: аренда истечёт, и её заберёт следующий проход
+					s.logger.Error("failed to load webhook", "op", "service.webhook.deliverDue", "webhook_id", d.WebhookID, "err", err)
+					unavailable[d.WebhookID] = true
+					continue
+				}
+				w = nil
+			}
+			webhooks[d.WebhookID] = w
+		}
+		s.deliver(ctx, d, w)
+	}
+}
+
+func (s *webhookService) deliver(ctx context.Context, d models.WebhookDelivery, w *models.Webhook) {
+	attempts := d.Attempts + 1
+
+	// Удалённый или выключенный вебхук повторять бессмысленно
+	if w == nil || !w.Active {
+		if err := s.repo.MarkDeliveryFailed(d.ID, attempts, time.Now(), 0, "", ErrWebhookInactive.Error(), true); err != nil {
+			s.logger.Error("failed to record webhook delivery failure", "id", d.ID, "err", err)
+		}
+		return
+	}
+
+	status, body, sendErr := s.send(ctx, w, d)
+	if sendErr == nil {
+		if err := s.repo.MarkDeliverySuccess(d.ID, attempts, status, body); err != nil {
+			s.logger.Error("failed to mark webhook delivered", "id", d.ID, "err", err)
+			return
+		}
+		s.logger.Info("webhook delivered", "op", "service.webhook.deliver", "id", d.ID, "webhook_id", w.ID, "status", status)
+		return
+	}
+
+	// Остановка сервера — не ошибка получателя: аренда истечёт, и доставка уйдёт после перезапуска
+	if ctx.Err() != nil {
+		return
+	}
+
+	dead := attempts >= webhookMaxAttempts
+	next := time.Now().Add(retryBackoff(attempts, webhookBaseBackoff, webhookMaxBackoff))
+	if err := s.repo.MarkDeliveryFailed(d.ID, attempts, next, status, body, sendErr.Error(), dead); err != nil {
+		s.logger.Error("failed to record webhook delivery failure", "id", d.ID, "err", err)
+		return
+	}
+
+	if dead {
+		s.logger.Error("webhook delivery gave up", "op", "service.webhook.deliver", "id", d.ID, "webhook_id", w.ID, "attempts", attempts, "err", sendErr)
+		return
+	}
+	s.logger.Warn("webhook delivery failed, will retry", "op", "service.webhook.deliver", "id", d.ID, "attempts", attempts, "next_attempt_at", next, "err", sendErr)
+}
+
+func (s *webhookService) send(ctx context.Context, w *models.Webhook, d models.WebhookDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MiniJira-Webhooks")
+	req.Header.Set(WebhookEventHeader, d.EventType)
+	req.Header.Set(WebhookDeliveryHeader, fmt.Sprint(d.ID))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(w.Secret, []byte(d.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxResponse))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(body), fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// SignWebhookPayload возвращает значение заголовка подписи: "sha256=" + hex(HMAC-SHA256(secret, body)).
+// Получатель считает ту же подпись от сырого тела запроса и сравнивает через hmac.Equal.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *webhookService) Create(projectID uint, req models.WebhookCreateReq) (models.WebhookResponse, error) {
+	if _, err := s.projectRepo.GetProjectByID(projectID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.WebhookResponse{}, ErrProjectNotFound
+		}
+		return models.WebhookResponse{}, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret = generateWebhookSecret()
+	}
+
+	webhook := models.Webhook{
+		ProjectID: projectID,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    strings.Join(req.Events, ","),
+		Active:    true,
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if err := s.repo.Create(&webhook); err != nil {
+		return models.WebhookResponse{}, err
+	}
+	// Gorm не пишет false в поле с default:true при создании
+	if !webhook.Active {
+		if err := s.repo.Update(&webhook); err != nil {
+			return models.WebhookResponse{}, err
+		}
+	}
+
+	resp := buildWebhookResponse(webhook)
+	resp.Secret = secret
+	return resp, nil
+}
+
+func (s *webhookService) List(projectID uint) ([]models.WebhookResponse, error) {
+	webhooks, err := s.repo.ListByProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]models.WebhookResponse, 0, len(webhooks))
+	for _, w := range webhooks {
+		resp = append(resp, buildWebhookResponse(w))
+	}
+	return resp, nil
+}
+
+func (s *webhookService) Update(id uint, req models.WebhookUpdateReq) (models.WebhookResponse, error) {
+	webhook, err := s.getWebhook(id)
+	if err != nil {
+		return models.WebhookResponse{}, err
+	}
+
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Events != nil {
+		webhook.Events = strings.Join(*req.Events, ",")
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	if req.Secret != nil {
+		webhook.Secret = *req.Secret
+		if webhook.Secret == "" {
+			webhook.Secret = generateWebhookSecret()
+		}
+	}
+
+	if err := s.repo.Update(webhook); err != nil {
+		return models.WebhookResponse{}, err
+	}
+
+	resp := buildWebhookResponse(*webhook)
+	if req.Secret != nil {
+		resp.Secret = webhook.Secret
+	}
+	return resp, nil
+}
+
+func (s *webhookService) Delete(id uint) error {
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWebhookNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(webhookID uint, limit, offset int) ([]models.WebhookDelivery, error) {
+	if _, err := s.getWebhook(webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.repo.ListDeliveries(webhookID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+	return deliveries, nil
+}
+
+// Redeliver создаёт новую доставку с тем же телом и событием; исходная запись журнала не меняется
+func (s *webhookService) Redeliver(deliveryID uint) (*models.WebhookDelivery, error) {
+	original, err := s.repo.GetDelivery(deliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	webhook, err := s.getWebhook(original.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.Active {
+		return nil, ErrWebhookInactive
+	}
+
+	batch := []models.WebhookDelivery{{
+		WebhookID:     original.WebhookID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+		RedeliveryOf:  &original.ID,
+	}}
+	if err := s.repo.CreateDeliveries(batch); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("webhook redelivery queued", "op", "service.webhook.Redeliver", "original_id", original.ID, "id", batch[0].ID)
+	return &batch[0], nil
+}
+
+func (s *webhookService) getWebhook(id uint) (*models.Webhook, error) {
+	webhook, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func buildWebhookResponse(w models.Webhook) models.WebhookResponse {
+	events := []string{}
+	if w.Events != "" {
+		events = strings.Split(w.Events, ",")
+	}
+	return models.WebhookResponse{
+		ID:        w.ID,
+		ProjectID: w.ProjectID,
+		URL:       w.URL,
+		Events:    events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func generateWebhookSecret() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
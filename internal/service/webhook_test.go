@@ -0,0 +1,43 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"testing"
+)
+
+type fakeWebhookRepo struct {
+	repository.WebhookRepository
+	lookups    []string
+	deliveries []models.WebhookDelivery
+}
+
+func (r *fakeWebhookRepo) ListSubscribed(projectID uint, eventType string) ([]models.Webhook, error) {
+	r.lookups = append(r.lookups, eventType)
+	w := models.Webhook{ProjectID: projectID, Events: eventType, Active: true}
+	w.ID = 1
+	return []models.Webhook{w}, nil
+}
+
+func (r *fakeWebhookRepo) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	r.deliveries = append(r.deliveries, deliveries...)
+	return nil
+}
+
+func TestWebhookPublishSkipsInternalEvents(t *testing.T) {
+	repo := &fakeWebhookRepo{}
+	svc := NewWebhookService(repo, nil, newTestLogger())
+
+	task := &models.Task{Title: "Task", ProjectID: 4}
+	svc.Publish(NewDomainEvent(models.EventTaskUpdated, 4, taskEventData(task, "todo")))
+	svc.Publish(NewDomainEvent(models.EventTaskDeleted, 4, taskEventData(task, "todo")))
+	svc.Publish(taskAssignedDomainEvent(task, []uint{1}, 0))
+	if len(repo.lookups) != 0 {
+		t.Fatalf("internal events must not query webhooks, got lookups for %v", repo.lookups)
+	}
+
+	svc.Publish(NewDomainEvent(models.EventTaskCreated, 4, taskEventData(task, "todo")))
+	if len(repo.lookups) != 1 || len(repo.deliveries) != 1 || repo.deliveries[0].EventType != models.EventTaskCreated {
+		t.Fatalf("task.created: lookups %v, deliveries %d", repo.lookups, len(repo.deliveries))
+	}
+}
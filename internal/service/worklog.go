@@ -0,0 +1,252 @@
+package service
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/repository"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTimerAlreadyRunning = errors.New("another timer is already running")
+	ErrNoRunningTimer      = errors.New("no running timer")
+	ErrWorklogNotFound     = errors.New("worklog not found")
+	ErrWorklogTaskNotFound = errors.New("task not found")
+	ErrWorklogForbidden    = errors.New("you cannot change another user's worklog")
+	ErrWorklogRunning      = errors.New("stop the timer before editing the worklog")
+	ErrWorklogInFuture     = errors.New("worklog cannot end in the future")
+)
+
+// WorklogService ведёт учёт времени: таймеры (один запущенный на пользователя) и ручные записи
+type WorklogService interface {
+	StartTimer(taskID, userID uint, req models.TimerStartReq) (models.WorklogResponse, error)
+	StopTimer(userID uint) (models.WorklogResponse, error)
+	// RunningTimer возвращает nil, если таймер не запущен
+	RunningTimer(userID uint) (*models.WorklogResponse, error)
+	Create(taskID, userID uint, req models.WorklogCreateReq) (models.WorklogResponse, error)
+	Update(id uint, req models.WorklogUpdateReq, currentUser models.User) (models.WorklogResponse, error)
+	Delete(id uint, currentUser models.User) error
+	ListByTask(taskID uint) ([]models.WorklogResponse, error)
+}
+
+type worklogService struct {
+	db       *gorm.DB
+	repo     repository.WorklogRepository
+	taskRepo repository.TaskRepository
+	logger   *slog.Logger
+}
+
+func NewWorklogService(db *gorm.DB, repo repository.WorklogRepository, taskRepo repository.TaskRepository, logger *slog.Logger) WorklogService {
+	return &worklogService{db: db, repo: repo, taskRepo: taskRepo, logger: logger}
+}
+
+func (s *worklogService) StartTimer(taskID, userID uint, req models.TimerStartReq) (models.WorklogResponse, error) {
+	task, err := s.task(taskID)
+	if err != nil {
+		return models.WorklogResponse{}, err
+	}
+
+	worklog := models.Worklog{
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		UserID:    userID,
+		StartedAt: time.Now(),
+		Source:    models.WorklogSourceTimer,
+		Comment:   req.Comment,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithDB(tx)
+		if err := repo.LockUser(userID); err != nil {
+			return err
+		}
+		running, err := repo.GetRunning(userID)
+		if err != nil {
+			return err
+		}
+		if running != nil {
+			return ErrTimerAlreadyRunning
+		}
+		return repo.Create(&worklog)
+	})
+	if err != nil {
+		return models.WorklogResponse{}, err
+	}
+
+	s.logger.Info("timer started", "op", "service.worklog.StartTimer", "task_id", taskID, "user_id", userID)
+	return buildWorklogResponse(worklog, worklog.StartedAt), nil
+}
+
+func (s *worklogService) StopTimer(userID uint) (models.WorklogResponse, error) {
+	running, err := s.repo.GetRunning(userID)
+	if err != nil {
+		return models.WorklogResponse{}, err
+	}
+	if running == nil {
+		return models.WorklogResponse{}, ErrNoRunningTimer
+	}
+
+	now := time.Now()
+	running.EndedAt = &now
+	if err := s.repo.Save(running); err != nil {
+		return models.WorklogResponse{}, err
+	}
+
+	s.logger.Info("timer stopped", "op", "service.worklog.StopTimer", "task_id", running.TaskID, "user_id", userID,
+		"seconds", int64(now.Sub(running.StartedAt).Seconds()))
+	return buildWorklogResponse(*running, now), nil
+}
+
+func (s *worklogService) RunningTimer(userID uint) (*models.WorklogResponse, error) {
+	running, err := s.repo.GetRunning(userID)
+	if err != nil || running == nil {
+		return nil, err
+	}
+	resp := buildWorklogResponse(*running, time.Now())
+	return &resp, nil
+}
+
+func (s *worklogService) Create(taskID, userID uint, req models.WorklogCreateReq) (models.WorklogResponse, error) {
+	task, err := s.task(taskID)
+	if err != nil {
+		return models.WorklogResponse{}, err
+	}
+
+	now := time.Now()
+	duration := time.Duration(req.DurationMinutes) * time.Minute
+	startedAt := now.Add(-duration)
+	if req.StartedAt != nil {
+		startedAt = *req.StartedAt
+	}
+	endedAt := startedAt.Add(duration)
+	if endedAt.After(now) {
+		return models.WorklogResponse{}, ErrWorklogInFuture
+	}
+
+	worklog := models.Worklog{
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		UserID:    userID,
+		StartedAt: startedAt,
+		EndedAt:   &endedAt,
+		Source:    models.WorklogSourceManual,
+		Comment:   req.Comment,
+	}
+	if err := s.repo.Create(&worklog); err != nil {
+		return models.WorklogResponse{}, err
+	}
+
+	s.logger.Info("worklog created", "op", "service.worklog.Create", "task_id", taskID, "user_id", userID, "minutes", req.DurationMinutes)
+	return buildWorklogResponse(worklog, now), nil
+}
+
+func (s *worklogService) Update(id uint, req models.WorklogUpdateReq, currentUser models.User) (models.WorklogResponse, error) {
+	worklog, err := s.editable(id, currentUser)
+	if err != nil {
+		return models.WorklogResponse{}, err
+	}
+
+	if req.Comment != nil {
+		worklog.Comment = *req.Comment
+	}
+	if req.StartedAt != nil || req.DurationMinutes != nil {
+		if worklog.EndedAt == nil {
+			return models.WorklogResponse{}, ErrWorklogRunning
+		}
+		duration := worklog.EndedAt.Sub(worklog.StartedAt)
+		if req.DurationMinutes != nil {
+			duration = time.Duration(*req.DurationMinutes) * time.Minute
+		}
+		if req.StartedAt != nil {
+			worklog.StartedAt = *req.StartedAt
+		}
+		endedAt := worklog.StartedAt.Add(duration)
+		if endedAt.After(time.Now()) {
+			return models.WorklogResponse{}, ErrWorklogInFuture
+		}
+		worklog.EndedAt = &endedAt
+	}
+
+	if err := s.repo.Save(worklog); err != nil {
+		return models.WorklogResponse{}, err
+	}
+	return buildWorklogResponse(*worklog, time.Now()), nil
+}
+
+func (s *worklogService) Delete(id uint, currentUser models.User) error {
+	if _, err := s.editable(id, currentUser); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWorklogNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *worklogService) ListByTask(taskID uint) ([]models.WorklogResponse, error) {
+	worklogs, err := s.repo.ListByTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	resp := make([]models.WorklogResponse, 0, len(worklogs))
+	for _, w := range worklogs {
+		resp = append(resp, buildWorklogResponse(w, now))
+	}
+	return resp, nil
+}
+
+func (s *worklogService) task(taskID uint) (*models.Task, error) {
+	task, err := s.taskRepo.GetTaskByID(taskID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorklogTaskNotFound
+		}
+		return nil, err
+	}
+	return task, nil
+}
+
+// editable загружает запись, которую текущий пользователь вправе менять: свою или любую для админа
+func (s *worklogService) editable(id uint, currentUser models.User) (*models.Worklog, error) {
+	worklog, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWorklogNotFound
+		}
+		return nil, err
+	}
+	if worklog.UserID != currentUser.ID && !currentUser.IsAdmin {
+		return nil, ErrWorklogForbidden
+	}
+	return worklog, nil
+}
+
+// buildWorklogResponse считает длительность; у запущенного таймера — по момент now
+func buildWorklogResponse(w models.Worklog, now time.Time) models.WorklogResponse {
+	end := now
+	if w.EndedAt != nil {
+		end = *w.EndedAt
+	}
+	duration := end.Sub(w.StartedAt)
+
+	return models.WorklogResponse{
+		ID:              w.ID,
+		TaskID:          w.TaskID,
+		ProjectID:       w.ProjectID,
+		UserID:          w.UserID,
+		StartedAt:       w.StartedAt,
+		EndedAt:         w.EndedAt,
+		Running:         w.EndedAt == nil,
+		DurationSeconds: int64(duration.Seconds()),
+		DurationHuman:   formatDuration(duration),
+		Source:          w.Source,
+		Comment:         w.Comment,
+	}
+}
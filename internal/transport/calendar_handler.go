@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"back-minijira-petproject1/internal/middleware"
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarHandler struct {
+	service service.CalendarService
+	logger  *slog.Logger
+}
+
+func NewCalendarHandler(service service.CalendarService, logger *slog.Logger) *CalendarHandler {
+	return &CalendarHandler{service: service, logger: logger}
+}
+
+func (h *CalendarHandler) RegisterRoutes(r *gin.Engine, authService service.AuthService) {
+	authToken := r.Group("/users/me/calendar-token")
+	authToken.Use(middleware.AuthMiddleware(authService))
+	{
+		authToken.POST("", h.CreateToken)
+		authToken.DELETE("", h.RevokeToken)
+	}
+
+	// Календарные приложения не умеют отправлять JWT, поэтому лента доступна по токену в пути
+	r.GET("/calendar/:token", h.Feed)
+}
+
+func (h *CalendarHandler) CreateToken(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	token, err := h.service.CreateToken(currentUser.ID)
+	if err != nil {
+		h.logger.Error("CreateCalendarToken failed", "user_id", currentUser.ID, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+func (h *CalendarHandler) RevokeToken(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	if err := h.service.RevokeToken(currentUser.ID); err != nil {
+		if errors.Is(err, service.ErrCalendarTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("RevokeCalendarToken failed", "user_id", currentUser.ID, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	feed, err := h.service.Feed(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrCalendarTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("CalendarFeed failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build calendar"})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="minijira.ics"`)
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", feed)
+}
@@ -4,6 +4,7 @@ import (
 	"back-minijira-petproject1/internal/middleware"
 	"back-minijira-petproject1/internal/models"
 	"back-minijira-petproject1/internal/service"
+	"errors"
 	"log/slog"
 
 	"net/http"
@@ -22,17 +23,35 @@ func NewChatHandler(chatService service.ChatService, logger *slog.Logger) *ChatH
 }
 
 func (h *ChatHandler) SetupChatRoutes(r *gin.Engine, authService service.AuthService) {
-	authChat := r.Group("/chat/:type/:id") // type = "projects" | "tasks"
+	authChat := r.Group("/chat/:type/:id") // type = "projects" | "tasks" | "teams" | "direct"
 	authChat.Use(middleware.AuthMiddleware(authService))
 	{
 		authChat.POST("/", h.AddMessage)
 		authChat.GET("/", h.GetMessages)
+		authChat.POST("/:messageId/reactions", h.AddReaction)
+		authChat.DELETE("/:messageId/reactions", h.RemoveReaction)
+		authChat.GET("/:messageId/thread", h.GetThread)
+		authChat.POST("/read", h.MarkAsRead)
+		authChat.GET("/seen-by", h.GetSeenBy)
+	}
+
+	authDirect := r.Group("/chat/direct")
+	authDirect.Use(middleware.AuthMiddleware(authService))
+	{
+		authDirect.GET("", h.ListDirectConversations)
+		authDirect.POST("", h.CreateDirectConversation)
+	}
+
+	authMe := r.Group("/users/me")
+	authMe.Use(middleware.AuthMiddleware(authService))
+	{
+		authMe.GET("/unread", h.GetUnread)
 	}
 
 }
 
 func (h *ChatHandler) AddMessage(c *gin.Context) {
-	chatType := c.Param("type") // "projects" / "tasks"
+	chatType := c.Param("type") // "projects" / "tasks" / "teams" / "direct"
 	chatIDStr := c.Param("id")
 	chatID, err := strconv.Atoi(chatIDStr)
 	if err != nil {
@@ -41,15 +60,16 @@ func (h *ChatHandler) AddMessage(c *gin.Context) {
 	}
 
 	// Валидируем тип чата
-	if chatType != "projects" && chatType != "tasks" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat type, must be 'projects' or 'tasks'"})
+	if !service.IsValidChatType(chatType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidChatType.Error()})
 		return
 	}
 
-	// Создаем структуру для валидации только user_id и text
+	// Автор сообщения берётся из токена, user_id в теле запроса игнорируется
 	var input struct {
-		UserID uint   `json:"user_id" binding:"required"`
-		Text   string `json:"text" binding:"required,min=1,max=5000"`
+		Text       string `json:"text" binding:"required,min=1,max=5000"`
+		ParentID   *uint  `json:"parent_id"`
+		MentionIDs []uint `json:"mention_ids"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -58,31 +78,27 @@ func (h *ChatHandler) AddMessage(c *gin.Context) {
 		return
 	}
 
-	// Проверяем доступ для задач
-	if chatType == "tasks" {
-		hasAccess, err := h.chatService.CanUserAccessTask(c.Request.Context(), uint(chatID), input.UserID)
-		if err != nil {
-			h.logger.Error("ошибка при проверке прав доступа", "op", "ChatHandler.AddMessage", "error", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify access"})
-			return
-		}
-		if !hasAccess {
-			h.logger.Warn("попытка добавить комментарий без доступа", "user_id", input.UserID, "task_id", chatID)
-			c.JSON(http.StatusForbidden, gin.H{"error": "you don't have access to this task"})
-			return
-		}
+	currentUser := c.MustGet("currentUser").(models.User)
+	if !h.checkChatAccess(c, chatType, uint(chatID), currentUser, "ChatHandler.AddMessage") {
+		return
 	}
 
 	// Создаем полную структуру для сервиса
 	req := models.ChatMessageCreateReq{
-		UserID:       input.UserID,
+		UserID:       currentUser.ID,
 		Text:         input.Text,
 		ChatableID:   uint(chatID),
 		ChatableType: chatType,
+		ParentID:     input.ParentID,
+		MentionIDs:   input.MentionIDs,
 	}
 
 	msg, err := h.chatService.AddMessage(c.Request.Context(), req)
 
+	if errors.Is(err, service.ErrParentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.Error("failed to add message", "op", "ChatHandler.AddMessage", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -107,16 +123,25 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 	}
 
 	// Валидируем тип чата
-	if chatType != "projects" && chatType != "tasks" {
+	if !service.IsValidChatType(chatType) {
 		h.logger.Warn("invalid chat type", "op", "ChatHandler.GetMessages", "type", chatType)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat type, must be 'projects' or 'tasks'"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidChatType.Error()})
 		return
 	}
 
+	currentUser := c.MustGet("currentUser").(models.User)
+	if !h.checkChatAccess(c, chatType, uint(chatID), currentUser, "ChatHandler.GetMessages") {
+		return
+	}
+
+	// top_level=true возвращает только корневые сообщения тредов с количеством ответов
+	topLevelOnly := c.Query("top_level") == "true"
+
 	messages, err := h.chatService.GetMessages(
 		c.Request.Context(),
 		chatType,
 		uint(chatID),
+		topLevelOnly,
 	)
 
 	if err != nil {
@@ -128,3 +153,259 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 	h.logger.Info("messages retrieved", "op", "ChatHandler.GetMessages", "count", len(messages), "type", chatType, "id", chatID)
 	c.JSON(http.StatusOK, messages)
 }
+
+func (h *ChatHandler) AddReaction(c *gin.Context) {
+	chatType, chatID, messageID, ok := h.parseMessageParams(c, "ChatHandler.AddReaction")
+	if !ok {
+		return
+	}
+
+	var req models.ChatReactionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("invalid reaction body", "op", "ChatHandler.AddReaction", "err", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+	if !h.checkChatAccess(c, chatType, chatID, currentUser, "ChatHandler.AddReaction") {
+		return
+	}
+
+	reactions, err := h.chatService.AddReaction(c.Request.Context(), chatType, chatID, messageID, currentUser.ID, req.Emoji)
+	if err != nil {
+		h.respondReactionError(c, err, "ChatHandler.AddReaction")
+		return
+	}
+
+	h.logger.Info("reaction added", "op", "ChatHandler.AddReaction", "message_id", messageID, "user_id", currentUser.ID)
+	c.JSON(http.StatusOK, gin.H{"message_id": messageID, "reactions": reactions})
+}
+
+func (h *ChatHandler) RemoveReaction(c *gin.Context) {
+	chatType, chatID, messageID, ok := h.parseMessageParams(c, "ChatHandler.RemoveReaction")
+	if !ok {
+		return
+	}
+
+	// DELETE без тела: эмодзи передаётся в query, но JSON тоже принимаем
+	emoji := c.Query("emoji")
+	if emoji == "" {
+		var req models.ChatReactionReq
+		if err := c.ShouldBindJSON(&req); err == nil {
+			emoji = req.Emoji
+		}
+	}
+	if emoji == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "emoji is required"})
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+	if !h.checkChatAccess(c, chatType, chatID, currentUser, "ChatHandler.RemoveReaction") {
+		return
+	}
+
+	reactions, err := h.chatService.RemoveReaction(c.Request.Context(), chatType, chatID, messageID, currentUser.ID, emoji)
+	if err != nil {
+		h.respondReactionError(c, err, "ChatHandler.RemoveReaction")
+		return
+	}
+
+	h.logger.Info("reaction removed", "op", "ChatHandler.RemoveReaction", "message_id", messageID, "user_id", currentUser.ID)
+	c.JSON(http.StatusOK, gin.H{"message_id": messageID, "reactions": reactions})
+}
+
+func (h *ChatHandler) GetThread(c *gin.Context) {
+	chatType, chatID, messageID, ok := h.parseMessageParams(c, "ChatHandler.GetThread")
+	if !ok {
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+	if !h.checkChatAccess(c, chatType, chatID, currentUser, "ChatHandler.GetThread") {
+		return
+	}
+
+	thread, err := h.chatService.GetThread(c.Request.Context(), chatType, chatID, messageID)
+	if err != nil {
+		if errors.Is(err, service.ErrMessageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("failed to get thread", "op", "ChatHandler.GetThread", "error", err, "message_id", messageID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("thread retrieved", "op", "ChatHandler.GetThread", "message_id", thread.Parent.ID, "replies", len(thread.Replies))
+	c.JSON(http.StatusOK, thread)
+}
+
+func (h *ChatHandler) MarkAsRead(c *gin.Context) {
+	chatType, chatID, ok := h.parseChatParams(c, "ChatHandler.MarkAsRead")
+	if !ok {
+		return
+	}
+
+	// Тело необязательно: без message_id чат отмечается прочитанным до последнего сообщения
+	var req models.ChatMarkReadReq
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			return
+		}
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+	if !h.checkChatAccess(c, chatType, chatID, currentUser, "ChatHandler.MarkAsRead") {
+		return
+	}
+
+	if err := h.chatService.MarkAsRead(c.Request.Context(), chatType, chatID, currentUser.ID, req.MessageID); err != nil {
+		if errors.Is(err, service.ErrMessageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("failed to mark chat as read", "op", "ChatHandler.MarkAsRead", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "chat marked as read"})
+}
+
+func (h *ChatHandler) GetSeenBy(c *gin.Context) {
+	chatType, chatID, ok := h.parseChatParams(c, "ChatHandler.GetSeenBy")
+	if !ok {
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+	if !h.checkChatAccess(c, chatType, chatID, currentUser, "ChatHandler.GetSeenBy") {
+		return
+	}
+
+	seenBy, err := h.chatService.GetSeenBy(c.Request.Context(), chatType, chatID)
+	if err != nil {
+		h.logger.Error("failed to get seen-by", "op", "ChatHandler.GetSeenBy", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, seenBy)
+}
+
+func (h *ChatHandler) GetUnread(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	summary, err := h.chatService.GetUnread(c.Request.Context(), currentUser.ID)
+	if err != nil {
+		h.logger.Error("failed to get unread counts", "op", "ChatHandler.GetUnread", "error", err, "user_id", currentUser.ID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("unread counts retrieved", "op", "ChatHandler.GetUnread", "user_id", currentUser.ID, "total", summary.Total)
+	c.JSON(http.StatusOK, summary)
+}
+
+func (h *ChatHandler) CreateDirectConversation(c *gin.Context) {
+	var req models.DirectConversationCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("invalid direct conversation body", "op", "ChatHandler.CreateDirectConversation", "err", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	conv, err := h.chatService.CreateDirectConversation(c.Request.Context(), currentUser.ID, req.UserIDs)
+	if err != nil {
+		if errors.Is(err, service.ErrDirectTooFewUsers) || errors.Is(err, service.ErrDirectUserNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("failed to create direct conversation", "op", "ChatHandler.CreateDirectConversation", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Info("direct conversation ready", "op", "ChatHandler.CreateDirectConversation", "conversation_id", conv.ID)
+	c.JSON(http.StatusOK, conv)
+}
+
+func (h *ChatHandler) ListDirectConversations(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	conversations, err := h.chatService.ListDirectConversations(c.Request.Context(), currentUser.ID)
+	if err != nil {
+		h.logger.Error("failed to list direct conversations", "op", "ChatHandler.ListDirectConversations", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conversations)
+}
+
+// parseChatParams разбирает :type и :id из пути
+func (h *ChatHandler) parseChatParams(c *gin.Context, op string) (string, uint, bool) {
+	chatType := c.Param("type")
+	if !service.IsValidChatType(chatType) {
+		h.logger.Warn("invalid chat type", "op", op, "type", chatType)
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidChatType.Error()})
+		return "", 0, false
+	}
+
+	chatID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || chatID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chat ID format"})
+		return "", 0, false
+	}
+
+	return chatType, uint(chatID), true
+}
+
+// parseMessageParams разбирает :type, :id и :messageId из пути
+func (h *ChatHandler) parseMessageParams(c *gin.Context, op string) (string, uint, uint, bool) {
+	chatType, chatID, ok := h.parseChatParams(c, op)
+	if !ok {
+		return "", 0, 0, false
+	}
+
+	messageID, err := strconv.Atoi(c.Param("messageId"))
+	if err != nil || messageID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message ID format"})
+		return "", 0, 0, false
+	}
+
+	return chatType, chatID, uint(messageID), true
+}
+
+// checkChatAccess пишет ответ и возвращает false, если у пользователя нет доступа к чату
+func (h *ChatHandler) checkChatAccess(c *gin.Context, chatType string, chatID uint, user models.User, op string) bool {
+	hasAccess, err := h.chatService.CanUserAccessChat(c.Request.Context(), chatType, chatID, user)
+	if err != nil {
+		h.logger.Error("ошибка при проверке прав доступа", "op", op, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify access"})
+		return false
+	}
+	if !hasAccess {
+		h.logger.Warn("попытка доступа к чату без прав", "op", op, "user_id", user.ID, "type", chatType, "id", chatID)
+		c.JSON(http.StatusForbidden, gin.H{"error": "you don't have access to this chat"})
+		return false
+	}
+	return true
+}
+
+func (h *ChatHandler) respondReactionError(c *gin.Context, err error, op string) {
+	switch {
+	case errors.Is(err, service.ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEmojiEmpty), errors.Is(err, service.ErrUserIdZero):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("reaction update failed", "op", op, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,162 @@
+package transport
+
+import (
+	"back-minijira-petproject1/internal/middleware"
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChatOpsHandler struct {
+	service  service.ChatOpsService
+	commands service.ChatOpsCommandService
+	logger   *slog.Logger
+}
+
+func NewChatOpsHandler(service service.ChatOpsService, commands service.ChatOpsCommandService, logger *slog.Logger) *ChatOpsHandler {
+	return &ChatOpsHandler{service: service, commands: commands, logger: logger}
+}
+
+func (h *ChatOpsHandler) RegisterRoutes(r *gin.Engine, authService service.AuthService) {
+	admin := r.Group("/admin/projects/:id/chatops")
+	admin.Use(middleware.AuthMiddleware(authService), middleware.RequireAdmin())
+	{
+		admin.GET("", h.Get)
+		admin.PUT("", h.Save)
+		admin.DELETE("", h.Delete)
+		admin.POST("/test", h.SendTest)
+	}
+
+	// Slash-команду вызывает сервер чата без JWT: её подлинность проверяет токен команды
+	r.POST("/integrations/chatops/:id/command", h.Command)
+}
+
+func (h *ChatOpsHandler) Get(c *gin.Context) {
+	projectID, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	integration, err := h.service.Get(projectID)
+	if err != nil {
+		h.respondError(c, "Get chat-ops integration failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, integration)
+}
+
+func (h *ChatOpsHandler) Save(c *gin.Context) {
+	projectID, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ChatOpsSaveReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	integration, err := h.service.Save(projectID, req)
+	if err != nil {
+		h.respondError(c, "Save chat-ops integration failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, integration)
+}
+
+func (h *ChatOpsHandler) Delete(c *gin.Context) {
+	projectID, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(projectID); err != nil {
+		h.respondError(c, "Delete chat-ops integration failed", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ChatOpsHandler) SendTest(c *gin.Context) {
+	projectID, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SendTest(c.Request.Context(), projectID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrChatOpsNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrChatOpsNoWebhook):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			// Ошибка получателя — не ошибка сервера: отдаём её администратору как есть
+			h.logger.Warn("chat-ops test message failed", "project_id", projectID, "err", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "test message sent"})
+}
+
+func (h *ChatOpsHandler) Command(c *gin.Context) {
+	projectID, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	var cmd models.SlashCommand
+	if err := c.ShouldBind(&cmd); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Mattermost дублирует токен в заголовке Authorization: Token <token>
+	if cmd.Token == "" {
+		cmd.Token = strings.TrimPrefix(c.GetHeader("Authorization"), "Token ")
+	}
+
+	resp, err := h.commands.Handle(projectID, cmd)
+	if err != nil {
+		if errors.Is(err, service.ErrChatOpsInvalidToken) {
+			h.logger.Warn("slash command with invalid token", "project_id", projectID, "ip", c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("slash command failed", "project_id", projectID, "err", err)
+		c.JSON(http.StatusOK, models.SlashCommandResponse{ResponseType: "ephemeral", Text: "Не удалось выполнить команду, попробуйте позже"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *ChatOpsHandler) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrChatOpsNotFound),
+		errors.Is(err, service.ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(msg, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func parseProjectID(c *gin.Context) (uint, bool) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || projectID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return 0, false
+	}
+	return uint(projectID), true
+}
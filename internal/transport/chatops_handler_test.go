@@ -0,0 +1,100 @@
+package transport
+
+import (
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/service"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type fakeChatOpsRepo struct {
+	integrations map[uint]*models.ChatOpsIntegration
+}
+
+func (r *fakeChatOpsRepo) GetByProject(projectID uint) (*models.ChatOpsIntegration, error) {
+	integration, ok := r.integrations[projectID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return integration, nil
+}
+
+func (r *fakeChatOpsRepo) Save(*models.ChatOpsIntegration) error { return nil }
+func (r *fakeChatOpsRepo) Delete(uint) error                     { return nil }
+
+func TestSlashCommandChecksToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	sum := sha256.Sum256([]byte("s3cret"))
+	repo := &fakeChatOpsRepo{integrations: map[uint]*models.ChatOpsIntegration{
+		5: {ProjectID: 5, CommandTokenHash: hex.EncodeToString(sum[:]), Active: true},
+		6: {ProjectID: 6, CommandTokenHash: hex.EncodeToString(sum[:]), Active: false},
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	commands := service.NewChatOpsCommandService(repo, nil, logger)
+
+	r := gin.New()
+	NewChatOpsHandler(nil, commands, logger).RegisterRoutes(r, nil)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		projectID string
+		form      url.Values
+		header    string
+		want      int
+	}{
+		{name: "valid token", projectID: "5", form: url.Values{"token": {"s3cret"}, "text": {"help"}}, want: http.StatusOK},
+		{name: "mattermost header", projectID: "5", form: url.Values{"text": {"help"}}, header: "Token s3cret", want: http.StatusOK},
+		{name: "wrong token", projectID: "5", form: url.Values{"token": {"guess"}, "text": {"help"}}, want: http.StatusUnauthorized},
+		{name: "missing token", projectID: "5", form: url.Values{"text": {"help"}}, want: http.StatusUnauthorized},
+		{name: "inactive integration", projectID: "6", form: url.Values{"token": {"s3cret"}}, want: http.StatusUnauthorized},
+		{name: "unknown project", projectID: "9", form: url.Values{"token": {"s3cret"}}, want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/integrations/chatops/"+tt.projectID+"/command", strings.NewReader(tt.form.Encode()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			var body models.SlashCommandResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.ResponseType != "ephemeral" || !strings.Contains(body.Text, "/minijira create") {
+				t.Errorf("unexpected help response %+v", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package transport
+
+import (
+	"back-minijira-petproject1/internal/middleware"
+	"back-minijira-petproject1/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailOutboxHandler struct {
+	service service.EmailOutboxService
+	logger  *slog.Logger
+}
+
+func NewEmailOutboxHandler(service service.EmailOutboxService, logger *slog.Logger) *EmailOutboxHandler {
+	return &EmailOutboxHandler{service: service, logger: logger}
+}
+
+func (h *EmailOutboxHandler) RegisterRoutes(r *gin.Engine, authService service.AuthService) {
+	adminEmails := r.Group("/admin/emails")
+	adminEmails.Use(middleware.AuthMiddleware(authService), middleware.RequireAdmin())
+	{
+		adminEmails.GET("/failed", h.ListFailed)
+		adminEmails.POST("/:id/resend", h.Resend)
+	}
+}
+
+func (h *EmailOutboxHandler) ListFailed(c *gin.Context) {
+	limit := 50
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 200 {
+		limit = l
+	}
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	messages, err := h.service.ListFailed(limit, offset)
+	if err != nil {
+		h.logger.Error("ListFailed emails failed", "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+func (h *EmailOutboxHandler) Resend(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email id"})
+		return
+	}
+
+	if err := h.service.Resend(uint(id)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmailNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrEmailAlreadySent):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("Resend email failed", "id", id, "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	h.logger.Info("email requeued", "id", id)
+	c.JSON(http.StatusOK, gin.H{"message": "email queued for delivery"})
+}
@@ -0,0 +1,39 @@
+package transport
+
+import (
+	"back-minijira-petproject1/internal/export"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFormat выбирает формат ответа: ?format= важнее заголовка Accept, по умолчанию JSON.
+// При неизвестном ?format= отвечает 400 и возвращает false.
+func exportFormat(c *gin.Context) (export.Format, bool) {
+	if value := c.Query("format"); value != "" {
+		format, err := export.ParseFormat(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return "", false
+		}
+		return format, true
+	}
+	return export.FormatForMIME(c.NegotiateFormat(gin.MIMEJSON, export.MIMECSV, export.MIMEXLSX, export.MIMEPDF)), true
+}
+
+// writeExport отдаёт документ файлом. Заголовки уже отправлены к моменту ошибки записи,
+// поэтому её остаётся только залогировать — клиент получит оборванный файл.
+func writeExport(c *gin.Context, logger *slog.Logger, format export.Format, name string, doc export.Document) {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102"), format)
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	if err := export.Write(c.Writer, format, doc); err != nil {
+		logger.Error("export failed", "name", name, "format", format, "err", err)
+	}
+}
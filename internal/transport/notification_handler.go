@@ -0,0 +1,153 @@
+package transport
+
+import (
+	"back-minijira-petproject1/internal/middleware"
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	service  service.NotificationService
+	settings service.NotificationSettingsService
+	logger   *slog.Logger
+}
+
+func NewNotificationHandler(service service.NotificationService, settings service.NotificationSettingsService, logger *slog.Logger) *NotificationHandler {
+	return &NotificationHandler{service: service, settings: settings, logger: logger}
+}
+
+func (h *NotificationHandler) RegisterRoutes(r *gin.Engine, authService service.AuthService) {
+	authNotifications := r.Group("/users/me/notifications")
+	authNotifications.Use(middleware.AuthMiddleware(authService))
+	{
+		authNotifications.GET("", h.List)
+		authNotifications.GET("/unread-count", h.UnreadCount)
+		authNotifications.POST("/:id/read", h.MarkRead)
+		authNotifications.POST("/read-all", h.MarkAllRead)
+	}
+
+	authSettings := r.Group("/users/me/notification-settings")
+	authSettings.Use(middleware.AuthMiddleware(authService))
+	{
+		authSettings.GET("", h.GetSettings)
+		authSettings.PUT("", h.UpdateSettings)
+	}
+}
+
+func (h *NotificationHandler) List(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	filter := models.NotificationFilter{
+		UnreadOnly: c.Query("unread") == "true",
+		Limit:      20,
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 && limit <= 100 {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+
+	notifications, err := h.service.List(currentUser.ID, filter)
+	if err != nil {
+		h.logger.Error("ListNotifications failed", "user_id", currentUser.ID, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	count, err := h.service.UnreadCount(currentUser.ID)
+	if err != nil {
+		h.logger.Error("UnreadCount failed", "user_id", currentUser.ID, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": count})
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification id"})
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	if err := h.service.MarkRead(uint(id), currentUser.ID); err != nil {
+		if errors.Is(err, service.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("MarkRead failed", "id", id, "user_id", currentUser.ID, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notification marked as read"})
+}
+
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	count, err := h.service.MarkAllRead(currentUser.ID)
+	if err != nil {
+		h.logger.Error("MarkAllRead failed", "user_id", currentUser.ID, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notifications marked as read", "count": count})
+}
+
+func (h *NotificationHandler) GetSettings(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	settings, err := h.settings.Get(currentUser.ID)
+	if err != nil {
+		h.logger.Error("GetNotificationSettings failed", "user_id", currentUser.ID, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *NotificationHandler) UpdateSettings(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	var req models.NotificationSettingsUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.settings.Update(currentUser.ID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTimezone),
+			errors.Is(err, service.ErrInvalidQuietHours),
+			errors.Is(err, service.ErrInvalidNotificationEvent),
+			errors.Is(err, service.ErrInvalidNotificationChannel):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("UpdateNotificationSettings failed", "user_id", currentUser.ID, "err", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
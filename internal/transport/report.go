@@ -1,8 +1,12 @@
 package transport
 
 import (
+	"back-minijira-petproject1/internal/export"
 	"back-minijira-petproject1/internal/middleware"
+	"back-minijira-petproject1/internal/models"
 	"back-minijira-petproject1/internal/service"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -12,10 +16,11 @@ import (
 
 type ReportHandler struct {
 	service service.ReportService
+	logger  *slog.Logger
 }
 
 func NewReportHandler(s service.ReportService, logger *slog.Logger) *ReportHandler {
-	return &ReportHandler{service: s}
+	return &ReportHandler{service: s, logger: logger}
 }
 
 func (h *ReportHandler) RegisterRoutes(r *gin.Engine,authService service.AuthService) {
@@ -26,10 +31,40 @@ func (h *ReportHandler) RegisterRoutes(r *gin.Engine,authService service.AuthSer
 		authReports.GET("/avg-time", h.GetAverageTime)
 		authReports.GET("/completion-percent", h.GetCompletionPercent)
 		authReports.GET("/user-tracker/:userId", h.GetUserTracker)
+		authReports.GET("/burndown", h.GetBurndown)
+		authReports.GET("/cumulative-flow", h.GetCumulativeFlow)
+		authReports.GET("/cycle-time", h.GetCycleTime)
+		authReports.GET("/throughput", h.GetThroughput)
+		authReports.GET("/time", h.GetProjectTime)
+	}
+
+	authTeams := r.Group("/teams/:id/reports")
+	authTeams.Use(middleware.AuthMiddleware(authService))
+	{
+		authTeams.GET("", h.GetTeam)
+	}
+
+	authWorkload := r.Group("/reports")
+	authWorkload.Use(middleware.AuthMiddleware(authService))
+	{
+		authWorkload.GET("/workload", h.GetWorkload)
+		authWorkload.GET("/time", h.GetTime)
+	}
+
+	admin := r.Group("/admin/reports")
+	admin.Use(middleware.AuthMiddleware(authService), middleware.RequireAdmin())
+	{
+		admin.GET("/throughput", h.GetThroughputAll)
+		admin.GET("/portfolio", h.GetPortfolio)
 	}
 }
 
 func (h *ReportHandler) GetTopWorkers(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
@@ -40,10 +75,15 @@ func (h *ReportHandler) GetTopWorkers(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	h.respond(c, format, fmt.Sprintf("project-%d-top-workers", id), data, func() export.Document { return topWorkersDocument(uint(id), data) })
 }
 
 func (h *ReportHandler) GetAverageTime(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
@@ -54,10 +94,15 @@ func (h *ReportHandler) GetAverageTime(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	h.respond(c, format, fmt.Sprintf("project-%d-avg-time", id), data, func() export.Document { return averageTimeDocument(uint(id), data) })
 }
 
 func (h *ReportHandler) GetCompletionPercent(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
@@ -68,10 +113,15 @@ func (h *ReportHandler) GetCompletionPercent(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	h.respond(c, format, fmt.Sprintf("project-%d-completion", id), data, func() export.Document { return completionPercentDocument(uint(id), data) })
 }
 
 func (h *ReportHandler) GetUserTracker(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
 	projectID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
@@ -88,5 +138,262 @@ func (h *ReportHandler) GetUserTracker(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, data)
+	h.respond(c, format, fmt.Sprintf("project-%d-user-%d-tracker", projectID, userID), data, func() export.Document { return userTrackerDocument(uint(projectID), data) })
+}
+
+// GetBurndown отдаёт ряды для burndown и burnup: ?from=&to=&interval=day|week&unit=count|estimate
+func (h *ReportHandler) GetBurndown(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	var query models.BurndownQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.Burndown(projectID, query)
+	if err != nil {
+		h.respondError(c, "GetBurndown failed", err)
+		return
+	}
+	h.respond(c, format, fmt.Sprintf("project-%d-burndown", projectID), data, func() export.Document { return burndownDocument(data) })
+}
+
+// GetCumulativeFlow отдаёт число задач в каждом статусе по дням: ?from=&to=&interval=day|week
+func (h *ReportHandler) GetCumulativeFlow(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	var query models.ReportRangeQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.CumulativeFlow(projectID, query)
+	if err != nil {
+		h.respondError(c, "GetCumulativeFlow failed", err)
+		return
+	}
+	h.respond(c, format, fmt.Sprintf("project-%d-cumulative-flow", projectID), data, func() export.Document { return cumulativeFlowDocument(data) })
+}
+
+// GetCycleTime отдаёт перцентили и гистограмму cycle time и lead time: ?from=&to=&priority=&assignee_id=
+func (h *ReportHandler) GetCycleTime(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	var query models.CycleTimeQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.CycleTime(projectID, query)
+	if err != nil {
+		h.respondError(c, "GetCycleTime failed", err)
+		return
+	}
+	h.respond(c, format, fmt.Sprintf("project-%d-cycle-time", projectID), data, func() export.Document { return cycleTimeDocument(data) })
+}
+
+// GetThroughput отдаёт число закрытых задач по интервалам: ?from=&to=&interval=day|week|month&window=
+func (h *ReportHandler) GetThroughput(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	projectID, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	var query models.ThroughputQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.Throughput(projectID, query)
+	if err != nil {
+		h.respondError(c, "GetThroughput failed", err)
+		return
+	}
+	h.respond(c, format, fmt.Sprintf("project-%d-throughput", projectID), data, func() export.Document { return throughputDocument(data) })
+}
+
+// GetThroughputAll — то же по всем проектам, только для админов
+func (h *ReportHandler) GetThroughputAll(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	var query models.ThroughputQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.ThroughputAll(query)
+	if err != nil {
+		h.respondError(c, "GetThroughputAll failed", err)
+		return
+	}
+	h.respond(c, format, "throughput", data, func() export.Document { return throughputDocument(data) })
+}
+
+// GetPortfolio — сводка по всем проектам: выполнение, просрочки, WIP, дни до дедлайна и здоровье
+func (h *ReportHandler) GetPortfolio(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.Portfolio()
+	if err != nil {
+		h.respondError(c, "GetPortfolio failed", err)
+		return
+	}
+	h.respond(c, format, "portfolio", data, func() export.Document { return portfolioDocument(data) })
+}
+
+// GetWorkload — нагрузка пользователей против их Capacity: ?project_id=&over_capacity=true
+func (h *ReportHandler) GetWorkload(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	var query models.WorkloadQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.Workload(query)
+	if err != nil {
+		h.respondError(c, "GetWorkload failed", err)
+		return
+	}
+	h.respond(c, format, "workload", data, func() export.Document { return workloadDocument(data) })
+}
+
+// GetProjectTime — записанное время против календарного по людям и задачам: ?from=&to=&user_id=
+func (h *ReportHandler) GetProjectTime(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+	var query models.TimeReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.ProjectTime(uint(id), query)
+	if err != nil {
+		h.respondError(c, "GetProjectTime failed", err)
+		return
+	}
+	h.respond(c, format, fmt.Sprintf("project-%d-time", id), data, func() export.Document { return projectTimeDocument(data) })
+}
+
+// GetTime — записанное время по всем проектам: ?from=&to=&user_id=
+func (h *ReportHandler) GetTime(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	var query models.TimeReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.Time(query)
+	if err != nil {
+		h.respondError(c, "GetTime failed", err)
+		return
+	}
+	h.respond(c, format, "time", data, func() export.Document { return timeDocument(data) })
+}
+
+// GetTeam — показатели команды по задачам её участников: ?from=&to=&interval=&window=
+func (h *ReportHandler) GetTeam(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid team id"})
+		return
+	}
+	var query models.ThroughputQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.Team(uint(id), query)
+	if err != nil {
+		h.respondError(c, "GetTeamReport failed", err)
+		return
+	}
+	h.respond(c, format, fmt.Sprintf("team-%d-report", id), data, func() export.Document { return teamDocument(data) })
+}
+
+// respond отдаёт отчёт JSON или файлом в выбранном формате
+func (h *ReportHandler) respond(c *gin.Context, format export.Format, name string, data any, document func() export.Document) {
+	if format == export.FormatJSON {
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	writeExport(c, h.logger, format, name, document())
+}
+
+func (h *ReportHandler) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrProjectNotFound), errors.Is(err, service.ErrTeamNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidReportRange),
+		errors.Is(err, service.ErrInvalidReportInterval),
+		errors.Is(err, service.ErrInvalidReportUnit):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(msg, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
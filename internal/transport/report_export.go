@@ -0,0 +1,565 @@
+package transport
+
+import (
+	"back-minijira-petproject1/internal/export"
+	"back-minijira-petproject1/internal/models"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Документы выгрузки для отчётов: те же данные, что в JSON, разложенные по колонкам
+
+func topWorkersDocument(projectID uint, data []models.WorkerStats) export.Document {
+	chart := export.Chart{Title: "Completed tasks", Kind: export.BarChart, Series: []export.Series{{Name: "Completed"}}}
+	for _, w := range data {
+		chart.Labels = append(chart.Labels, w.Name)
+		chart.Series[0].Values = append(chart.Series[0].Values, float64(w.CompletedTasks))
+	}
+
+	return export.Document{
+		Title:  fmt.Sprintf("Project %d: top workers", projectID),
+		Charts: []export.Chart{chart},
+		Sheets: []export.Sheet{{
+			Title: "Top workers",
+			Columns: []export.Column{
+				{Title: "User ID", Type: export.Integer},
+				{Title: "Name", Type: export.Text},
+				{Title: "Completed tasks", Type: export.Integer},
+			},
+			Rows: export.Rows(data, func(w models.WorkerStats) []any {
+				return []any{w.UserID, w.Name, w.CompletedTasks}
+			}),
+		}},
+	}
+}
+
+func averageTimeDocument(projectID uint, data models.AvgTimeDTO) export.Document {
+	return export.Document{
+		Title: fmt.Sprintf("Project %d: average time", projectID),
+		Sheets: []export.Sheet{{
+			Title: "Average time",
+			Columns: []export.Column{
+				{Title: "Tasks", Type: export.Integer},
+				{Title: "Completed", Type: export.Integer},
+				{Title: "Average, seconds", Type: export.Integer},
+				{Title: "Average", Type: export.Text},
+			},
+			Rows: export.Rows([]models.AvgTimeDTO{data}, func(d models.AvgTimeDTO) []any {
+				return []any{d.TasksCount, d.CompletedCount, d.AverageSeconds, d.AverageHuman}
+			}),
+		}},
+	}
+}
+
+func completionPercentDocument(projectID uint, data models.CompletionPercentDTO) export.Document {
+	return export.Document{
+		Title: fmt.Sprintf("Project %d: completion", projectID),
+		Sheets: []export.Sheet{{
+			Title: "Completion",
+			Columns: []export.Column{
+				{Title: "Total tasks", Type: export.Integer},
+				{Title: "Done tasks", Type: export.Integer},
+				{Title: "Percent", Type: export.Number},
+			},
+			Rows: export.Rows([]models.CompletionPercentDTO{data}, func(d models.CompletionPercentDTO) []any {
+				return []any{d.TotalTasks, d.DoneTasks, d.Percent}
+			}),
+		}},
+	}
+}
+
+func userTrackerDocument(projectID uint, data models.UserTrackerDTO) export.Document {
+	return export.Document{
+		Title: fmt.Sprintf("Project %d: user %d tracker", projectID, data.UserID),
+		Sheets: []export.Sheet{
+			{
+				Title: "Summary",
+				Columns: []export.Column{
+					{Title: "User ID", Type: export.Integer},
+					{Title: "In progress", Type: export.Integer},
+					{Title: "Done", Type: export.Integer},
+					{Title: "Total time, seconds", Type: export.Integer},
+					{Title: "Average time, seconds", Type: export.Integer},
+					{Title: "Logged time, seconds", Type: export.Integer},
+				},
+				Rows: export.Rows([]models.UserTrackerDTO{data}, func(d models.UserTrackerDTO) []any {
+					return []any{d.UserID, d.InProgress, d.Done, d.TotalTimeSeconds, d.AverageTimeSeconds, d.LoggedTimeSeconds}
+				}),
+			},
+			{
+				Title: "Active tasks",
+				Columns: []export.Column{
+					{Title: "Task ID", Type: export.Integer},
+					{Title: "Title", Type: export.Text},
+					{Title: "Started at", Type: export.DateTime},
+				},
+				Rows: export.Rows(data.ActiveTasks, func(t models.UserTrackerTaskDTO) []any {
+					return []any{t.TaskID, t.Title, reportTime(t.StartedAt, time.RFC3339)}
+				}),
+			},
+		},
+	}
+}
+
+func burndownDocument(data models.BurndownReport) export.Document {
+	burndown := export.Chart{Title: "Burndown", Series: []export.Series{{Name: "Remaining"}, {Name: "Ideal"}}}
+	burnup := export.Chart{Title: "Burnup", Series: []export.Series{{Name: "Scope"}, {Name: "Completed"}}}
+	for _, p := range data.Points {
+		burndown.Labels = append(burndown.Labels, p.Date)
+		burndown.Series[0].Values = append(burndown.Series[0].Values, export.Float(p.Remaining))
+		burndown.Series[1].Values = append(burndown.Series[1].Values, export.Float(p.Ideal))
+		burnup.Labels = append(burnup.Labels, p.Date)
+		burnup.Series[0].Values = append(burnup.Series[0].Values, export.Float(p.Scope))
+		burnup.Series[1].Values = append(burnup.Series[1].Values, export.Float(p.Completed))
+	}
+
+	return export.Document{
+		Title:  fmt.Sprintf("Project %d: burndown (%s, by %s)", data.ProjectID, data.Unit, data.Interval),
+		Charts: []export.Chart{burndown, burnup},
+		Sheets: []export.Sheet{{
+			Title: "Burndown",
+			Columns: []export.Column{
+				{Title: "Date", Type: export.Date},
+				{Title: "Scope", Type: export.Number},
+				{Title: "Completed", Type: export.Number},
+				{Title: "Remaining", Type: export.Number},
+				{Title: "Ideal", Type: export.Number},
+			},
+			Rows: export.Rows(data.Points, func(p models.BurndownPoint) []any {
+				return []any{reportTime(p.Date, "2006-01-02"), p.Scope, p.Completed, p.Remaining, p.Ideal}
+			}),
+		}},
+	}
+}
+
+func cumulativeFlowDocument(data models.CumulativeFlowReport) export.Document {
+	chart := export.Chart{Title: "Cumulative flow"}
+	columns := []export.Column{{Title: "Date", Type: export.Date}}
+	for _, status := range data.Statuses {
+		chart.Series = append(chart.Series, export.Series{Name: status})
+		columns = append(columns, export.Column{Title: status, Type: export.Integer})
+	}
+	columns = append(columns, export.Column{Title: "Total", Type: export.Integer})
+
+	for _, p := range data.Points {
+		chart.Labels = append(chart.Labels, p.Date)
+		for i, status := range data.Statuses {
+			chart.Series[i].Values = append(chart.Series[i].Values, float64(p.Counts[status]))
+		}
+	}
+
+	return export.Document{
+		Title:  fmt.Sprintf("Project %d: cumulative flow", data.ProjectID),
+		Charts: []export.Chart{chart},
+		Sheets: []export.Sheet{{
+			Title:   "Cumulative flow",
+			Columns: columns,
+			Rows: export.Rows(data.Points, func(p models.CumulativeFlowPoint) []any {
+				row := []any{reportTime(p.Date, "2006-01-02")}
+				for _, status := range data.Statuses {
+					row = append(row, p.Counts[status])
+				}
+				return append(row, p.Total)
+			}),
+		}},
+	}
+}
+
+func cycleTimeDocument(data models.CycleTimeReport) export.Document {
+	type statsRow struct {
+		metric, group string
+		stats         models.DurationStats
+	}
+	summary := []statsRow{
+		{"cycle time", "all", data.CycleTime},
+		{"lead time", "all", data.LeadTime},
+		{"cycle time", "reopened", data.Reopened.CycleTime},
+		{"lead time", "reopened", data.Reopened.LeadTime},
+	}
+
+	chart := export.Chart{
+		Title:  "Distribution",
+		Kind:   export.BarChart,
+		Series: []export.Series{{Name: "Cycle time"}, {Name: "Lead time"}},
+	}
+	for i, b := range data.CycleTime.Histogram {
+		chart.Labels = append(chart.Labels, b.Label)
+		chart.Series[0].Values = append(chart.Series[0].Values, float64(b.Count))
+		chart.Series[1].Values = append(chart.Series[1].Values, float64(data.LeadTime.Histogram[i].Count))
+	}
+
+	return export.Document{
+		Title:  fmt.Sprintf("Project %d: cycle and lead time", data.ProjectID),
+		Charts: []export.Chart{chart},
+		Sheets: []export.Sheet{
+			{
+				Title: "Summary",
+				Columns: []export.Column{
+					{Title: "Metric", Type: export.Text},
+					{Title: "Tasks", Type: export.Text},
+					{Title: "Count", Type: export.Integer},
+					{Title: "Min, s", Type: export.Integer},
+					{Title: "P50, s", Type: export.Integer},
+					{Title: "P75, s", Type: export.Integer},
+					{Title: "P85, s", Type: export.Integer},
+					{Title: "P95, s", Type: export.Integer},
+					{Title: "Max, s", Type: export.Integer},
+					{Title: "Mean, s", Type: export.Integer},
+				},
+				Rows: export.Rows(summary, func(r statsRow) []any {
+					s := r.stats
+					return []any{r.metric, r.group, s.Count, s.MinSeconds, s.P50Seconds, s.P75Seconds, s.P85Seconds, s.P95Seconds, s.MaxSeconds, s.MeanSeconds}
+				}),
+			},
+			{
+				Title: "Histogram",
+				Columns: []export.Column{
+					{Title: "Bucket", Type: export.Text},
+					{Title: "Cycle time", Type: export.Integer},
+					{Title: "Lead time", Type: export.Integer},
+					{Title: "Reopened cycle time", Type: export.Integer},
+					{Title: "Reopened lead time", Type: export.Integer},
+				},
+				Rows: func(yield func([]any) error) error {
+					for i, b := range data.CycleTime.Histogram {
+						row := []any{b.Label, b.Count, data.LeadTime.Histogram[i].Count,
+							data.Reopened.CycleTime.Histogram[i].Count, data.Reopened.LeadTime.Histogram[i].Count}
+						if err := yield(row); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func throughputDocument(data models.ThroughputReport) export.Document {
+	chart := export.Chart{Title: "Throughput", Series: []export.Series{{Name: "Completed"}, {Name: "Rolling average"}}}
+	for _, p := range data.Points {
+		chart.Labels = append(chart.Labels, p.Date)
+		chart.Series[0].Values = append(chart.Series[0].Values, float64(p.Completed))
+		chart.Series[1].Values = append(chart.Series[1].Values, p.RollingAverage)
+	}
+
+	title := "All projects: throughput"
+	if data.ProjectID != nil {
+		title = fmt.Sprintf("Project %d: throughput", *data.ProjectID)
+	}
+
+	return export.Document{
+		Title:  fmt.Sprintf("%s by %s", title, data.Interval),
+		Charts: []export.Chart{chart},
+		Sheets: []export.Sheet{{
+			Title: "Throughput",
+			Columns: []export.Column{
+				{Title: "Date", Type: export.Date},
+				{Title: "Completed", Type: export.Integer},
+				{Title: "Rolling average", Type: export.Number},
+			},
+			Rows: export.Rows(data.Points, func(p models.ThroughputPoint) []any {
+				return []any{reportTime(p.Date, "2006-01-02"), p.Completed, p.RollingAverage}
+			}),
+		}},
+	}
+}
+
+func portfolioDocument(data models.PortfolioReport) export.Document {
+	chart := export.Chart{
+		Title:  "Completion vs elapsed time",
+		Kind:   export.BarChart,
+		Series: []export.Series{{Name: "Completion, %"}, {Name: "Time elapsed, %"}},
+	}
+	for _, p := range data.Projects {
+		chart.Labels = append(chart.Labels, p.Title)
+		chart.Series[0].Values = append(chart.Series[0].Values, p.CompletionPercent)
+		chart.Series[1].Values = append(chart.Series[1].Values, export.Float(p.ExpectedPercent))
+	}
+
+	return export.Document{
+		Title:  "Portfolio",
+		Charts: []export.Chart{chart},
+		Sheets: []export.Sheet{{
+			Title: "Portfolio",
+			Columns: []export.Column{
+				{Title: "Project ID", Type: export.Integer},
+				{Title: "Title", Type: export.Text},
+				{Title: "Health", Type: export.Text},
+				{Title: "Reason", Type: export.Text},
+				{Title: "Completion, %", Type: export.Number},
+				{Title: "Tasks", Type: export.Integer},
+				{Title: "In progress", Type: export.Integer},
+				{Title: "Overdue", Type: export.Integer},
+				{Title: "Deadline", Type: export.Date},
+				{Title: "Days left", Type: export.Integer},
+			},
+			Rows: export.Rows(data.Projects, func(p models.PortfolioProject) []any {
+				return []any{p.ProjectID, p.Title, p.Health, p.HealthReason, p.CompletionPercent,
+					p.TotalTasks, p.InProgressTasks, p.OverdueTasks, p.TimeEnd, p.DaysLeft}
+			}),
+		}},
+	}
+}
+
+func workloadDocument(data models.WorkloadReport) export.Document {
+	chart := export.Chart{
+		Title:  "Load vs capacity",
+		Kind:   export.BarChart,
+		Series: []export.Series{{Name: "Weighted load"}, {Name: "Capacity"}},
+	}
+	for _, u := range data.Users {
+		chart.Labels = append(chart.Labels, u.Name)
+		chart.Series[0].Values = append(chart.Series[0].Values, u.WeightedLoad)
+		chart.Series[1].Values = append(chart.Series[1].Values, float64(u.Capacity))
+	}
+
+	return export.Document{
+		Title:  "Workload",
+		Charts: []export.Chart{chart},
+		Sheets: []export.Sheet{{
+			Title: "Workload",
+			Columns: []export.Column{
+				{Title: "User ID", Type: export.Integer},
+				{Title: "Name", Type: export.Text},
+				{Title: "Open tasks", Type: export.Integer},
+				{Title: "In progress", Type: export.Integer},
+				{Title: "Weighted load", Type: export.Number},
+				{Title: "Capacity", Type: export.Integer},
+				{Title: "Utilization, %", Type: export.Number},
+				{Title: "Over capacity", Type: export.Text},
+				{Title: "Oldest in progress, s", Type: export.Integer},
+			},
+			Rows: export.Rows(data.Users, func(u models.WorkloadUser) []any {
+				over := ""
+				if u.OverCapacity {
+					over = "yes"
+				}
+				return []any{u.UserID, u.Name, u.OpenTasks, u.InProgressTasks, u.WeightedLoad,
+					u.Capacity, u.UtilizationPercent, over, u.OldestInProgressSeconds}
+			}),
+		}},
+	}
+}
+
+// timeAmountColumns — общие колонки «записано против календаря»
+var timeAmountColumns = []export.Column{
+	{Title: "Logged, h", Type: export.Number},
+	{Title: "Calendar, h", Type: export.Number},
+	{Title: "Logged, %", Type: export.Number},
+}
+
+func timeAmountValues(t models.TimeAmount) []any {
+	return []any{hours(t.LoggedSeconds), hours(t.CalendarSeconds), t.LoggedPercent}
+}
+
+// hours переводит секунды в часы с двумя знаками: в таблицах часы читаются легче секунд
+func hours(seconds int64) float64 {
+	return math.Round(float64(seconds)/36) / 100
+}
+
+func projectTimeDocument(data models.ProjectTimeReport) export.Document {
+	chart := export.Chart{
+		Title:  "Logged vs calendar time, h",
+		Kind:   export.BarChart,
+		Series: []export.Series{{Name: "Logged"}, {Name: "Calendar"}},
+	}
+	for _, t := range data.Tasks {
+		chart.Labels = append(chart.Labels, t.Title)
+		chart.Series[0].Values = append(chart.Series[0].Values, hours(t.LoggedSeconds))
+		chart.Series[1].Values = append(chart.Series[1].Values, hours(t.CalendarSeconds))
+	}
+
+	return export.Document{
+		Title:  fmt.Sprintf("Project %d: time %s – %s", data.ProjectID, data.From, data.To),
+		Charts: []export.Chart{chart},
+		Sheets: []export.Sheet{
+			{
+				Title:   "Summary",
+				Columns: append([]export.Column{{Title: "From", Type: export.Date}, {Title: "To", Type: export.Date}}, timeAmountColumns...),
+				Rows: export.Rows([]models.ProjectTimeReport{data}, func(d models.ProjectTimeReport) []any {
+					return append([]any{reportTime(d.From, "2006-01-02"), reportTime(d.To, "2006-01-02")}, timeAmountValues(d.TimeAmount)...)
+				}),
+			},
+			{
+				Title: "Users",
+				Columns: []export.Column{
+					{Title: "User ID", Type: export.Integer},
+					{Title: "Name", Type: export.Text},
+					{Title: "Logged, h", Type: export.Number},
+				},
+				Rows: export.Rows(data.Users, func(u models.UserTime) []any {
+					return []any{u.UserID, u.Name, hours(u.LoggedSeconds)}
+				}),
+			},
+			{
+				Title:   "Tasks",
+				Columns: append([]export.Column{{Title: "Task ID", Type: export.Integer}, {Title: "Title", Type: export.Text}}, timeAmountColumns...),
+				Rows: export.Rows(data.Tasks, func(t models.TaskTime) []any {
+					return append([]any{t.TaskID, t.Title}, timeAmountValues(t.TimeAmount)...)
+				}),
+			},
+		},
+	}
+}
+
+func timeDocument(data models.TimeReport) export.Document {
+	type userProject struct {
+		user    models.UserTime
+		project models.ProjectLoggedTime
+	}
+	var rows []userProject
+	for _, u := range data.Users {
+		for _, p := range u.Projects {
+			rows = append(rows, userProject{user: u, project: p})
+		}
+	}
+
+	return export.Document{
+		Title: fmt.Sprintf("Time %s – %s", data.From, data.To),
+		Sheets: []export.Sheet{
+			{
+				Title: "Users by project",
+				Columns: []export.Column{
+					{Title: "User ID", Type: export.Integer},
+					{Title: "Name", Type: export.Text},
+					{Title: "Project ID", Type: export.Integer},
+					{Title: "Project", Type: export.Text},
+					{Title: "Logged, h", Type: export.Number},
+				},
+				Rows: export.Rows(rows, func(r userProject) []any {
+					return []any{r.user.UserID, r.user.Name, r.project.ProjectID, r.project.Title, hours(r.project.LoggedSeconds)}
+				}),
+			},
+			{
+				Title:   "Projects",
+				Columns: append([]export.Column{{Title: "Project ID", Type: export.Integer}, {Title: "Title", Type: export.Text}}, timeAmountColumns...),
+				Rows: export.Rows(data.Projects, func(p models.ProjectTime) []any {
+					return append([]any{p.ProjectID, p.Title}, timeAmountValues(p.TimeAmount)...)
+				}),
+			},
+		},
+	}
+}
+
+func snapshotsDocument(projectID uint, data []models.ReportSnapshotResponse) export.Document {
+	chart := export.Chart{Title: "Completion, %", Series: []export.Series{{Name: "Completion"}}}
+	for _, s := range data {
+		chart.Labels = append(chart.Labels, s.Day)
+		chart.Series[0].Values = append(chart.Series[0].Values, s.CompletionPercent)
+	}
+
+	return export.Document{
+		Title:  fmt.Sprintf("Project %d: daily snapshots", projectID),
+		Charts: []export.Chart{chart},
+		Sheets: []export.Sheet{{
+			Title: "Snapshots",
+			Columns: []export.Column{
+				{Title: "Day", Type: export.Date},
+				{Title: "Total", Type: export.Integer},
+				{Title: "Todo", Type: export.Integer},
+				{Title: "In progress", Type: export.Integer},
+				{Title: "Done", Type: export.Integer},
+				{Title: "Overdue", Type: export.Integer},
+				{Title: "Estimate total", Type: export.Number},
+				{Title: "Estimate done", Type: export.Number},
+				{Title: "Completion, %", Type: export.Number},
+				{Title: "Avg cycle, s", Type: export.Integer},
+				{Title: "Refreshed at", Type: export.DateTime},
+			},
+			Rows: export.Rows(data, func(s models.ReportSnapshotResponse) []any {
+				return []any{reportTime(s.Day, "2006-01-02"), s.TotalTasks, s.TodoTasks, s.InProgressTasks, s.DoneTasks,
+					s.OverdueTasks, s.EstimateTotal, s.EstimateDone, s.CompletionPercent, s.AvgCycleSeconds, s.RefreshedAt}
+			}),
+		}},
+	}
+}
+
+func teamDocument(data models.TeamReport) export.Document {
+	throughput := throughputDocument(data.Throughput)
+	cycle := data.CycleTime.CycleTime
+
+	sheets := []export.Sheet{
+		{
+			Title: "Summary",
+			Columns: []export.Column{
+				{Title: "Team ID", Type: export.Integer},
+				{Title: "Team", Type: export.Text},
+				{Title: "Project ID", Type: export.Integer},
+				{Title: "Members", Type: export.Integer},
+				{Title: "Total tasks", Type: export.Integer},
+				{Title: "Done tasks", Type: export.Integer},
+				{Title: "Completion, %", Type: export.Number},
+				{Title: "Completed in period", Type: export.Integer},
+				{Title: "Avg cycle, s", Type: export.Integer},
+				{Title: "P85 cycle, s", Type: export.Integer},
+			},
+			Rows: export.Rows([]models.TeamReport{data}, func(d models.TeamReport) []any {
+				return []any{d.TeamID, d.Name, d.ProjectID, len(d.MemberIDs), d.Completion.TotalTasks, d.Completion.DoneTasks,
+					d.Completion.Percent, d.Throughput.Total, cycle.MeanSeconds, cycle.P85Seconds}
+			}),
+		},
+		{
+			Title: "Contributors",
+			Columns: []export.Column{
+				{Title: "User ID", Type: export.Integer},
+				{Title: "Name", Type: export.Text},
+				{Title: "Completed", Type: export.Integer},
+				{Title: "In progress", Type: export.Integer},
+				{Title: "Logged, h", Type: export.Number},
+			},
+			Rows: export.Rows(data.TopContributors, func(u models.TeamContributor) []any {
+				return []any{u.UserID, u.Name, u.CompletedTasks, u.InProgressTasks, hours(u.LoggedSeconds)}
+			}),
+		},
+	}
+
+	return export.Document{
+		Title:  fmt.Sprintf("Team %s: %s – %s", data.Name, data.Throughput.From, data.Throughput.To),
+		Charts: throughput.Charts,
+		Sheets: append(sheets, throughput.Sheets...),
+	}
+}
+
+// taskListDocument выгружает задачи по мере чтения из базы через each
+func taskListDocument(each func(fn func(*models.TaskResponse) error) error) export.Document {
+	return export.Document{
+		Title: "Tasks",
+		Sheets: []export.Sheet{{
+			Title: "Tasks",
+			Columns: []export.Column{
+				{Title: "ID", Type: export.Integer},
+				{Title: "Title", Type: export.Text},
+				{Title: "Status", Type: export.Text},
+				{Title: "Priority", Type: export.Text},
+				{Title: "Project ID", Type: export.Integer},
+				{Title: "Assignees", Type: export.Text},
+				{Title: "Estimate", Type: export.Number},
+				{Title: "Started", Type: export.DateTime},
+				{Title: "Finished", Type: export.DateTime},
+				{Title: "Due", Type: export.DateTime},
+			},
+			Rows: func(yield func([]any) error) error {
+				return each(func(t *models.TaskResponse) error {
+					names := make([]string, 0, len(t.Users))
+					for _, u := range t.Users {
+						names = append(names, u.FullName)
+					}
+					return yield([]any{t.ID, t.Title, t.Status, t.Priority, t.ProjectID,
+						strings.Join(names, ", "), t.Estimate, t.StartTask, t.FinishTask, t.DueDate})
+				})
+			},
+		}},
+	}
+}
+
+// reportTime разбирает дату из DTO отчёта, чтобы в XLSX она попала типизированной ячейкой
+func reportTime(value, layout string) any {
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return value
+	}
+	return t
+}
@@ -0,0 +1,134 @@
+package transport
+
+import (
+	"back-minijira-petproject1/internal/export"
+	"back-minijira-petproject1/internal/middleware"
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/service"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportSnapshotHandler struct {
+	service service.ReportSnapshotService
+	logger  *slog.Logger
+}
+
+func NewReportSnapshotHandler(service service.ReportSnapshotService, logger *slog.Logger) *ReportSnapshotHandler {
+	return &ReportSnapshotHandler{service: service, logger: logger}
+}
+
+func (h *ReportSnapshotHandler) RegisterRoutes(r *gin.Engine, authService service.AuthService) {
+	authReports := r.Group("/projects/:id/reports")
+	authReports.Use(middleware.AuthMiddleware(authService))
+	{
+		authReports.GET("/summary", h.Summary)
+		authReports.GET("/snapshots", h.List)
+		authReports.GET("/compare", h.Compare)
+	}
+
+	admin := r.Group("/admin/reports/snapshots")
+	admin.Use(middleware.AuthMiddleware(authService), middleware.RequireAdmin())
+	{
+		admin.POST("/refresh", h.Refresh)
+	}
+}
+
+// Summary — сегодняшние показатели проекта из снимка, для дашборда
+func (h *ReportSnapshotHandler) Summary(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+
+	data, err := h.service.Summary(uint(id))
+	if err != nil {
+		h.respondError(c, "GetSnapshotSummary failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// List — история снимков по дням: ?from=&to=
+func (h *ReportSnapshotHandler) List(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+	var query models.ReportRangeQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.List(uint(id), query)
+	if err != nil {
+		h.respondError(c, "ListSnapshots failed", err)
+		return
+	}
+	if format == export.FormatJSON {
+		c.JSON(http.StatusOK, data)
+		return
+	}
+	writeExport(c, h.logger, format, fmt.Sprintf("project-%d-snapshots", id), snapshotsDocument(uint(id), data))
+}
+
+// Compare — сегодня против прошлого: ?days=7 или ?date=2024-01-31
+func (h *ReportSnapshotHandler) Compare(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+	var query models.SnapshotCompareQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.Compare(uint(id), query)
+	if err != nil {
+		h.respondError(c, "CompareSnapshots failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+// Refresh пересчитывает сегодняшние снимки сразу: ?project_id= или все проекты
+func (h *ReportSnapshotHandler) Refresh(c *gin.Context) {
+	var query models.SnapshotRefreshQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Refresh(query.ProjectID); err != nil {
+		h.respondError(c, "RefreshSnapshots failed", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ReportSnapshotHandler) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidReportRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(msg, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
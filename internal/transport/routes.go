@@ -19,6 +19,15 @@ func RegisterRoutes(
 	authService service.AuthService,
 	userRepo repository.UserRepository,
 	teamService service.TeamService,
+	notificationService service.NotificationService,
+	notificationSettingsService service.NotificationSettingsService,
+	emailOutboxService service.EmailOutboxService,
+	webhookService service.WebhookService,
+	calendarService service.CalendarService,
+	chatOpsService service.ChatOpsService,
+	chatOpsCommandService service.ChatOpsCommandService,
+	worklogService service.WorklogService,
+	reportSnapshotService service.ReportSnapshotService,
 ) {
 	taskHandler := NewTaskHandler(taskService, logger)
 	projectHandler := NewProjectHandler(projectService, logger)
@@ -27,6 +36,13 @@ func RegisterRoutes(
 	userHandler := NewUserHandler(userService, logger)
 	authHandler := NewAuthHandler(authService, logger)
 	teamHandler := NewTeamHandler(teamService, logger)
+	notificationHandler := NewNotificationHandler(notificationService, notificationSettingsService, logger)
+	emailOutboxHandler := NewEmailOutboxHandler(emailOutboxService, logger)
+	webhookHandler := NewWebhookHandler(webhookService, logger)
+	calendarHandler := NewCalendarHandler(calendarService, logger)
+	chatOpsHandler := NewChatOpsHandler(chatOpsService, chatOpsCommandService, logger)
+	worklogHandler := NewWorklogHandler(worklogService, logger)
+	reportSnapshotHandler := NewReportSnapshotHandler(reportSnapshotService, logger)
 
 	chatHandler.SetupChatRoutes(router, authService)
 	reportHandler.RegisterRoutes(router, authService)
@@ -35,5 +51,12 @@ func RegisterRoutes(
 	userHandler.RegisterRoutes(router, authService)
 	authHandler.SetupRoutes(router)
 	teamHandler.RegisterRoutes(router, authService)
+	notificationHandler.RegisterRoutes(router, authService)
+	emailOutboxHandler.RegisterRoutes(router, authService)
+	webhookHandler.RegisterRoutes(router, authService)
+	calendarHandler.RegisterRoutes(router, authService)
+	chatOpsHandler.RegisterRoutes(router, authService)
+	worklogHandler.RegisterRoutes(router, authService)
+	reportSnapshotHandler.RegisterRoutes(router, authService)
 
 }
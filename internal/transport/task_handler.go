@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"back-minijira-petproject1/internal/export"
 	"back-minijira-petproject1/internal/middleware"
 	"back-minijira-petproject1/internal/models"
 	"back-minijira-petproject1/internal/service"
@@ -54,6 +55,11 @@ func (h *TaskHandler) GetTaskByID(c *gin.Context) {
 }
 
 func (h *TaskHandler) ListTasks(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+
 	filter := models.TaskFilter{
 		Limit:  20,
 		Offset: 0,
@@ -96,6 +102,14 @@ func (h *TaskHandler) ListTasks(c *gin.Context) {
 		filter.SortOrder = &sortOrder
 	}
 
+	// Выгрузка отдаёт все задачи по фильтру без пагинации, читая их из базы пачками
+	if format != export.FormatJSON {
+		writeExport(c, h.logger, format, "tasks", taskListDocument(func(fn func(*models.TaskResponse) error) error {
+			return h.service.EachTask(&filter, fn)
+		}))
+		return
+	}
+
 	tasks, err := h.service.ListTasks(&filter)
 
 	if err != nil {
@@ -143,7 +157,9 @@ func (h *TaskHandler) Create(c *gin.Context) {
 		req.Status = "todo"
 	}
 
-	if err := h.service.CreateTask(&req); err != nil {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	if _, err := h.service.CreateTask(&req, currentUser.ID); err != nil {
 		h.logger.Error("failed to create task", "op", "task.handler.Create", "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create task"})
 		return
@@ -163,8 +179,9 @@ func (h *TaskHandler) Update(c *gin.Context) {
 	}
 
 	id, _ := strconv.Atoi(c.Param("id"))
+	currentUser := c.MustGet("currentUser").(models.User)
 
-	if err := h.service.UpdateTask(uint(id), req); err != nil {
+	if err := h.service.UpdateTask(uint(id), req, currentUser.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -190,7 +207,7 @@ func (h *TaskHandler) UnassignTask(c *gin.Context) {
 	taskID, _ := strconv.Atoi(c.Param("id"))
 	currentUser := c.MustGet("currentUser").(models.User)
 
-	if err := h.service.UnassignTaskFromUser(uint(taskID), currentUser.ID); err != nil {
+	if err := h.service.UnassignTaskFromUser(uint(taskID), currentUser.ID, currentUser.ID); err != nil {
 		h.logger.Error("UnassignTask failed", "task_id", taskID, "user_id", currentUser.ID, "err", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
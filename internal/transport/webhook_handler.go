@@ -0,0 +1,163 @@
+package transport
+
+import (
+	"back-minijira-petproject1/internal/middleware"
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	service service.WebhookService
+	logger  *slog.Logger
+}
+
+func NewWebhookHandler(service service.WebhookService, logger *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{service: service, logger: logger}
+}
+
+func (h *WebhookHandler) RegisterRoutes(r *gin.Engine, authService service.AuthService) {
+	admin := r.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(authService), middleware.RequireAdmin())
+	{
+		admin.GET("/projects/:id/webhooks", h.List)
+		admin.POST("/projects/:id/webhooks", h.Create)
+		admin.PATCH("/webhooks/:id", h.Update)
+		admin.DELETE("/webhooks/:id", h.Delete)
+		admin.GET("/webhooks/:id/deliveries", h.ListDeliveries)
+		admin.POST("/webhook-deliveries/:id/redeliver", h.Redeliver)
+	}
+}
+
+func (h *WebhookHandler) Create(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+
+	var req models.WebhookCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := h.service.Create(uint(projectID), req)
+	if err != nil {
+		h.respondError(c, "Create webhook failed", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+
+	webhooks, err := h.service.List(uint(projectID))
+	if err != nil {
+		h.respondError(c, "List webhooks failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return
+	}
+
+	var req models.WebhookUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := h.service.Update(uint(id), req)
+	if err != nil {
+		h.respondError(c, "Update webhook failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return
+	}
+
+	if err := h.service.Delete(uint(id)); err != nil {
+		h.respondError(c, "Delete webhook failed", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 200 {
+		limit = l
+	}
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	deliveries, err := h.service.ListDeliveries(uint(id), limit, offset)
+	if err != nil {
+		h.respondError(c, "List webhook deliveries failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery id"})
+		return
+	}
+
+	delivery, err := h.service.Redeliver(uint(id))
+	if err != nil {
+		h.respondError(c, "Redeliver webhook failed", err)
+		return
+	}
+
+	h.logger.Info("webhook redelivery queued", "original_id", id, "id", delivery.ID)
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func (h *WebhookHandler) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound),
+		errors.Is(err, service.ErrWebhookDeliveryNotFound),
+		errors.Is(err, service.ErrProjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWebhookInactive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(msg, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
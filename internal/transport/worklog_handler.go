@@ -0,0 +1,189 @@
+package transport
+
+import (
+	"back-minijira-petproject1/internal/middleware"
+	"back-minijira-petproject1/internal/models"
+	"back-minijira-petproject1/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorklogHandler struct {
+	service service.WorklogService
+	logger  *slog.Logger
+}
+
+func NewWorklogHandler(service service.WorklogService, logger *slog.Logger) *WorklogHandler {
+	return &WorklogHandler{service: service, logger: logger}
+}
+
+func (h *WorklogHandler) RegisterRoutes(r *gin.Engine, authService service.AuthService) {
+	authTasks := r.Group("/tasks")
+	authTasks.Use(middleware.AuthMiddleware(authService))
+	{
+		authTasks.POST("/:id/timer/start", h.StartTimer)
+		authTasks.GET("/:id/worklogs", h.ListByTask)
+		authTasks.POST("/:id/worklogs", h.Create)
+	}
+
+	authTimer := r.Group("/users/me/timer")
+	authTimer.Use(middleware.AuthMiddleware(authService))
+	{
+		authTimer.GET("", h.RunningTimer)
+		authTimer.POST("/stop", h.StopTimer)
+	}
+
+	authWorklogs := r.Group("/worklogs")
+	authWorklogs.Use(middleware.AuthMiddleware(authService))
+	{
+		authWorklogs.PATCH("/:id", h.Update)
+		authWorklogs.DELETE("/:id", h.Delete)
+	}
+}
+
+func (h *WorklogHandler) StartTimer(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+	taskID, ok := worklogParamID(c, "invalid task id")
+	if !ok {
+		return
+	}
+
+	var req models.TimerStartReq
+	// Тело необязательно: таймер можно запустить без комментария
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	worklog, err := h.service.StartTimer(taskID, currentUser.ID, req)
+	if err != nil {
+		h.respondError(c, "StartTimer failed", err)
+		return
+	}
+	c.JSON(http.StatusCreated, worklog)
+}
+
+func (h *WorklogHandler) StopTimer(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	worklog, err := h.service.StopTimer(currentUser.ID)
+	if err != nil {
+		h.respondError(c, "StopTimer failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, worklog)
+}
+
+func (h *WorklogHandler) RunningTimer(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+
+	timer, err := h.service.RunningTimer(currentUser.ID)
+	if err != nil {
+		h.respondError(c, "RunningTimer failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"timer": timer})
+}
+
+func (h *WorklogHandler) Create(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+	taskID, ok := worklogParamID(c, "invalid task id")
+	if !ok {
+		return
+	}
+
+	var req models.WorklogCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	worklog, err := h.service.Create(taskID, currentUser.ID, req)
+	if err != nil {
+		h.respondError(c, "CreateWorklog failed", err)
+		return
+	}
+	c.JSON(http.StatusCreated, worklog)
+}
+
+func (h *WorklogHandler) ListByTask(c *gin.Context) {
+	taskID, ok := worklogParamID(c, "invalid task id")
+	if !ok {
+		return
+	}
+
+	worklogs, err := h.service.ListByTask(taskID)
+	if err != nil {
+		h.respondError(c, "ListWorklogs failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, worklogs)
+}
+
+func (h *WorklogHandler) Update(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+	id, ok := worklogParamID(c, "invalid worklog id")
+	if !ok {
+		return
+	}
+
+	var req models.WorklogUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	worklog, err := h.service.Update(id, req, currentUser)
+	if err != nil {
+		h.respondError(c, "UpdateWorklog failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, worklog)
+}
+
+func (h *WorklogHandler) Delete(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(models.User)
+	id, ok := worklogParamID(c, "invalid worklog id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id, currentUser); err != nil {
+		h.respondError(c, "DeleteWorklog failed", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func worklogParamID(c *gin.Context, msg string) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *WorklogHandler) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrTimerAlreadyRunning), errors.Is(err, service.ErrWorklogRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNoRunningTimer),
+		errors.Is(err, service.ErrWorklogNotFound),
+		errors.Is(err, service.ErrWorklogTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWorklogForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWorklogInFuture):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(msg, "err", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}